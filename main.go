@@ -1,7 +1,7 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
 	"os"
 	"strings"
@@ -52,16 +52,27 @@ func printBanner() {
 }
 
 func main() {
+	deny := flag.String("deny", "", "comma-separated std modules that scripts cannot import, e.g., os,fs")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: zmol [-deny modules] [script [args...]]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
 	z := NewZmol()
+	z.state.Args = flag.Args()
 
 	nativeFuncRegistry := native.NewNativeFuncRegistry(z.state)
+	if *deny != "" {
+		nativeFuncRegistry.Policy.DeniedModules = strings.Split(*deny, ",")
+	}
 	nativeFuncRegistry.RegisterNativeFunc()
 
-	if len(os.Args) > 1 {
-		fileName := os.Args[1]
+	if flag.NArg() > 0 {
+		fileName := flag.Arg(0)
 		sourceCode, err := os.ReadFile(fileName)
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		z.state.Eval(string(sourceCode))
@@ -72,8 +83,7 @@ func main() {
 			fmt.Print(">>> ")
 			color.Unset()
 
-			in := bufio.NewReader(os.Stdin)
-			code, err := in.ReadString('\n')
+			code, err := z.state.Stdin.ReadString('\n')
 			if err != nil {
 				fmt.Println(err)
 				os.Exit(1)
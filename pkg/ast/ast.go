@@ -1,6 +1,10 @@
 package ast
 
-import "github.com/ariaghora/zmol/pkg/lexer"
+import (
+	"math/big"
+
+	"github.com/ariaghora/zmol/pkg/lexer"
+)
 
 type Node interface {
 	Literal() string
@@ -75,6 +79,7 @@ func (es *ExpressionStatement) Str() string     { return es.Expression.Str() }
 type IntegerLiteral struct {
 	Token lexer.ZTok
 	Value int64
+	Big   *big.Int // set instead of Value if the literal exceeds 64 bits
 }
 
 func (il *IntegerLiteral) expressionNode() {}
@@ -90,6 +95,17 @@ func (fl *FloatLiteral) expressionNode() {}
 func (ie *FloatLiteral) Literal() string { return ie.Token.Text }
 func (ie *FloatLiteral) Str() string     { return ie.Token.Text }
 
+// A decimal literal, e.g., 12.50d. The value is kept as text, so that no
+// precision is lost before evaluation.
+type DecimalLiteral struct {
+	Token lexer.ZTok
+	Value string
+}
+
+func (dl *DecimalLiteral) expressionNode() {}
+func (dl *DecimalLiteral) Literal() string { return dl.Token.Text }
+func (dl *DecimalLiteral) Str() string     { return dl.Token.Text }
+
 type StringLiteral struct {
 	Token lexer.ZTok
 	Value string
@@ -99,6 +115,17 @@ func (sl *StringLiteral) expressionNode() {}
 func (sl *StringLiteral) Literal() string { return sl.Token.Text }
 func (sl *StringLiteral) Str() string     { return sl.Token.Text }
 
+// A bytes literal such as b"\x89PNG". Value holds the bytes after escapes
+// are replaced.
+type BytesLiteral struct {
+	Token lexer.ZTok
+	Value string
+}
+
+func (bl *BytesLiteral) expressionNode() {}
+func (bl *BytesLiteral) Literal() string { return bl.Token.Text }
+func (bl *BytesLiteral) Str() string     { return bl.Token.Text }
+
 type BooleanLiteral struct {
 	Token lexer.ZTok
 	Value bool
@@ -136,6 +163,29 @@ func (ie *IndexExpression) Str() string {
 	return "(" + ie.Left.Str() + "[" + ie.Index.Str() + "])"
 }
 
+// A slice expression such as x[a:b]. Start and End are nil when omitted, as
+// in x[:b] and x[a:].
+type SliceExpression struct {
+	Token lexer.ZTok // the '[' token
+	Left  Expression
+	Start Expression
+	End   Expression
+}
+
+func (se *SliceExpression) expressionNode() {}
+func (se *SliceExpression) Literal() string { return se.Token.Text }
+func (se *SliceExpression) Str() string {
+	out := "(" + se.Left.Str() + "["
+	if se.Start != nil {
+		out += se.Start.Str()
+	}
+	out += ":"
+	if se.End != nil {
+		out += se.End.Str()
+	}
+	return out + "])"
+}
+
 type MemberAccessExpression struct {
 	Token  lexer.ZTok // the '.' token
 	Left   Expression
@@ -264,17 +314,32 @@ func (is *IterStatement) Str() string {
 	return is.Token.Text + " " + is.List.Str() + " " + is.Body.Str()
 }
 
+// Placeholder marks where the piped value goes in the extra arguments of a
+// pipeline, e.g., the `_` in `text |> split{_, ","}`.
+type Placeholder struct {
+	Token lexer.ZTok // the '_' token
+}
+
+func (p *Placeholder) expressionNode() {}
+func (p *Placeholder) Literal() string { return p.Token.Text }
+func (p *Placeholder) Str() string     { return p.Token.Text }
+
 type PipelineExpression struct {
 	Token       lexer.ZTok // the '|>' token
 	List        Expression
 	FuncLiteral Expression
 	ExtraArgs   []Expression
+	Parallel    bool // `-> par` and `>- par` apply the function on several goroutines
 }
 
 func (pe *PipelineExpression) expressionNode() {}
 func (pe *PipelineExpression) Literal() string { return pe.Token.Text }
 func (pe *PipelineExpression) Str() string {
-	out := pe.List.Str() + " " + pe.Token.Text + " " + pe.FuncLiteral.Str()
+	out := pe.List.Str() + " " + pe.Token.Text + " "
+	if pe.Parallel {
+		out += "par "
+	}
+	out += pe.FuncLiteral.Str()
 	for _, a := range pe.ExtraArgs {
 		out += " " + a.Str()
 	}
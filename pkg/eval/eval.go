@@ -1,8 +1,12 @@
 package eval
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
+	"io"
+	"math"
+	"math/big"
 	"os"
 
 	"github.com/ariaghora/zmol/pkg/ast"
@@ -15,8 +19,21 @@ import (
 
 type ZmolState struct {
 	Env *val.Env
+
+	// The standard streams of scripts. Hosts embedding the interpreter can
+	// replace them, e.g., to capture the output of print().
+	Stdin  *bufio.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// The command-line arguments of scripts, starting with the script path.
+	Args []string
 }
 
+// Standard input is shared by all states, so that no buffered input is lost
+// between them.
+var defaultStdin = bufio.NewReader(os.Stdin)
+
 func NewZmolState(ParentEnv *val.Env) *ZmolState {
 	symTable := make(map[string]val.ZValue)
 	// default __moddir__ is the current working directory unless
@@ -27,6 +44,9 @@ func NewZmolState(ParentEnv *val.Env) *ZmolState {
 			SymTable:  symTable,
 			ParentEnv: ParentEnv,
 		},
+		Stdin:  defaultStdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
 	}
 }
 
@@ -43,15 +63,15 @@ func (s *ZmolState) Eval(source string) (val.ZValue, error) {
 		return nil, err
 	}
 	if len(p.Errors()) != 0 {
-		s.printParserErrors(os.Stderr, p.Errors())
+		s.printParserErrors(p.Errors())
 		return val.ERROR("Parser errors"), errors.New("parser errors")
 	}
 	return s.EvalProgram(program), nil
 }
 
-func (s *ZmolState) printParserErrors(out *os.File, errors []string) {
+func (s *ZmolState) printParserErrors(errors []string) {
 	for _, msg := range errors {
-		color.Red(msg)
+		color.New(color.FgRed).Fprintln(s.Stderr, msg)
 	}
 }
 
@@ -62,7 +82,7 @@ func (s *ZmolState) EvalProgram(node ast.Node) val.ZValue {
 	case *ast.Identifier:
 		return s.evalIdentifier(node)
 	case *ast.VarrAssignmentStatement:
-		fmt.Println("WARNING: let is deprecated, omit the 'let' keyword")
+		fmt.Fprintln(s.Stderr, "WARNING: let is deprecated, omit the 'let' keyword")
 		val := s.EvalProgram(node.Value)
 		if isErr(val) {
 			return val
@@ -76,6 +96,8 @@ func (s *ZmolState) EvalProgram(node ast.Node) val.ZValue {
 		return s.EvalProgram(node.Expression)
 	case *ast.PipelineExpression:
 		return s.evalPipelineExpression(node)
+	case *ast.PrefixExpression:
+		return s.evalPrefixExpression(node)
 	case *ast.InfixExpression:
 		switch node.Operator {
 		case "=":
@@ -93,14 +115,20 @@ func (s *ZmolState) EvalProgram(node ast.Node) val.ZValue {
 		return s.evalIntegerLiteral(node)
 	case *ast.FloatLiteral:
 		return s.evalFloatLiteral(node)
+	case *ast.DecimalLiteral:
+		return s.evalDecimalLiteral(node)
 	case *ast.StringLiteral:
 		return &val.ZString{Value: node.Value}
+	case *ast.BytesLiteral:
+		return val.BYTES([]byte(node.Value))
 	case *ast.BooleanLiteral:
 		return s.evalBooleanLiteral(node)
 	case *ast.ListLiteral:
 		return s.evalListLiteral(node)
 	case *ast.IndexExpression:
 		return s.evalIndexExpression(node)
+	case *ast.SliceExpression:
+		return s.evalSliceExpression(node)
 	case *ast.MemberAccessExpression:
 		return s.evalMemberAccessExpression(node)
 	case *ast.FuncLiteral:
@@ -129,6 +157,9 @@ func (s *ZmolState) evalStatements(program *ast.Program) val.ZValue {
 }
 
 func (s *ZmolState) evalIntegerLiteral(il *ast.IntegerLiteral) val.ZValue {
+	if il.Big != nil {
+		return val.BIGINT(il.Big)
+	}
 	return &val.ZInt{Value: il.Value}
 }
 
@@ -136,6 +167,14 @@ func (s *ZmolState) evalFloatLiteral(fl *ast.FloatLiteral) val.ZValue {
 	return &val.ZFloat{Value: fl.Value}
 }
 
+func (s *ZmolState) evalDecimalLiteral(dl *ast.DecimalLiteral) val.ZValue {
+	d, err := val.ParseDecimal(dl.Value)
+	if err != nil {
+		return val.ERROR(err.Error())
+	}
+	return d
+}
+
 func (s *ZmolState) evalBooleanLiteral(bl *ast.BooleanLiteral) val.ZValue {
 	return &val.ZBool{Value: bl.Value}
 }
@@ -157,6 +196,12 @@ func (s *ZmolState) evalIndexExpression(ie *ast.IndexExpression) val.ZValue {
 		return s.evalListIndexExpression(left, index)
 	case left.Type() == val.ZSTRING && index.Type() == val.ZINT:
 		return s.evalStringIndexExpression(left, index)
+	case left.Type() == val.ZBYTES && index.Type() == val.ZINT:
+		return s.evalBytesIndexExpression(left, index)
+	case left.Type() == val.ZTENSOR && index.Type() == val.ZINT:
+		return left.(*val.ZTensor).Index(int(index.(*val.ZInt).Value))
+	case left.Type() == val.ZTABLE && index.Type() == val.ZSTRING:
+		return left.(*val.ZTable).Index(index.(*val.ZString).Value)
 	default:
 		return val.ERROR("cannot perform indexing on " + string(left.Type()) + " type")
 	}
@@ -204,16 +249,87 @@ func (s *ZmolState) evalListIndexExpression(list val.ZValue, index val.ZValue) v
 	return listVal.Elements[indexVal.Value]
 }
 
+// Strings are indexed by character, not by byte, so that indexing agrees with
+// len() and iteration on non-ASCII text.
 func (s *ZmolState) evalStringIndexExpression(str val.ZValue, index val.ZValue) val.ZValue {
-	strVal := str.(*val.ZString)
+	runes := []rune(str.(*val.ZString).Value)
 	indexVal := index.(*val.ZInt)
-	max := int64(len(strVal.Value) - 1)
+	max := int64(len(runes) - 1)
 
 	if indexVal.Value < 0 || indexVal.Value > max {
 		RuntimeErrorf("index out of range: %d", indexVal.Value)
 	}
 
-	return &val.ZString{Value: string(strVal.Value[indexVal.Value])}
+	return &val.ZString{Value: string(runes[indexVal.Value])}
+}
+
+func (s *ZmolState) evalBytesIndexExpression(b val.ZValue, index val.ZValue) val.ZValue {
+	bytesVal := b.(*val.ZBytes)
+	indexVal := index.(*val.ZInt)
+	max := int64(len(bytesVal.Value) - 1)
+
+	if indexVal.Value < 0 || indexVal.Value > max {
+		RuntimeErrorf("index out of range: %d", indexVal.Value)
+	}
+
+	return &val.ZInt{Value: int64(bytesVal.Value[indexVal.Value])}
+}
+
+// Slices a list, a string, by character, or bytes. Omitted bounds default to
+// the start and the end.
+func (s *ZmolState) evalSliceExpression(se *ast.SliceExpression) val.ZValue {
+	left := s.EvalProgram(se.Left)
+	if isErr(left) {
+		return left
+	}
+
+	var length int
+	var runes []rune
+	switch left := left.(type) {
+	case *val.ZList:
+		length = len(left.Elements)
+	case *val.ZString:
+		runes = []rune(left.Value)
+		length = len(runes)
+	case *val.ZBytes:
+		length = len(left.Value)
+	default:
+		return val.ERROR("cannot perform slicing on " + string(left.Type()) + " type")
+	}
+
+	bound := func(e ast.Expression, dflt int) (int, val.ZValue) {
+		if e == nil {
+			return dflt, nil
+		}
+		v := s.EvalProgram(e)
+		if isErr(v) {
+			return 0, v
+		}
+		if v.Type() != val.ZINT {
+			return 0, val.ERROR("slice bounds must be Int, got " + string(v.Type()))
+		}
+		return int(v.(*val.ZInt).Value), nil
+	}
+	start, err := bound(se.Start, 0)
+	if err != nil {
+		return err
+	}
+	end, err := bound(se.End, length)
+	if err != nil {
+		return err
+	}
+	if start < 0 || end > length || start > end {
+		RuntimeErrorf("slice bounds out of range: [%d:%d] with length %d", start, end, length)
+	}
+
+	switch left := left.(type) {
+	case *val.ZList:
+		return &val.ZList{Elements: append([]val.ZValue{}, left.Elements[start:end]...)}
+	case *val.ZString:
+		return &val.ZString{Value: string(runes[start:end])}
+	default:
+		return val.BYTES(append([]byte{}, left.(*val.ZBytes).Value[start:end]...))
+	}
 }
 
 func (s *ZmolState) evalMemberAssignment(mae *ast.MemberAccessExpression, value val.ZValue) val.ZValue {
@@ -244,6 +360,10 @@ func (s *ZmolState) evalIndexAssignment(ie *ast.IndexExpression, value val.ZValu
 	if left.Type() == val.ZLIST && index.Type() == val.ZINT {
 		return s.evalListIndexAssignment(left, index, value)
 	}
+	if left.Type() == val.ZTABLE && index.Type() == val.ZSTRING {
+		left.(*val.ZTable).Set(index.(*val.ZString).Value, value)
+		return value
+	}
 	RuntimeErrorf("index assignment not supported: %s", string(left.Type()))
 	return val.NULL()
 }
@@ -265,6 +385,11 @@ func (s *ZmolState) evalBooleanExpression(node *ast.InfixExpression) val.ZValue
 	left := s.EvalProgram(node.Left)
 	right := s.EvalProgram(node.Right)
 
+	// Comparing a value with a tensor compares it with each element
+	if right.Type() == val.ZTENSOR && left.Type() != val.ZTENSOR {
+		return right.(*val.ZTensor).Elementwise(node.Operator, left, false)
+	}
+
 	leftComparable, ok := left.(val.ZComparable)
 	if !ok {
 		return val.ERROR(fmt.Sprintf("cannot compare %s to %s with `%s` operator", left.Type(), right.Type(), node.Operator))
@@ -313,6 +438,20 @@ func (s *ZmolState) evalLogicalExpression(node *ast.InfixExpression) val.ZValue
 }
 
 func (s *ZmolState) evalPipelineExpression(node *ast.PipelineExpression) val.ZValue {
+	switch node.Token.Type {
+	case lexer.TokMap, lexer.TokFilter:
+		stream, lazy := s.evalPipelineStream(node)
+		if isErr(stream) {
+			return stream
+		}
+		// A pipeline over a lazy source stays lazy. Otherwise the fused stages
+		// are materialized here, once, at the end of the chain.
+		if lazy {
+			return stream
+		}
+		return val.Collect(stream.(*val.ZIterator))
+	}
+
 	list := s.EvalProgram(node.List)
 	if isErr(list) {
 		return list
@@ -323,15 +462,11 @@ func (s *ZmolState) evalPipelineExpression(node *ast.PipelineExpression) val.ZVa
 		return val.ERROR("Right side of pipeline must be a function")
 	}
 
-	extraArgs := []val.ZValue{}
-	for _, arg := range node.ExtraArgs {
-		extraArgs = append(extraArgs, s.EvalProgram(arg))
-	}
+	args := s.evalPipelineArgs(node)
 
 	switch node.Token.Type {
 	case lexer.TokPipe:
-		finalArgs := []val.ZValue{list}
-		finalArgs = append(finalArgs, extraArgs...)
+		finalArgs := withPipedValue(args, list)
 		if function.Type() == val.ZNATIVE {
 			return function.(*val.ZNativeFunc).Fn(finalArgs...)
 		}
@@ -341,10 +476,6 @@ func (s *ZmolState) evalPipelineExpression(node *ast.PipelineExpression) val.ZVa
 		}
 
 		return s.applyPipe(callable, finalArgs)
-	case lexer.TokMap:
-		return s.applyMap(function, list, extraArgs)
-	case lexer.TokFilter:
-		return s.applyFilter(function, list, extraArgs)
 	}
 
 	msg := fmt.Sprintf("Unknown pipeline operator: %s", node.Token.Text)
@@ -352,9 +483,82 @@ func (s *ZmolState) evalPipelineExpression(node *ast.PipelineExpression) val.ZVa
 	return val.ERROR(msg)
 }
 
+// Builds a lazy iterator for a chain of map (->) and filter (>-) stages. The
+// stages are fused: each element is pulled through the whole chain before the
+// next one is read, so no intermediate list is allocated. Parallel stages read
+// their input in batches instead. The second return value reports whether the
+// source of the chain is itself lazy.
+func (s *ZmolState) evalPipelineStream(node *ast.PipelineExpression) (val.ZValue, bool) {
+	var source val.ZValue
+	var lazy bool
+
+	upstream, ok := node.List.(*ast.PipelineExpression)
+	if ok && (upstream.Token.Type == lexer.TokMap || upstream.Token.Type == lexer.TokFilter) {
+		source, lazy = s.evalPipelineStream(upstream)
+	} else {
+		source = s.EvalProgram(node.List)
+		lazy = source.Type() == val.ZITERATOR
+	}
+	if isErr(source) {
+		return source, false
+	}
+
+	stream, ok := val.Iterate(source)
+	if !ok {
+		RuntimeErrorf("Left side of pipeline must be iterable")
+	}
+
+	function := s.EvalProgram(node.FuncLiteral)
+	if isErr(function) {
+		return function, false
+	}
+
+	args := s.evalPipelineArgs(node)
+
+	if node.Parallel {
+		return s.applyParallel(function, stream, args, node.Token.Type == lexer.TokFilter), lazy
+	}
+	if node.Token.Type == lexer.TokMap {
+		return s.applyMap(function, stream, args), lazy
+	}
+	return s.applyFilter(function, stream, args), lazy
+}
+
+// Evaluates the extra arguments of a pipeline stage. The returned arguments
+// have a nil slot wherever the piped value goes: at each `_` placeholder, or
+// as the first argument if there is no placeholder.
+func (s *ZmolState) evalPipelineArgs(node *ast.PipelineExpression) []val.ZValue {
+	args := []val.ZValue{}
+	hasPlaceholder := false
+	for _, arg := range node.ExtraArgs {
+		if _, ok := arg.(*ast.Placeholder); ok {
+			hasPlaceholder = true
+			args = append(args, nil)
+		} else {
+			args = append(args, s.EvalProgram(arg))
+		}
+	}
+	if !hasPlaceholder {
+		args = append([]val.ZValue{nil}, args...)
+	}
+	return args
+}
+
+// Returns a copy of the pipeline arguments with the piped value filled in.
+func withPipedValue(args []val.ZValue, piped val.ZValue) []val.ZValue {
+	finalArgs := make([]val.ZValue, len(args))
+	for i, arg := range args {
+		if arg == nil {
+			finalArgs[i] = piped
+		} else {
+			finalArgs[i] = arg
+		}
+	}
+	return finalArgs
+}
+
 func (s *ZmolState) applyPipe(fn val.ZCallable, args []val.ZValue) val.ZValue {
 	if len(args) != len(fn.Params()) {
-		fmt.Println("args", args)
 		RuntimeErrorf("Wrong number of arguments for `%s`: expected=%d, got=%d", fn.Name(), len(fn.Params()), len(args))
 	}
 	evaluated := EvalCallable(fn, args, s.Env)
@@ -369,32 +573,14 @@ func (s *ZmolState) applyPipe(fn val.ZCallable, args []val.ZValue) val.ZValue {
 	return evaluated
 }
 
-func (s *ZmolState) applyMap(fn val.ZValue, list val.ZValue, extraArgs []val.ZValue) val.ZValue {
-	// error if the list is not a list or string
-	if list.Type() != val.ZLIST && list.Type() != val.ZSTRING {
-		RuntimeErrorf("Left side of pipeline must be iterable")
-	}
-
+// Returns a function applying a pipeline stage function to a single element,
+// placed among the pipeline arguments.
+func (s *ZmolState) pipelineStage(fn val.ZValue, args []val.ZValue) func(elem val.ZValue) val.ZValue {
 	//// Case 1: Native function
 	if fn.Type() == val.ZNATIVE {
-		newList := &val.ZList{Elements: []val.ZValue{}}
-		switch list.Type() {
-		case val.ZSTRING:
-			for _, elem := range list.(*val.ZString).Value {
-				finalArgs := []val.ZValue{&val.ZString{Value: string(elem)}}
-				finalArgs = append(finalArgs, extraArgs...)
-				newList.Elements = append(newList.Elements, fn.(*val.ZNativeFunc).Fn(finalArgs...))
-			}
-
-		case val.ZLIST:
-			for _, elem := range list.(*val.ZList).Elements {
-				finalArgs := []val.ZValue{elem}
-				finalArgs = append(finalArgs, extraArgs...)
-				newList.Elements = append(newList.Elements, fn.(*val.ZNativeFunc).Fn(finalArgs...))
-			}
+		return func(elem val.ZValue) val.ZValue {
+			return fn.(*val.ZNativeFunc).Fn(withPipedValue(args, elem)...)
 		}
-
-		return newList
 	}
 
 	//// Case 2: User-defined function
@@ -402,101 +588,45 @@ func (s *ZmolState) applyMap(fn val.ZValue, list val.ZValue, extraArgs []val.ZVa
 	if !ok {
 		RuntimeErrorf("Right side of pipeline must be a callable, but got %s", fn.Type())
 	}
-	// zState := NewZmolState(s.Env)
-	if len(extraArgs)+1 != len(udFunc.Params()) {
-		RuntimeErrorf("Wrong number of arguments: expected=%d, got=%d", len(udFunc.Params()), len(extraArgs)+1)
+	if len(args) != len(udFunc.Params()) {
+		RuntimeErrorf("Wrong number of arguments: expected=%d, got=%d", len(udFunc.Params()), len(args))
 	}
-
-	// Evaluate the function for each element in the list
-	newList := &val.ZList{Elements: []val.ZValue{}}
-	switch list.Type() {
-	case val.ZSTRING:
-		for _, elem := range list.(*val.ZString).Value {
-			finalArgs := []val.ZValue{&val.ZString{Value: string(elem)}}
-			finalArgs = append(finalArgs, extraArgs...)
-			evaluated := EvalCallable(udFunc, finalArgs, s.Env)
-			newList.Elements = append(newList.Elements, evaluated)
-		}
-
-	case val.ZLIST:
-		for _, elem := range list.(*val.ZList).Elements {
-			finalArgs := []val.ZValue{elem}
-			finalArgs = append(finalArgs, extraArgs...)
-			evaluated := EvalCallable(udFunc, finalArgs, s.Env)
-			newList.Elements = append(newList.Elements, evaluated)
-		}
+	return func(elem val.ZValue) val.ZValue {
+		return EvalCallable(udFunc, withPipedValue(args, elem), s.Env)
 	}
-
-	return newList
 }
 
-func (s *ZmolState) applyFilter(fn val.ZValue, list val.ZValue, extraArgs []val.ZValue) val.ZValue {
-	// error if the list is not a list or string
-	if list.Type() != val.ZLIST && list.Type() != val.ZSTRING {
-		RuntimeErrorf("Left side of pipeline must be iterable")
-	}
+func (s *ZmolState) applyMap(fn val.ZValue, stream *val.ZIterator, args []val.ZValue) val.ZValue {
+	stage := s.pipelineStage(fn, args)
+	return val.ITERATOR(func() (val.ZValue, bool) {
+		elem, ok := stream.Next()
+		if !ok || isErr(elem) {
+			return elem, ok
+		}
+		return stage(elem), true
+	})
+}
 
-	//// Case 1: Native function
-	if fn.Type() == val.ZNATIVE {
-		newList := &val.ZList{Elements: []val.ZValue{}}
-		switch list.Type() {
-		case val.ZSTRING:
-			for _, elem := range list.(*val.ZString).Value {
-				finalArgs := []val.ZValue{&val.ZString{Value: string(elem)}}
-				finalArgs = append(finalArgs, extraArgs...)
-				if fn.(*val.ZNativeFunc).Fn(finalArgs...).(*val.ZBool).Value {
-					newList.Elements = append(newList.Elements, &val.ZString{Value: string(elem)})
-				}
+func (s *ZmolState) applyFilter(fn val.ZValue, stream *val.ZIterator, args []val.ZValue) val.ZValue {
+	stage := s.pipelineStage(fn, args)
+	return val.ITERATOR(func() (val.ZValue, bool) {
+		for {
+			elem, ok := stream.Next()
+			if !ok || isErr(elem) {
+				return elem, ok
 			}
-
-		case val.ZLIST:
-			for _, elem := range list.(*val.ZList).Elements {
-				finalArgs := []val.ZValue{elem}
-				finalArgs = append(finalArgs, extraArgs...)
-				if fn.(*val.ZNativeFunc).Fn(finalArgs...).(*val.ZBool).Value {
-					newList.Elements = append(newList.Elements, elem)
-				}
+			keep := stage(elem)
+			if isErr(keep) {
+				return keep, true
 			}
-		}
-
-		return newList
-	}
-
-	//// Case 2: User-defined function
-	udFunc, ok := fn.(val.ZCallable)
-	if !ok {
-		RuntimeErrorf("Right side of pipeline must be a callable, but got %s", fn.Type())
-	}
-	// zState := NewZmolState(s.Env)
-	if len(extraArgs)+1 != len(udFunc.Params()) {
-		RuntimeErrorf("Wrong number of arguments: expected=%d, got=%d", len(udFunc.Params()), len(extraArgs)+1)
-	}
-
-	// Evaluate the function for each element in the list
-	newList := &val.ZList{Elements: []val.ZValue{}}
-	switch list.Type() {
-	case val.ZSTRING:
-		for _, elem := range list.(*val.ZString).Value {
-			finalArgs := []val.ZValue{&val.ZString{Value: string(elem)}}
-			finalArgs = append(finalArgs, extraArgs...)
-			evaluated := EvalCallable(udFunc, finalArgs, s.Env)
-			if evaluated.(*val.ZBool).Value {
-				newList.Elements = append(newList.Elements, &val.ZString{Value: string(elem)})
+			if keep.Type() != val.ZBOOL {
+				return &val.ZError{Message: "Filter function must return a boolean, got " + string(keep.Type())}, true
 			}
-
-		}
-	case val.ZLIST:
-		for _, elem := range list.(*val.ZList).Elements {
-			finalArgs := []val.ZValue{elem}
-			finalArgs = append(finalArgs, extraArgs...)
-			evaluated := EvalCallable(udFunc, finalArgs, s.Env)
-			if evaluated.(*val.ZBool).Value {
-				newList.Elements = append(newList.Elements, elem)
+			if keep.(*val.ZBool).Value {
+				return elem, true
 			}
 		}
-	}
-
-	return newList
+	})
 }
 
 func EvalCallable(fn val.ZCallable, args []val.ZValue, parentEnv *val.Env) val.ZValue {
@@ -512,95 +642,407 @@ func EvalCallable(fn val.ZCallable, args []val.ZValue, parentEnv *val.Env) val.Z
 	return zState.EvalProgram(fn.Body())
 }
 
+// IsCallable reports whether a value can be called, i.e., it is a user-defined
+// function, a module function, or a native function.
+func IsCallable(fn val.ZValue) bool {
+	if fn.Type() == val.ZNATIVE {
+		return true
+	}
+	_, ok := fn.(val.ZCallable)
+	return ok
+}
+
+// CallFunction calls any callable value with the given arguments. User-defined
+// functions are evaluated in the environment they were defined in. This is
+// mainly used by native functions that take other functions as arguments.
+func CallFunction(fn val.ZValue, args ...val.ZValue) val.ZValue {
+	switch fn := fn.(type) {
+	case *val.ZNativeFunc:
+		return fn.Fn(args...)
+	case *val.ZModuleFunc:
+		return EvalCallable(fn, args, fn.Env)
+	case *val.ZFunction:
+		return EvalCallable(fn, args, fn.Env)
+	}
+	return &val.ZError{Message: string(fn.Type()) + " is not callable"}
+}
+
+// EvalInfix applies a binary arithmetic operator to two values, following
+// the same rules as the infix expressions in zmol source code.
+func EvalInfix(operator string, left, right val.ZValue) val.ZValue {
+	s := &ZmolState{}
+	return s.evalInfixExpression(operator, left, right)
+}
+
 func (s *ZmolState) evalInfixExpression(operator string, left, right val.ZValue) val.ZValue {
+	if isErr(left) {
+		return left
+	}
+	if isErr(right) {
+		return right
+	}
+
 	switch {
 	case left.Type() == val.ZINT && right.Type() == val.ZINT:
 		return s.evalIntegerInfixExpression(operator, left, right)
+	case isInteger(left) && isInteger(right):
+		return s.evalBigIntInfixExpression(operator, left, right)
 	case left.Type() == val.ZFLOAT && right.Type() == val.ZFLOAT:
 		return s.evalFloatInfixExpression(operator, left, right)
-	case left.Type() == val.ZINT && right.Type() == val.ZFLOAT:
+	case isInteger(left) && right.Type() == val.ZFLOAT:
 		return s.evalIntFloatInfixExpression(operator, left, right)
-	case left.Type() == val.ZFLOAT && right.Type() == val.ZINT:
+	case left.Type() == val.ZFLOAT && isInteger(right):
 		return s.evalFloatIntInfixExpression(operator, left, right)
 
+	case left.Type() == val.ZVARIABLE || right.Type() == val.ZVARIABLE:
+		return s.evalVariableInfixExpression(operator, left, right)
+
+	case left.Type() == val.ZDECIMAL || right.Type() == val.ZDECIMAL:
+		return s.evalDecimalInfixExpression(operator, left, right)
+
+	case left.Type() == val.ZTIME || left.Type() == val.ZDURATION:
+		return s.evalArithOperandExpression(operator, left.(val.ZArithOperand), right)
+	case right.Type() == val.ZDURATION && operator == "*":
+		return right.(*val.ZDuration).Mul(left)
+
+	case left.Type() == val.ZTENSOR:
+		return s.evalArithOperandExpression(operator, left.(*val.ZTensor), right)
+	case right.Type() == val.ZTENSOR:
+		return right.(*val.ZTensor).Elementwise(operator, left, false)
+
 	case left.Type() == val.ZLIST && right.Type() == val.ZLIST:
 		return s.evalListConcatExpression(left, right)
 
 	case left.Type() == val.ZSTRING && right.Type() == val.ZSTRING:
 		return &val.ZString{Value: left.(*val.ZString).Value + right.(*val.ZString).Value}
+
+	case left.Type() == val.ZBYTES && right.Type() == val.ZBYTES && operator == "+":
+		l, r := left.(*val.ZBytes).Value, right.(*val.ZBytes).Value
+		return val.BYTES(append(append(make([]byte, 0, len(l)+len(r)), l...), r...))
 	}
 
 	return val.ERROR(fmt.Sprintf("type mismatch: %s %s %s", left.Type(), operator, right.Type()))
 }
 
+// Decimal operations accept Int and BigInt operands on either side, which are
+// converted to decimals. Floats must be converted explicitly.
+func (s *ZmolState) evalDecimalInfixExpression(operator string, left, right val.ZValue) val.ZValue {
+	leftDec, ok := val.ToDecimal(left)
+	if !ok {
+		if left.Type() == val.ZFLOAT {
+			return val.ERROR("cannot mix Decimal and Float with `" + operator + "`, convert the Float with decimal() first")
+		}
+		return val.ERROR(fmt.Sprintf("type mismatch: %s %s %s", left.Type(), operator, right.Type()))
+	}
+
+	switch operator {
+	case "//":
+		return leftDec.FloorDiv(right)
+	case "**":
+		return leftDec.Pow(right)
+	}
+	return s.evalArithOperandExpression(operator, leftDec, right)
+}
+
+// Variable operations accept numbers and tensors on either side, which are
+// treated as constants.
+func (s *ZmolState) evalVariableInfixExpression(operator string, left, right val.ZValue) val.ZValue {
+	leftVar, ok := val.ToVariable(left)
+	if !ok {
+		return val.ERROR(fmt.Sprintf("type mismatch: %s %s %s", left.Type(), operator, right.Type()))
+	}
+	if operator == "**" {
+		return leftVar.Pow(right)
+	}
+	return s.evalArithOperandExpression(operator, leftVar, right)
+}
+
+// Evaluates an arithmetic operator on a type that implements the operators
+// itself.
+func (s *ZmolState) evalArithOperandExpression(operator string, left val.ZArithOperand, right val.ZValue) val.ZValue {
+	switch operator {
+	case "+":
+		return left.Add(right)
+	case "-":
+		return left.Sub(right)
+	case "*":
+		return left.Mul(right)
+	case "/":
+		return left.Div(right)
+	case "%":
+		return left.Mod(right)
+	}
+	return val.ERROR(fmt.Sprintf("Operator %s not supported for %s and %s", operator, left.Type(), right.Type()))
+}
+
+// Reports whether a value is an Int or a BigInt.
+func isInteger(v val.ZValue) bool {
+	return v.Type() == val.ZINT || v.Type() == val.ZBIGINT
+}
+
+func divisionByZero() val.ZValue {
+	return &val.ZError{Message: "division by zero"}
+}
+
+// Integer operations are evaluated on int64 values. When the result does not
+// fit in 64 bits, the operation is evaluated again as BigInt operation.
 func (s *ZmolState) evalIntegerInfixExpression(operator string, left, right val.ZValue) val.ZValue {
 	leftVal := left.(*val.ZInt).Value
 	rightVal := right.(*val.ZInt).Value
 
 	switch operator {
 	case "+":
-		return &val.ZInt{Value: leftVal + rightVal}
+		if sum := leftVal + rightVal; (sum > leftVal) == (rightVal > 0) {
+			return &val.ZInt{Value: sum}
+		}
 	case "-":
-		return &val.ZInt{Value: leftVal - rightVal}
+		if diff := leftVal - rightVal; (diff < leftVal) == (rightVal > 0) {
+			return &val.ZInt{Value: diff}
+		}
 	case "*":
-		return &val.ZInt{Value: leftVal * rightVal}
+		product := leftVal * rightVal
+		if leftVal == 0 || (product/leftVal == rightVal && !(leftVal == -1 && rightVal == math.MinInt64) && !(rightVal == -1 && leftVal == math.MinInt64)) {
+			return &val.ZInt{Value: product}
+		}
 	case "/":
-		return &val.ZInt{Value: leftVal / rightVal}
+		if rightVal == 0 {
+			return divisionByZero()
+		}
+		if !(leftVal == math.MinInt64 && rightVal == -1) {
+			return &val.ZInt{Value: leftVal / rightVal}
+		}
+	case "//":
+		if rightVal == 0 {
+			return divisionByZero()
+		}
+		if !(leftVal == math.MinInt64 && rightVal == -1) {
+			quotient := leftVal / rightVal
+			if leftVal%rightVal != 0 && (leftVal < 0) != (rightVal < 0) {
+				quotient--
+			}
+			return &val.ZInt{Value: quotient}
+		}
 	case "%":
+		if rightVal == 0 {
+			return divisionByZero()
+		}
 		return &val.ZInt{Value: leftVal % rightVal}
+	case "**":
+		if rightVal < 0 {
+			return &val.ZFloat{Value: math.Pow(float64(leftVal), float64(rightVal))}
+		}
+		if result, ok := powInt64(leftVal, rightVal); ok {
+			return &val.ZInt{Value: result}
+		}
+	case "&":
+		return &val.ZInt{Value: leftVal & rightVal}
+	case "|":
+		return &val.ZInt{Value: leftVal | rightVal}
+	case "^":
+		return &val.ZInt{Value: leftVal ^ rightVal}
+	case "<<":
+		if rightVal < 0 {
+			return &val.ZError{Message: "negative shift count"}
+		}
+		if rightVal < 63 && (leftVal<<rightVal)>>rightVal == leftVal {
+			return &val.ZInt{Value: leftVal << rightVal}
+		}
+	case ">>":
+		if rightVal < 0 {
+			return &val.ZError{Message: "negative shift count"}
+		}
+		return &val.ZInt{Value: leftVal >> rightVal}
+	default:
+		return val.ERROR("Operator " + operator + " not supported for integers")
 	}
-	return val.ERROR("Operator " + operator + " not supported for integers")
+
+	// The result overflows int64
+	return s.evalBigIntInfixExpression(operator, left, right)
 }
 
-func (s *ZmolState) evalFloatInfixExpression(operator string, left, right val.ZValue) val.ZValue {
-	leftVal := left.(*val.ZFloat).Value
-	rightVal := right.(*val.ZFloat).Value
+// Computes base**exp by repeated squaring. The second return value is false if
+// the result overflows int64.
+func powInt64(base, exp int64) (int64, bool) {
+	result := int64(1)
+	for exp > 0 {
+		if exp&1 == 1 {
+			product := result * base
+			if base != 0 && product/base != result {
+				return 0, false
+			}
+			result = product
+		}
+		exp >>= 1
+		if exp > 0 {
+			square := base * base
+			if base != 0 && square/base != base {
+				return 0, false
+			}
+			base = square
+		}
+	}
+	return result, true
+}
+
+func (s *ZmolState) evalBigIntInfixExpression(operator string, left, right val.ZValue) val.ZValue {
+	leftVal, _ := val.ToBigInt(left)
+	rightVal, _ := val.ToBigInt(right)
+	result := new(big.Int)
 
 	switch operator {
 	case "+":
-		return &val.ZFloat{Value: leftVal + rightVal}
+		result.Add(leftVal, rightVal)
 	case "-":
-		return &val.ZFloat{Value: leftVal - rightVal}
+		result.Sub(leftVal, rightVal)
 	case "*":
-		return &val.ZFloat{Value: leftVal * rightVal}
+		result.Mul(leftVal, rightVal)
 	case "/":
-		return &val.ZFloat{Value: leftVal / rightVal}
+		if rightVal.Sign() == 0 {
+			return divisionByZero()
+		}
+		result.Quo(leftVal, rightVal)
+	case "//":
+		if rightVal.Sign() == 0 {
+			return divisionByZero()
+		}
+		remainder := new(big.Int)
+		result.QuoRem(leftVal, rightVal, remainder)
+		if remainder.Sign() != 0 && remainder.Sign() != rightVal.Sign() {
+			result.Sub(result, big.NewInt(1))
+		}
+	case "%":
+		if rightVal.Sign() == 0 {
+			return divisionByZero()
+		}
+		result.Rem(leftVal, rightVal)
+	case "**":
+		if rightVal.Sign() < 0 {
+			base, _ := new(big.Float).SetInt(leftVal).Float64()
+			exp, _ := new(big.Float).SetInt(rightVal).Float64()
+			return &val.ZFloat{Value: math.Pow(base, exp)}
+		}
+		if val.PowTooLarge(leftVal, rightVal) {
+			return &val.ZError{Message: "exponent too large"}
+		}
+		result.Exp(leftVal, rightVal, nil)
+	case "&":
+		result.And(leftVal, rightVal)
+	case "|":
+		result.Or(leftVal, rightVal)
+	case "^":
+		result.Xor(leftVal, rightVal)
+	case "<<", ">>":
+		if rightVal.Sign() < 0 {
+			return &val.ZError{Message: "negative shift count"}
+		}
+		if !rightVal.IsUint64() || rightVal.Uint64() > math.MaxUint32 {
+			return &val.ZError{Message: "shift count too large"}
+		}
+		if operator == "<<" {
+			result.Lsh(leftVal, uint(rightVal.Uint64()))
+		} else {
+			result.Rsh(leftVal, uint(rightVal.Uint64()))
+		}
+	default:
+		return val.ERROR("Operator " + operator + " not supported for integers")
 	}
-	return val.ERROR("Operator " + operator + " not supported for floats")
+	return val.INTEGER(result)
 }
 
-func (s *ZmolState) evalIntFloatInfixExpression(operator string, left, right val.ZValue) val.ZValue {
-	leftVal := float64(left.(*val.ZInt).Value)
-	rightVal := right.(*val.ZFloat).Value
-
+// Applies an arithmetic operator to two floats. The second return value is
+// false if the operator is not supported.
+func evalFloatOperation(operator string, leftVal, rightVal float64) (val.ZValue, bool) {
 	switch operator {
 	case "+":
-		return &val.ZFloat{Value: leftVal + rightVal}
+		return &val.ZFloat{Value: leftVal + rightVal}, true
 	case "-":
-		return &val.ZFloat{Value: leftVal - rightVal}
+		return &val.ZFloat{Value: leftVal - rightVal}, true
 	case "*":
-		return &val.ZFloat{Value: leftVal * rightVal}
+		return &val.ZFloat{Value: leftVal * rightVal}, true
 	case "/":
-		return &val.ZFloat{Value: leftVal / rightVal}
+		return &val.ZFloat{Value: leftVal / rightVal}, true
+	case "//":
+		return &val.ZFloat{Value: math.Floor(leftVal / rightVal)}, true
+	case "%":
+		return &val.ZFloat{Value: math.Mod(leftVal, rightVal)}, true
+	case "**":
+		return &val.ZFloat{Value: math.Pow(leftVal, rightVal)}, true
+	}
+	return nil, false
+}
+
+// Returns the float value of an Int, a BigInt or a Float.
+func toFloat(v val.ZValue) float64 {
+	switch v := v.(type) {
+	case *val.ZInt:
+		return float64(v.Value)
+	case *val.ZBigInt:
+		return v.Float()
+	}
+	return v.(*val.ZFloat).Value
+}
+
+func (s *ZmolState) evalFloatInfixExpression(operator string, left, right val.ZValue) val.ZValue {
+	if result, ok := evalFloatOperation(operator, toFloat(left), toFloat(right)); ok {
+		return result
+	}
+	return val.ERROR("Operator " + operator + " not supported for floats")
+}
+
+func (s *ZmolState) evalIntFloatInfixExpression(operator string, left, right val.ZValue) val.ZValue {
+	if result, ok := evalFloatOperation(operator, toFloat(left), toFloat(right)); ok {
+		return result
 	}
 	return val.ERROR("Operator " + operator + " not supported for integers and floats")
 }
 
 func (s *ZmolState) evalFloatIntInfixExpression(operator string, left, right val.ZValue) val.ZValue {
-	leftVal := left.(*val.ZFloat).Value
-	rightVal := float64(right.(*val.ZInt).Value)
+	if result, ok := evalFloatOperation(operator, toFloat(left), toFloat(right)); ok {
+		return result
+	}
+	return val.ERROR("Operator " + operator + " not supported for floats and integers")
+}
 
-	switch operator {
-	case "+":
-		return &val.ZFloat{Value: leftVal + rightVal}
+func (s *ZmolState) evalPrefixExpression(node *ast.PrefixExpression) val.ZValue {
+	right := s.EvalProgram(node.Right)
+	if isErr(right) {
+		return right
+	}
+
+	switch node.Operator {
 	case "-":
-		return &val.ZFloat{Value: leftVal - rightVal}
-	case "*":
-		return &val.ZFloat{Value: leftVal * rightVal}
-	case "/":
-		return &val.ZFloat{Value: leftVal / rightVal}
+		switch right := right.(type) {
+		case *val.ZInt:
+			if right.Value == math.MinInt64 {
+				return val.BIGINT(new(big.Int).Neg(big.NewInt(right.Value)))
+			}
+			return &val.ZInt{Value: -right.Value}
+		case *val.ZBigInt:
+			return val.INTEGER(new(big.Int).Neg(right.Value))
+		case *val.ZFloat:
+			return &val.ZFloat{Value: -right.Value}
+		case *val.ZDecimal:
+			return right.Neg()
+		case *val.ZTensor:
+			return right.Elementwise("-", val.INT(0), false)
+		case *val.ZVariable:
+			return right.Neg()
+		case *val.ZDuration:
+			return right.Neg()
+		}
+	case "+":
+		if isInteger(right) || right.Type() == val.ZFLOAT || right.Type() == val.ZDECIMAL {
+			return right
+		}
+	case "~":
+		switch right := right.(type) {
+		case *val.ZInt:
+			return &val.ZInt{Value: ^right.Value}
+		case *val.ZBigInt:
+			return val.INTEGER(new(big.Int).Not(right.Value))
+		}
 	}
-	return val.ERROR("Operator " + operator + " not supported for floats and integers")
+	return val.ERROR(fmt.Sprintf("Operator %s not supported for %s", node.Operator, right.Type()))
 }
 
 func (s *ZmolState) evalIdentifier(node *ast.Identifier) val.ZValue {
@@ -610,18 +1052,19 @@ func (s *ZmolState) evalIdentifier(node *ast.Identifier) val.ZValue {
 	return RuntimeErrorf("identifier not found: " + node.Value)
 }
 
+// RuntimeErrorf prints a fatal error to val.Stderr and ends the process.
 func RuntimeErrorf(format string, args ...interface{}) val.ZValue {
-	color.Set(color.FgRed)
-	fmt.Println("\n*** RUNTIME ERROR ***")
-	fmt.Println(fmt.Sprintf(format, args...))
-	color.Unset()
+	red := color.New(color.FgRed)
+	red.Fprintln(val.Stderr, "\n*** RUNTIME ERROR ***")
+	red.Fprintln(val.Stderr, fmt.Sprintf(format, args...))
 	os.Exit(1)
 	return nil
 }
 
 func (s *ZmolState) evalVariableAssignment(node *ast.InfixExpression) val.ZValue {
 	value := s.EvalProgram(node.Right)
-	if isErr(value) {
+	// Errors can be bound to variables, so they can be checked with is_error
+	if _, ok := node.Left.(*ast.Identifier); isErr(value) && !ok {
 		return value
 	}
 
@@ -723,7 +1166,7 @@ func (s *ZmolState) evalCallExpression(node *ast.CallExpression) val.ZValue {
 
 		// Copy class attributes and methods (i.e., ZValues in the class' env)
 		// to the new object
-		for k, v := range class.Env().SymTable {
+		for k, v := range class.Env().Symbols() {
 			obj.Env().Set(k, v)
 		}
 		obj.Env().ParentEnv = class.Env().ParentEnv
@@ -793,15 +1236,21 @@ func (s *ZmolState) evalIterStatement(node *ast.IterStatement) val.ZValue {
 		return list
 	}
 
-	// check if it is a list
-	if list.Type() != val.ZLIST {
-		fmt.Println("Iter statement requires a list")
-		os.Exit(1)
+	items, ok := val.Iterate(list)
+	if !ok {
+		RuntimeErrorf("Iter statement requires a list, a string, a table or an iterator")
 	}
 
 	ident := node.Ident.Value
 
-	for _, item := range list.(*val.ZList).Elements {
+	for {
+		item, ok := items.Next()
+		if !ok {
+			break
+		}
+		if isErr(item) {
+			return item
+		}
 		s.Env.Set(ident, item)
 		s.EvalProgram(node.Body)
 	}
@@ -819,8 +1268,7 @@ func (s *ZmolState) evalListConcatExpression(left, right val.ZValue) val.ZValue
 
 	// check if both are lists
 	if left.Type() != val.ZLIST || right.Type() != val.ZLIST {
-		fmt.Println("Concatenation requires two lists")
-		os.Exit(1)
+		RuntimeErrorf("Concatenation requires two lists")
 	}
 
 	return &val.ZList{Elements: append(left.(*val.ZList).Elements, right.(*val.ZList).Elements...)}
@@ -1,9 +1,13 @@
 package eval
 
 import (
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/ariaghora/zmol/pkg/val"
+	"gorgonia.org/tensor"
 )
 
 func TestEvalIntegerExpression(t *testing.T) {
@@ -22,6 +26,320 @@ func TestEvalIntegerExpression(t *testing.T) {
 	}
 }
 
+func TestIntegerOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"7 / 2", 3},
+		{"7 // 2", 3},
+		{"-7 // 2", -4},
+		{"7 // -2", -4},
+		{"7 % 3", 1},
+		{"2 ** 10", 1024},
+		{"2 ** 3 ** 2", 512},
+		{"-2 ** 2", -4},
+		{"6 & 3", 2},
+		{"6 | 3", 7},
+		{"6 ^ 3", 5},
+		{"1 << 10", 1024},
+		{"-16 >> 2", -4},
+		{"~5", -6},
+		{"1 + 2 * 3 & 7", 7},
+		{"9223372036854775807 + 1 - 1", 9223372036854775807},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestIntegerOverflowPromotion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"-9223372036854775807 - 2", "-9223372036854775809"},
+		{"4294967296 * 4294967296", "18446744073709551616"},
+		{"2 ** 64", "18446744073709551616"},
+		{"1 << 64", "18446744073709551616"},
+		{"-(-9223372036854775807 - 1)", "9223372036854775808"},
+		{"100000000000000000000", "100000000000000000000"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		result, ok := evaluated.(*val.ZBigInt)
+		if !ok {
+			t.Errorf("object is not BigInt. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if result.Str() != tt.expected {
+			t.Errorf("object has wrong value. got=%s, want=%s", result.Str(), tt.expected)
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"0.1d + 0.2d", "0.3"},
+		{"12.50d * 3", "37.50"},
+		{"2 - 0.75d", "1.25"},
+		{"10.00d / 4", "2.50"},
+		{"1d / 3", "0.3333333333333333333333333333"},
+		{"-7.5d // 2", "-4"},
+		{"7.5d % 2", "1.5"},
+		{"1.5d ** 2", "2.25"},
+		{"2d ** -2", "0.25"},
+		{"-1.25d", "-1.25"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		result, ok := evaluated.(*val.ZDecimal)
+		if !ok {
+			t.Errorf("%s: object is not Decimal. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if result.Str() != tt.expected {
+			t.Errorf("%s: object has wrong value. got=%s, want=%s", tt.input, result.Str(), tt.expected)
+		}
+	}
+}
+
+func TestDecimalComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"0.1d + 0.2d == 0.3d", true},
+		{"1.50d == 1.5d", true},
+		{"2.0d == 2", true},
+		{"3 > 2.5d", true},
+		{"2.5d < 2.25", false},
+		{"1.5d != 1.5", false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestPowLimit(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2 ** 100000000", "ERROR: exponent too large"},
+		{"3 ** 99999999999999999999", "ERROR: exponent too large"},
+		{"1.5d ** 100000000", "ERROR: exponent too large"},
+		{"0.1d ** 100000000", "ERROR: exponent too large"},
+		{"1 ** 99999999999999999999", "1"},
+		{"-1 ** 100000001", "-1"},
+		{"1d ** 100000000", "1"},
+		{"2 ** 100", "1267650600228229401496703205376"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Str() != tt.expected {
+			t.Errorf("%s: got=%s, want=%s", tt.input, evaluated.Str(), tt.expected)
+		}
+	}
+}
+
+func TestTensorOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"t * 2 + 1", "[[3, 5, 7], [9, 11, 13]]"},
+		{"10 - t", "[[9, 8, 7], [6, 5, 4]]"},
+		{"t + t", "[[2, 4, 6], [8, 10, 12]]"},
+		{"-t", "[[-1, -2, -3], [-4, -5, -6]]"},
+		{"t[1]", "[4, 5, 6]"},
+		{"t[-1][0]", "4"},
+		{"t + [10, 20, 30]", "[[11, 22, 33], [14, 25, 36]]"},
+		{"[1, 1, 1] - t", "[[0, -1, -2], [-3, -4, -5]]"},
+		{"t * t[0]", "[[1, 4, 9], [4, 10, 18]]"},
+		{"t > 3", "[[false, false, false], [true, true, true]]"},
+		{"2 <= t", "[[false, true, true], [true, true, true]]"},
+		{"t == [1, 5, 3]", "[[true, false, true], [false, true, false]]"},
+		{"t + [1, 2]", "ERROR: cannot broadcast shapes (2, 3) and (2) for `+`"},
+		{"t / 2", "[[0, 1, 1], [2, 2, 3]]"},
+		{"t * 0.5", "[[0.500000, 1.000000, 1.500000], [2.000000, 2.500000, 3.000000]]"},
+		{"(t > 2) + 1", "[[1, 1, 2], [2, 2, 2]]"},
+		{"t % 0", "ERROR: division by zero"},
+		{"(t > 2) < (t > 3)", "ERROR: Operator < not supported for bool tensors"},
+	}
+
+	for _, tt := range tests {
+		state := NewZmolState(nil)
+		state.Env.Set("t", val.TENSOR(tensor.New(
+			tensor.WithShape(2, 3), tensor.WithBacking([]int64{1, 2, 3, 4, 5, 6}),
+		)))
+		evaluated, err := state.Eval(tt.input)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.input, err)
+		}
+
+		var got string
+		switch evaluated := evaluated.(type) {
+		case *val.ZTensor:
+			got = evaluated.ToList().Str()
+		default:
+			got = evaluated.Str()
+		}
+		if got != tt.expected {
+			t.Errorf("%s: got=%s, want=%s", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestVariableGradients(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"y = x * x + 2 * x\ny.backward()\nx.grad", "8.000000"},
+		{"y = 1 / x - x ** 2\ny.backward()\nx.grad", "-6.111111"},
+		{"y = -x * 4\ny.backward()\ny.value", "-12.000000"},
+		{"y = (v * x - v) * [1, 0, 2]\ny", "[2, 0, 12]"},
+		{"v ** 2 - [1, 1, 1]", "[0, 3, 8]"},
+		{"y = v * x\ny.backward()", "ERROR: backward() takes a single value, got shape (3), reduce it with sum() or mean() first"},
+		{"y = v + [1, 2]\ny", "ERROR: cannot broadcast shapes (3) and (2) for `+`"},
+	}
+
+	for _, tt := range tests {
+		state := NewZmolState(nil)
+		x, _ := val.VARIABLE(val.FLOAT(3))
+		v, _ := val.VARIABLE(val.TENSOR(tensor.New(tensor.WithShape(3), tensor.WithBacking([]float64{1, 2, 3}))))
+		state.Env.Set("x", x)
+		state.Env.Set("v", v)
+		evaluated, err := state.Eval(tt.input)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.input, err)
+		}
+
+		var got string
+		switch evaluated := evaluated.(type) {
+		case *val.ZVariable:
+			got = evaluated.Value().(*val.ZTensor).AsType(tensor.Int64).ToList().Str()
+		default:
+			got = evaluated.Str()
+		}
+		if got != tt.expected {
+			t.Errorf("%s: got=%s, want=%s", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestTableAccess(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`t["name"]`, "zmol"},
+		{"t.version", "2"},
+		{`t["version"] = 3` + "\nt", `{name: "zmol", version: 3}`},
+		{"t.tags = [1]\nt", `{name: "zmol", version: 2, tags: [1]}`},
+		{`t["missing"]`, `ERROR: key "missing" not found`},
+		{"k = \"\"\niter t as key { k = k + key }\nk", "nameversion"},
+	}
+
+	for _, tt := range tests {
+		state := NewZmolState(nil)
+		table := val.TABLE()
+		table.Set("name", val.STRING("zmol"))
+		table.Set("version", val.INT(2))
+		state.Env.Set("t", table)
+		evaluated, err := state.Eval(tt.input)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.input, err)
+		}
+		if evaluated.Str() != tt.expected {
+			t.Errorf("%s: got=%s, want=%s", tt.input, evaluated.Str(), tt.expected)
+		}
+	}
+}
+
+func TestTimeArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"t + 2 * hour", "2024-01-31T11:30:00Z"},
+		{"t - hour * 1.5", "2024-01-31T08:00:00Z"},
+		{"(t + hour) - t", "1h0m0s"},
+		{"-hour / 4", "-15m0s"},
+		{"hour / (hour / 4)", "4.000000"},
+		{"t + hour > t", "true"},
+		{"t == t + hour - hour", "true"},
+		{"hour / 0", "ERROR: division by zero"},
+	}
+
+	for _, tt := range tests {
+		state := NewZmolState(nil)
+		state.Env.Set("t", val.TIME(time.Date(2024, 1, 31, 9, 30, 0, 0, time.UTC)))
+		state.Env.Set("hour", val.DURATION(time.Hour))
+		evaluated, err := state.Eval(tt.input)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.input, err)
+		}
+		if evaluated.Str() != tt.expected {
+			t.Errorf("%s: got=%s, want=%s", tt.input, evaluated.Str(), tt.expected)
+		}
+	}
+}
+
+func TestBytesAndSlicing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`b"ab\x00\xff"`, `b"ab\x00\xff"`},
+		{`b"abc"[1]`, "98"},
+		{`b"ab" + b"\n"`, `b"ab\n"`},
+		{`b"hello"[1:3]`, `b"el"`},
+		{`b"ab" == b"ab"`, "true"},
+		{`"héllo"[1]`, "é"},
+		{`"héllo"[1:4]`, "éll"},
+		{`xs = [1, 2, 3, 4]
+		xs[:2]`, "[1, 2]"},
+		{`xs = [1, 2, 3, 4]
+		xs[2:]`, "[3, 4]"},
+		{`xs = [1, 2, 3, 4]
+		xs[1:3]`, "[2, 3]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Str() != tt.expected {
+			t.Errorf("%s: got=%s, want=%s", tt.input, evaluated.Str(), tt.expected)
+		}
+	}
+}
+
+func TestDivisionByZero(t *testing.T) {
+	for _, input := range []string{"1 / 0", "1 // 0", "1 % 0", "x = 1 / 0\nx", "1.5d / 0"} {
+		evaluated := testEval(input)
+		errVal, ok := evaluated.(*val.ZError)
+		if !ok {
+			t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if errVal.Message != "division by zero" {
+			t.Errorf("wrong error message. got=%q", errVal.Message)
+		}
+	}
+}
+
 func TestFunctionValue(t *testing.T) {
 	input := "@(x){ x + 2 }"
 	evaluated := testEval(input)
@@ -78,6 +396,235 @@ func TestFunctionCalls(t *testing.T) {
 	testIntegerObject(t, evaluated, 10)
 }
 
+func TestIsCallable(t *testing.T) {
+	tests := []struct {
+		value    val.ZValue
+		expected bool
+	}{
+		{testEval("fn(x) { x }"), true},
+		{testEval("add = @(a, b) { a + b }\nadd"), true},
+		{&val.ZNativeFunc{Fn: func(args ...val.ZValue) val.ZValue { return val.NULL() }}, true},
+		{val.INT(1), false},
+		{val.STRING("fn"), false},
+		{&val.ZList{}, false},
+		{val.NULL(), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsCallable(tt.value); got != tt.expected {
+			t.Errorf("IsCallable(%s): got=%t, want=%t", tt.value.Str(), got, tt.expected)
+		}
+	}
+}
+
+func TestCallFunction(t *testing.T) {
+	double := &val.ZNativeFunc{Fn: func(args ...val.ZValue) val.ZValue {
+		return EvalInfix("*", args[0], val.INT(2))
+	}}
+	// A function sees the environment it was defined in, not the caller's
+	closure := testEval("offset = 100\nadd = @(x) { x + offset }\nadd")
+	tests := []struct {
+		fn       val.ZValue
+		args     []val.ZValue
+		expected string
+	}{
+		{testEval("@(a, b) { a - b }"), []val.ZValue{val.INT(5), val.INT(3)}, "2"},
+		{testEval("@() { 42 }"), nil, "42"},
+		{closure, []val.ZValue{val.INT(1)}, "101"},
+		{double, []val.ZValue{val.FLOAT(1.5)}, "3.000000"},
+		{testEval("@(x) { 1 / x }"), []val.ZValue{val.INT(0)}, "ERROR: division by zero"},
+		{val.INT(1), nil, "ERROR: Int is not callable"},
+		{&val.ZList{}, nil, "ERROR: List is not callable"},
+	}
+
+	for _, tt := range tests {
+		if got := CallFunction(tt.fn, tt.args...).Str(); got != tt.expected {
+			t.Errorf("CallFunction(%s): got=%s, want=%s", tt.fn.Str(), got, tt.expected)
+		}
+	}
+}
+
+func TestEvalInfix(t *testing.T) {
+	tests := []struct {
+		operator    string
+		left, right val.ZValue
+		expected    string
+	}{
+		{"+", val.INT(2), val.INT(3), "5"},
+		{"-", val.INT(2), val.FLOAT(0.5), "1.500000"},
+		{"*", val.FLOAT(1.5), val.INT(2), "3.000000"},
+		{"/", val.INT(7), val.INT(2), "3"},
+		{"%", val.INT(7), val.INT(0), "ERROR: division by zero"},
+		{"**", val.INT(2), val.INT(64), "18446744073709551616"},
+		{"//", val.INT(-7), val.INT(2), "-4"},
+		{"+", val.STRING("a"), val.STRING("b"), "ab"},
+		{"+", &val.ZError{Message: "left"}, val.INT(1), "ERROR: left"},
+		{"+", val.INT(1), &val.ZError{Message: "right"}, "ERROR: right"},
+	}
+
+	for _, tt := range tests {
+		if got := EvalInfix(tt.operator, tt.left, tt.right).Str(); got != tt.expected {
+			t.Errorf("%s %s %s: got=%s, want=%s", tt.left.Str(), tt.operator, tt.right.Str(), got, tt.expected)
+		}
+	}
+}
+
+func TestPipelineChain(t *testing.T) {
+	source := `
+	numbers = [1, 2, 3, 4, 5, 6]
+	is_even = fn(x) { x % 2 == 0 }
+	scale = fn(x, factor) { x * factor }
+	numbers >- is_even{} -> scale{10}
+	`
+
+	evaluated := testEval(source)
+	list, ok := evaluated.(*val.ZList)
+	if !ok {
+		t.Fatalf("object is not List. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	expected := []int64{20, 40, 60}
+	if len(list.Elements) != len(expected) {
+		t.Fatalf("list has wrong length. got=%d, want=%d", len(list.Elements), len(expected))
+	}
+	for i, e := range expected {
+		testIntegerObject(t, list.Elements[i], e)
+	}
+}
+
+func TestPipelinePlaceholder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"10 |> fn(a, b) { a - b }{_, 3}", 7},
+		{"10 |> fn(a, b) { a - b }{3, _}", -7},
+		{"10 |> fn(a, b) { a - b }{3}", 7},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+
+	evaluated := testEval("[1, 2] -> fn(a, b) { a - b }{10, _}")
+	list, ok := evaluated.(*val.ZList)
+	if !ok {
+		t.Fatalf("object is not List. got=%T (%+v)", evaluated, evaluated)
+	}
+	testIntegerObject(t, list.Elements[0], 9)
+	testIntegerObject(t, list.Elements[1], 8)
+}
+
+func TestParallelPipeline(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2, 3, 4, 5] -> par fn(a, b) { a * b }{10}", "[10, 20, 30, 40, 50]"},
+		{"[1, 2, 3, 4, 5] >- par fn(x) { x % 2 == 1 }{}", "[1, 3, 5]"},
+		{"[1, 2, 3, 4] -> par fn(x) { x * 2 }{} >- par fn(x) { x > 4 }{}", "[6, 8]"},
+		{"[4, 2, 0, 1, 0] -> par fn(x) { 8 / x }{}", "ERROR: division by zero"},
+		{"[1, 2] >- par fn(x) { x }{}", "ERROR: Filter function must return a boolean, got Int"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Str() != tt.expected {
+			t.Errorf("%s: got=%s, want=%s", tt.input, evaluated.Str(), tt.expected)
+		}
+	}
+}
+
+func TestParallelApply(t *testing.T) {
+	elems := make([]val.ZValue, 100)
+	for i := range elems {
+		elems[i] = val.INT(int64(i))
+	}
+	results, err := ParallelApply(elems, 8, func(elem val.ZValue) val.ZValue {
+		if n := elem.(*val.ZInt).Value; n == 30 || n == 60 {
+			return &val.ZError{Message: fmt.Sprintf("failed at %d", n)}
+		}
+		return elem
+	})
+
+	if err == nil || err.Str() != "ERROR: failed at 30" {
+		t.Fatalf("expected the first error, got=%v", err)
+	}
+	if len(results) != 30 {
+		t.Fatalf("expected the 30 results before the error, got=%d", len(results))
+	}
+	for i, result := range results {
+		testIntegerObject(t, result, int64(i))
+	}
+}
+
+func TestLazyPipeline(t *testing.T) {
+	pulled := 0
+	source := val.ITERATOR(func() (val.ZValue, bool) {
+		pulled++
+		return val.INT(int64(pulled)), true
+	})
+
+	state := NewZmolState(nil)
+	state.Env.Set("numbers", source)
+	evaluated, err := state.Eval("numbers >- fn(x) { x % 2 == 0 }{} -> fn(x) { x * 10 }{}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stream, ok := evaluated.(*val.ZIterator)
+	if !ok {
+		t.Fatalf("object is not Iterator. got=%T (%+v)", evaluated, evaluated)
+	}
+	if pulled != 0 {
+		t.Fatalf("pipeline consumed its source eagerly, pulled=%d", pulled)
+	}
+
+	for _, expected := range []int64{20, 40} {
+		elem, ok := stream.Next()
+		if !ok {
+			t.Fatalf("iterator ended early")
+		}
+		testIntegerObject(t, elem, expected)
+	}
+	if pulled != 4 {
+		t.Errorf("wrong number of elements pulled. got=%d, want=4", pulled)
+	}
+}
+
+// Functions called from several goroutines share the environment they were
+// defined in, while another goroutine assigns to it.
+func TestConcurrentCalls(t *testing.T) {
+	state := NewZmolState(nil)
+	fn, err := state.Eval("offset = 100\nadd = @(x) { y = x * 2\n y + offset }\nadd")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := make([]val.ZValue, 50)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = CallFunction(fn, val.INT(int64(i)))
+		}(i)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			state.Env.Set("unrelated", val.INT(int64(i)))
+		}
+	}()
+	wg.Wait()
+
+	for i, result := range results {
+		testIntegerObject(t, result, int64(2*i+100))
+	}
+}
+
 func testEval(input string) val.ZValue {
 	state := NewZmolState(nil)
 	value, err := state.Eval(input)
@@ -101,3 +648,18 @@ func testIntegerObject(t *testing.T, obj val.ZValue, expected int64) bool {
 
 	return true
 }
+
+func testBooleanObject(t *testing.T, obj val.ZValue, expected bool) bool {
+	result, ok := obj.(*val.ZBool)
+	if !ok {
+		t.Errorf("object is not Boolean. got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%t, want=%t", result.Value, expected)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,132 @@
+package eval
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// The number of elements a parallel pipeline stage pulls from its source for
+// each worker at a time.
+const parallelBatchPerWorker = 4
+
+// DefaultWorkers returns the number of goroutines used by parallel pipeline
+// stages, one per CPU that Go can use.
+func DefaultWorkers() int {
+	return runtime.GOMAXPROCS(0)
+}
+
+// ParallelApply calls fn on each element on a pool of goroutines, and returns
+// the results in the order of the elements. Each call of a user-defined
+// function is evaluated in its own state, so calls only share the environment
+// the function was defined in. At the first error, in the order of the
+// elements, no more calls are started, and the results before the error are
+// returned with the error.
+func ParallelApply(elems []val.ZValue, workers int, fn func(val.ZValue) val.ZValue) ([]val.ZValue, val.ZValue) {
+	if workers > len(elems) {
+		workers = len(elems)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]val.ZValue, len(elems))
+	next := int64(-1)
+	failed := int64(len(elems)) // the lowest index with an error
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				// Indices are taken in increasing order, so once one is past an
+				// error, so are all the next ones.
+				i := atomic.AddInt64(&next, 1)
+				if i >= int64(len(elems)) || i > atomic.LoadInt64(&failed) {
+					return
+				}
+				results[i] = fn(elems[i])
+				if !isErr(results[i]) {
+					continue
+				}
+				for {
+					lowest := atomic.LoadInt64(&failed)
+					if i >= lowest || atomic.CompareAndSwapInt64(&failed, lowest, i) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if failed < int64(len(elems)) {
+		return results[:failed], results[failed]
+	}
+	return results, nil
+}
+
+// Returns a lazy iterator applying a map (-> par) or filter (>- par) stage to
+// the elements of a stream on a pool of goroutines. Elements are pulled from
+// the stream in batches, so that a lazy source is not consumed all at once,
+// and the results keep the order of the elements.
+func (s *ZmolState) applyParallel(fn val.ZValue, stream *val.ZIterator, args []val.ZValue, filter bool) val.ZValue {
+	stage := s.pipelineStage(fn, args)
+	if filter {
+		stage = filterStage(stage)
+	}
+	workers := DefaultWorkers()
+
+	var pending []val.ZValue
+	return val.ITERATOR(func() (val.ZValue, bool) {
+		for len(pending) == 0 {
+			var batch []val.ZValue
+			var upstreamErr val.ZValue
+			for len(batch) < parallelBatchPerWorker*workers {
+				elem, ok := stream.Next()
+				if !ok {
+					break
+				}
+				if isErr(elem) {
+					upstreamErr = elem
+					break
+				}
+				batch = append(batch, elem)
+			}
+			if len(batch) == 0 && upstreamErr == nil {
+				return nil, false
+			}
+
+			results, err := ParallelApply(batch, workers, stage)
+			for i, result := range results {
+				if !filter {
+					pending = append(pending, result)
+				} else if result.(*val.ZBool).Value {
+					pending = append(pending, batch[i])
+				}
+			}
+			// The iterator ends at the first error
+			if err != nil {
+				pending = append(pending, err)
+			} else if upstreamErr != nil {
+				pending = append(pending, upstreamErr)
+			}
+		}
+		next := pending[0]
+		pending = pending[1:]
+		return next, true
+	})
+}
+
+// Wraps a filter stage so that a result that is not a boolean is an error.
+func filterStage(stage func(val.ZValue) val.ZValue) func(val.ZValue) val.ZValue {
+	return func(elem val.ZValue) val.ZValue {
+		keep := stage(elem)
+		if !isErr(keep) && keep.Type() != val.ZBOOL {
+			return &val.ZError{Message: "Filter function must return a boolean, got " + string(keep.Type())}
+		}
+		return keep
+	}
+}
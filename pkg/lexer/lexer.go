@@ -2,8 +2,8 @@ package lexer
 
 import (
 	"errors"
-	"fmt"
 	"strconv"
+	"strings"
 	"unicode"
 )
 
@@ -15,10 +15,16 @@ const (
 	TokSlash             = "/"
 	TokAster             = "*"
 	TokMod               = "%"
+	TokFloorDiv          = "//"
+	TokPow               = "**"
 	TokAnd               = "&&"
 	TokOr                = "||"
 	TokBitAnd            = "&"
 	TokBitOr             = "|"
+	TokBitXor            = "^"
+	TokBitNot            = "~"
+	TokShl               = "<<"
+	TokShr               = ">>"
 	TokEq                = "=="
 	TokNotEq             = "!="
 	TokGt                = ">"
@@ -53,7 +59,9 @@ const (
 	TokAs                = "as"
 	TokInt               = "INT"
 	TokFloat             = "FLOAT"
+	TokDecimal           = "DECIMAL"
 	TokString            = "STRING"
+	TokBytes             = "BYTES"
 )
 
 var SingularTokOps = map[rune]TokType{
@@ -65,6 +73,8 @@ var SingularTokOps = map[rune]TokType{
 	'%': TokMod,
 	'&': TokBitAnd,
 	'|': TokBitOr,
+	'^': TokBitXor,
+	'~': TokBitNot,
 	'>': TokGt,
 	'<': TokLt,
 	'.': TokDot,
@@ -130,6 +140,15 @@ func (z *ZLex) addIdent() {
 	}
 }
 
+// Reports whether the character at index i can be part of an identifier.
+func (z *ZLex) isIdentChar(i int) bool {
+	if i >= len(z.code) {
+		return false
+	}
+	c := rune(z.code[i])
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_'
+}
+
 func (z *ZLex) addNumber() {
 	// handle integer and float. Make sure there is only one dot
 	var nChar int
@@ -143,18 +162,24 @@ func (z *ZLex) addNumber() {
 		}
 		nChar++
 	}
-	if hasDot {
+	// A trailing `d` marks a decimal literal, e.g., 12.50d
+	if z.i+nChar < len(z.code) && z.code[z.i+nChar] == 'd' && !z.isIdentChar(z.i+nChar+1) {
+		z.addTok(TokDecimal, nChar+1)
+	} else if hasDot {
 		z.addTok(TokFloat, nChar)
 	} else {
 		z.addTok(TokInt, nChar)
 	}
 }
 
-func (z *ZLex) addString() {
+// Adds a string literal, or a bytes literal such as b"\x00\xff" if tokType is
+// TokBytes, starting at the opening quote. Characters are copied byte by byte,
+// so that UTF-8 text is kept as is.
+func (z *ZLex) addString(tokType TokType) {
 	var nChar int
 	z.advanceIndex(1)
 
-	out := ""
+	var out strings.Builder
 	for z.i+nChar < len(z.code) && z.code[z.i+nChar] != '"' {
 		if z.code[z.i+nChar] == '\\' {
 			if z.i+nChar+1 >= len(z.code) {
@@ -162,20 +187,36 @@ func (z *ZLex) addString() {
 			}
 
 			// TODO: handle more escape sequences
-			switch z.code[z.i+nChar+1] {
-			case 'r':
-				fmt.Println("the string contains \\r, which cannot be parsed properly yet")
-				out += "\r"
-			case 'n':
-				out += "\n"
-			case 't':
-				out += "\t"
-			case '\\':
-				out += "\\"
+			switch c := z.code[z.i+nChar+1]; {
+			case c == 'r':
+				out.WriteByte('\r')
+			case c == 'n':
+				out.WriteByte('\n')
+			case c == 't':
+				out.WriteByte('\t')
+			case c == '\\':
+				out.WriteByte('\\')
+			case c == '"':
+				out.WriteByte('"')
+			case c == 'x' && tokType == TokBytes:
+				if z.i+nChar+4 > len(z.code) {
+					panic(errors.New("invalid escape sequence"))
+				}
+				b, err := strconv.ParseUint(z.code[z.i+nChar+2:z.i+nChar+4], 16, 8)
+				if err != nil {
+					panic(errors.New("invalid escape sequence"))
+				}
+				out.WriteByte(byte(b))
+				nChar += 2
+			default:
+				// Other escapes are kept, so that patterns such as "\d+"
+				// can be written without doubling the backslash.
+				out.WriteByte('\\')
+				out.WriteByte(c)
 			}
 			nChar += 2
 		} else {
-			out += string(z.code[z.i+nChar])
+			out.WriteByte(z.code[z.i+nChar])
 			nChar++
 		}
 	}
@@ -184,8 +225,8 @@ func (z *ZLex) addString() {
 		panic(errors.New("unterminated string"))
 	}
 	z.Tokens = append(z.Tokens, ZTok{
-		Type: TokString,
-		Text: out,
+		Type: tokType,
+		Text: out.String(),
 		Col:  z.Col,
 		Row:  z.Row,
 	})
@@ -221,8 +262,16 @@ func (z *ZLex) Lex() error {
 				z.addTok(TokGTE, 2)
 			} else if z.code[z.i] == '>' && z.i+1 < len(z.code) && z.code[z.i+1] == '-' {
 				z.addTok(TokFilter, 2) // Token `>-`
+			} else if z.code[z.i] == '>' && z.i+1 < len(z.code) && z.code[z.i+1] == '>' {
+				z.addTok(TokShr, 2)
 			} else if z.code[z.i] == '<' && z.i+1 < len(z.code) && z.code[z.i+1] == '=' {
 				z.addTok(TokLTE, 2)
+			} else if z.code[z.i] == '<' && z.i+1 < len(z.code) && z.code[z.i+1] == '<' {
+				z.addTok(TokShl, 2)
+			} else if z.code[z.i] == '/' && z.i+1 < len(z.code) && z.code[z.i+1] == '/' {
+				z.addTok(TokFloorDiv, 2)
+			} else if z.code[z.i] == '*' && z.i+1 < len(z.code) && z.code[z.i+1] == '*' {
+				z.addTok(TokPow, 2)
 			} else if z.code[z.i] == '=' && z.i+1 < len(z.code) && z.code[z.i+1] == '=' {
 				z.addTok(TokEq, 2)
 			} else if z.code[z.i] == '!' && z.i+1 < len(z.code) && z.code[z.i+1] == '=' {
@@ -255,12 +304,15 @@ func (z *ZLex) Lex() error {
 			} else {
 				z.addTok(tokType, 1)
 			}
+		} else if z.code[z.i] == 'b' && z.i+1 < len(z.code) && z.code[z.i+1] == '"' {
+			z.advanceIndex(1)
+			z.addString(TokBytes)
 		} else if unicode.IsLetter(rune(z.code[z.i])) || z.code[z.i] == '_' {
 			z.addIdent()
 		} else if unicode.IsDigit(rune(z.code[z.i])) {
 			z.addNumber()
 		} else if z.code[z.i] == '"' {
-			z.addString()
+			z.addString(TokString)
 		} else {
 			return errors.New(
 				"Invalid token: " +
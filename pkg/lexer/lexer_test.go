@@ -200,3 +200,118 @@ func TestCurlyBraces(t *testing.T) {
 		}
 	}
 }
+
+func TestArithmeticAndBitwiseOps(t *testing.T) {
+	lexer := NewLexer("a // b ** c << d >> e & f | g ^ ~h")
+	err := lexer.Lex()
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	expectedTokens := []ZTok{
+		{Type: TokIdent, Text: "a"},
+		{Type: TokFloorDiv, Text: "//"},
+		{Type: TokIdent, Text: "b"},
+		{Type: TokPow, Text: "**"},
+		{Type: TokIdent, Text: "c"},
+		{Type: TokShl, Text: "<<"},
+		{Type: TokIdent, Text: "d"},
+		{Type: TokShr, Text: ">>"},
+		{Type: TokIdent, Text: "e"},
+		{Type: TokBitAnd, Text: "&"},
+		{Type: TokIdent, Text: "f"},
+		{Type: TokBitOr, Text: "|"},
+		{Type: TokIdent, Text: "g"},
+		{Type: TokBitXor, Text: "^"},
+		{Type: TokBitNot, Text: "~"},
+		{Type: TokIdent, Text: "h"},
+		{Type: TokEOF, Text: ""},
+	}
+
+	if len(lexer.Tokens) != len(expectedTokens) {
+		t.Fatalf("Expected %d tokens, got %d", len(expectedTokens), len(lexer.Tokens))
+	}
+
+	for i, tok := range lexer.Tokens {
+		if tok.Type != expectedTokens[i].Type {
+			t.Errorf("Expected token %d to be %v, got %v", i, expectedTokens[i], tok)
+		}
+	}
+}
+
+func TestDecimalLiteral(t *testing.T) {
+	lexer := NewLexer("12.50d + 3d - dd")
+	err := lexer.Lex()
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	expectedTokens := []ZTok{
+		{Type: TokDecimal, Text: "12.50d"},
+		{Type: TokPlus, Text: "+"},
+		{Type: TokDecimal, Text: "3d"},
+		{Type: TokMinus, Text: "-"},
+		{Type: TokIdent, Text: "dd"},
+		{Type: TokEOF, Text: ""},
+	}
+
+	if len(lexer.Tokens) != len(expectedTokens) {
+		t.Fatalf("Expected %d tokens, got %d", len(expectedTokens), len(lexer.Tokens))
+	}
+
+	for i, tok := range lexer.Tokens {
+		if tok.Type != expectedTokens[i].Type || tok.Text != expectedTokens[i].Text {
+			t.Errorf("Expected token %d to be %v, got %v", i, expectedTokens[i], tok)
+		}
+	}
+}
+
+func TestStringEscapes(t *testing.T) {
+	lexer := NewLexer(`"{\"a\": \"b\\c\"}\n\d+\r\t"`)
+	err := lexer.Lex()
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	expectedTokens := []ZTok{
+		{Type: TokString, Text: "{\"a\": \"b\\c\"}\n\\d+\r\t"},
+		{Type: TokEOF, Text: ""},
+	}
+
+	if len(lexer.Tokens) != len(expectedTokens) {
+		t.Fatalf("Expected %d tokens, got %d", len(expectedTokens), len(lexer.Tokens))
+	}
+
+	for i, tok := range lexer.Tokens {
+		if tok.Type != expectedTokens[i].Type || tok.Text != expectedTokens[i].Text {
+			t.Errorf("Expected token %d to be %v, got %v", i, expectedTokens[i], tok)
+		}
+	}
+}
+
+func TestBytesLiteral(t *testing.T) {
+	lexer := NewLexer(`b"\x00ab\xff" + "é" + b`)
+	err := lexer.Lex()
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	expectedTokens := []ZTok{
+		{Type: TokBytes, Text: "\x00ab\xff"},
+		{Type: TokPlus, Text: "+"},
+		{Type: TokString, Text: "é"},
+		{Type: TokPlus, Text: "+"},
+		{Type: TokIdent, Text: "b"},
+		{Type: TokEOF, Text: ""},
+	}
+
+	if len(lexer.Tokens) != len(expectedTokens) {
+		t.Fatalf("Expected %d tokens, got %d", len(expectedTokens), len(lexer.Tokens))
+	}
+
+	for i, tok := range lexer.Tokens {
+		if tok.Type != expectedTokens[i].Type || tok.Text != expectedTokens[i].Text {
+			t.Errorf("Expected token %d to be %v, got %v", i, expectedTokens[i], tok)
+		}
+	}
+}
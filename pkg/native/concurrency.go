@@ -0,0 +1,103 @@
+package native
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ariaghora/zmol/pkg/eval"
+	"github.com/ariaghora/zmol/pkg/native/std"
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// spawn(fn, args...) calls a function with the given arguments on its own
+// goroutine, and returns a task to wait for its result. A script does not wait
+// for its tasks to finish, unless it joins them.
+func Z_spawn(args ...val.ZValue) val.ZValue {
+	if len(args) < 1 || !eval.IsCallable(args[0]) {
+		return &val.ZError{Message: "spawn() takes a function as argument 1"}
+	}
+	fn, fnArgs := args[0], args[1:]
+
+	name := "<builtin>"
+	if callable, ok := fn.(val.ZCallable); ok {
+		// Checked here, as a wrong call on the task would stop the interpreter
+		if len(callable.Params()) != len(fnArgs) {
+			return &val.ZError{Message: fmt.Sprintf("spawn() function takes %d arguments, got %d", len(callable.Params()), len(fnArgs))}
+		}
+		name = callable.Name()
+	}
+
+	task := val.TASK(name)
+	go func() {
+		task.Finish(eval.CallFunction(fn, fnArgs...))
+	}()
+	return task
+}
+
+// channel([capacity]) returns a channel that holds up to capacity values
+// before sending blocks. By default, sending waits for a receiver.
+func Z_channel(args ...val.ZValue) val.ZValue {
+	if len(args) == 0 {
+		return val.CHANNEL(0)
+	}
+	if len(args) != 1 || args[0].Type() != val.ZINT || args[0].(*val.ZInt).Value < 0 {
+		return &val.ZError{Message: "channel() takes a non-negative capacity"}
+	}
+	return val.CHANNEL(int(args[0].(*val.ZInt).Value))
+}
+
+// select(channels[, timeout]) waits until one of the channels has a value, and
+// returns [i, value], where i is the index of the channel. Closed channels are
+// skipped. It returns null if the timeout, a duration or a number of seconds,
+// expires first, and an error if all the channels are closed.
+func Z_select(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 && len(args) != 2 {
+		return &val.ZError{Message: "select() takes 1 or 2 arguments"}
+	}
+	list, ok := args[0].(*val.ZList)
+	if !ok {
+		return &val.ZError{Message: "select() takes a list of channels as argument 1"}
+	}
+	channels := make([]*val.ZChannel, len(list.Elements))
+	for i, e := range list.Elements {
+		if channels[i], ok = e.(*val.ZChannel); !ok {
+			return &val.ZError{Message: fmt.Sprintf("select() takes a list of channels, got %s at index %d", e.Type(), i)}
+		}
+	}
+
+	timeout := time.Duration(-1)
+	if len(args) == 2 {
+		if d, ok := args[1].(*val.ZDuration); ok {
+			timeout = d.Value
+		} else if seconds, err := std.EnsureFloat(args[1]); err == nil {
+			timeout = time.Duration(seconds * float64(time.Second))
+		} else {
+			return &val.ZError{Message: "select() takes a duration or a number of seconds as argument 2"}
+		}
+	}
+
+	i, value, ok := val.Select(channels, timeout)
+	if !ok {
+		return &val.ZError{Message: "select() all channels are closed"}
+	}
+	if i < 0 {
+		return val.NULL()
+	}
+	return &val.ZList{Elements: []val.ZValue{val.INT(int64(i)), value}}
+}
+
+// mutex() returns an unlocked mutex.
+func Z_mutex(args ...val.ZValue) val.ZValue {
+	if len(args) != 0 {
+		return &val.ZError{Message: "mutex() takes no arguments"}
+	}
+	return val.MUTEX()
+}
+
+// wait_group() returns a wait group with no pending tasks.
+func Z_wait_group(args ...val.ZValue) val.ZValue {
+	if len(args) != 0 {
+		return &val.ZError{Message: "wait_group() takes no arguments"}
+	}
+	return val.WAITGROUP()
+}
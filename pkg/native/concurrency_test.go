@@ -0,0 +1,128 @@
+package native_test
+
+import (
+	"testing"
+
+	"github.com/ariaghora/zmol/pkg/eval"
+	"github.com/ariaghora/zmol/pkg/native"
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+func newState() *eval.ZmolState {
+	state := eval.NewZmolState(nil)
+	native.NewNativeFuncRegistry(state).RegisterNativeFunc()
+	return state
+}
+
+func testScript(t *testing.T, input string, expected string) {
+	t.Helper()
+	evaluated, err := newState().Eval(input)
+	if err != nil {
+		t.Fatalf("%s: %v", input, err)
+	}
+	if evaluated.Str() != expected {
+		t.Errorf("%s: got=%s, want=%s", input, evaluated.Str(), expected)
+	}
+}
+
+func TestSpawn(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"add = @(a, b) { a + b }\nspawn(add, 1, 2).join()", "3"},
+		{"add = @(a, b) { a + b }\nt = spawn(add, 1, 2)\nt.join()\nr = [t.done(), t.result()]\nr", "[true, 3]"},
+		{"add = @(a, b) { a + b }\nspawn(add, 1)", "ERROR: spawn() function takes 2 arguments, got 1"},
+		{"spawn(1)", "ERROR: spawn() takes a function as argument 1"},
+		{"f = @(x) { 1 / x }\nspawn(f, 0).join()", "ERROR: division by zero"},
+		{"tasks = [1, 2, 3] -> spawn{@(x) { x * x }, _}\ntasks -> @(t) { t.join() }{}", "[1, 4, 9]"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, tt.input, tt.expected)
+	}
+}
+
+func TestChannels(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"c = channel(2)\nc.send(1)\nc.send(2)\nc.close()\ncollect(c)", "[1, 2]"},
+		{"c = channel()\nc.close()\nr = [c.closed, c.send(1)]\nr", "[true, ERROR: send() on a closed channel]"},
+		{"c = channel()\nc.close()\nc.recv()", "ERROR: recv() on a closed channel"},
+		{"c = channel()\nc.close()\nc.close()", "ERROR: close() on a closed channel"},
+		{"c = channel(-1)", "ERROR: channel() takes a non-negative capacity"},
+		{"a = channel()\nb = channel(1)\nb.send(7)\nselect([a, b])", "[1, 7]"},
+		{"a = channel()\nselect([a], 0.01)", ""},
+		{"a = channel()\na.close()\nselect([a])", "ERROR: select() all channels are closed"},
+		{"select([1])", "ERROR: select() takes a list of channels, got Int at index 0"},
+		{"c = channel()\nsend = @(n) { c.send(n)\n c.close() }\nspawn(send, 5)\nc.recv()", "5"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, tt.input, tt.expected)
+	}
+}
+
+func TestMutexAndWaitGroup(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"m = mutex()\nr = [m.try_lock(), m.try_lock(), m.unlock(), m.unlock()]\nr", "[true, false, , ERROR: unlock() on an unlocked mutex]"},
+		{"wg = wait_group()\nwg.add(2)\nwg.done()\nwg.pending", "1"},
+		{"wg = wait_group()\nwg.done()", "ERROR: done() called more times than add()"},
+		{`m = mutex()
+		wg = wait_group()
+		counts = table([["n", 0]])
+		work = @(k) {
+			m.lock()
+			counts.n = counts.n + k
+			m.unlock()
+			wg.done()
+		}
+		iter range_list(0, 100) as k {
+			wg.add()
+			spawn(work, k)
+		}
+		wg.wait()
+		counts.n`, "4950"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, tt.input, tt.expected)
+	}
+}
+
+// The random and decimal modules keep process-wide state, which tasks must be
+// able to use at the same time. Run with -race to check it.
+func TestSpawnSharedModuleState(t *testing.T) {
+	state := newState()
+	input := `
+	random = import("random")
+	decimal = import("decimal")
+	work = @(n) {
+		random.seed(n)
+		decimal.set_division_scale(10 + n)
+		x = random.uniform(0, 1) + random.normal(0, 1)
+		random.shuffle([1, 2, 3])
+		decimal.round(1d / 3, 2)
+	}
+	tasks = range_list(0, 16) -> spawn{work, _}
+	tasks -> @(t) { t.join() }{}
+	`
+	evaluated, err := state.Eval(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, ok := evaluated.(*val.ZList)
+	if !ok || len(results.Elements) != 16 {
+		t.Fatalf("expected 16 results, got=%s", evaluated.Str())
+	}
+	for _, result := range results.Elements {
+		if result.Str() != "0.33" {
+			t.Errorf("expected 0.33, got=%s", result.Str())
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package native
 
 import (
+	"unicode/utf8"
+
 	"github.com/ariaghora/zmol/pkg/eval"
 	"github.com/ariaghora/zmol/pkg/val"
 )
@@ -44,21 +46,24 @@ func Z_filter(args ...val.ZValue) val.ZValue {
 	return list
 }
 
+// len(x) returns the number of elements of a list, keys of a table, bytes, or
+// characters of a string.
 func Z_len(args ...val.ZValue) val.ZValue {
 	if len(args) != 1 {
 		eval.RuntimeErrorf("len takes 1 argument")
 	}
 
-	list := args[0]
-	if list.Type() != val.ZLIST {
-		eval.RuntimeErrorf("len takes a list")
-	}
-
-	if list.Type() == val.ZLIST {
-		return &val.ZInt{Value: int64(len(list.(*val.ZList).Elements))}
-	} else {
-		return &val.ZInt{Value: int64(len(list.(*val.ZString).Value))}
+	switch x := args[0].(type) {
+	case *val.ZList:
+		return &val.ZInt{Value: int64(len(x.Elements))}
+	case *val.ZTable:
+		return &val.ZInt{Value: int64(x.Len())}
+	case *val.ZString:
+		return &val.ZInt{Value: int64(utf8.RuneCountInString(x.Value))}
+	case *val.ZBytes:
+		return &val.ZInt{Value: int64(len(x.Value))}
 	}
+	return eval.RuntimeErrorf("len takes a list, a table, a string or bytes")
 }
 
 func Z_reduce(args ...val.ZValue) val.ZValue {
@@ -154,3 +159,28 @@ func Z_zip(args ...val.ZValue) val.ZValue {
 	}
 	return list
 }
+
+// Wraps a list, a string, or an iterator as a lazy iterator. Pipelines over an
+// iterator are evaluated lazily, one element at a time.
+func Z_lazy(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "lazy takes 1 argument"}
+	}
+	it, ok := val.Iterate(args[0])
+	if !ok {
+		return &val.ZError{Message: "lazy takes a list, a string, or an iterator"}
+	}
+	return it
+}
+
+// Consumes an iterator into a list.
+func Z_collect(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "collect takes 1 argument"}
+	}
+	it, ok := val.Iterate(args[0])
+	if !ok {
+		return &val.ZError{Message: "collect takes a list, a string, or an iterator"}
+	}
+	return val.Collect(it)
+}
@@ -2,10 +2,13 @@ package native
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math/big"
 	"path"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/ariaghora/zmol/pkg/eval"
 	"github.com/ariaghora/zmol/pkg/native/goplugin"
@@ -15,6 +18,28 @@ import (
 
 type NativeFuncRegistry struct {
 	zState *eval.ZmolState
+
+	// Policy restricts what scripts can access. Modules imported by a script
+	// are under the same policy.
+	Policy Policy
+}
+
+// Policy is a sandbox policy, for hosts that run scripts they do not trust.
+// The zero value allows everything.
+type Policy struct {
+	// Names of the std modules that cannot be imported, e.g., "os" so that
+	// scripts cannot run programs or exit the interpreter.
+	DeniedModules []string
+}
+
+// Reports whether the policy denies importing a std module.
+func (p Policy) denies(module string) bool {
+	for _, denied := range p.DeniedModules {
+		if denied == module {
+			return true
+		}
+	}
+	return false
 }
 
 func NewNativeFuncRegistry(zState *eval.ZmolState) *NativeFuncRegistry {
@@ -26,27 +51,57 @@ func NewNativeFuncRegistry(zState *eval.ZmolState) *NativeFuncRegistry {
 func (reg *NativeFuncRegistry) RegisterNativeFunc() {
 	// Constructs
 	reg.zState.Env.Set("import", &val.ZNativeFunc{Fn: reg.Z_import})
-	reg.zState.Env.Set("print", &val.ZNativeFunc{Fn: Z_print})
-	reg.zState.Env.Set("println", &val.ZNativeFunc{Fn: Z_println})
 	reg.zState.Env.Set("range_list", &val.ZNativeFunc{Fn: Z_range_list})
 
+	// standard streams
+	reg.zState.Env.Set("print", &val.ZNativeFunc{Fn: reg.Z_print})
+	reg.zState.Env.Set("println", &val.ZNativeFunc{Fn: reg.Z_println})
+	reg.zState.Env.Set("eprint", &val.ZNativeFunc{Fn: reg.Z_eprint})
+	reg.zState.Env.Set("eprintln", &val.ZNativeFunc{Fn: reg.Z_eprintln})
+	reg.zState.Env.Set("input", &val.ZNativeFunc{Fn: reg.Z_input})
+	reg.zState.Env.Set("read_all_stdin", &val.ZNativeFunc{Fn: reg.Z_read_all_stdin})
+	reg.zState.Env.Set("stdin_lines", &val.ZNativeFunc{Fn: reg.Z_stdin_lines})
+
 	// Object creation
 	reg.zState.Env.Set("class", &val.ZNativeFunc{Fn: reg.Z_class})
 
 	// itertools
 	reg.zState.Env.Set("append", &val.ZNativeFunc{Fn: Z_append})
+	reg.zState.Env.Set("collect", &val.ZNativeFunc{Fn: Z_collect})
 	reg.zState.Env.Set("filter", &val.ZNativeFunc{Fn: Z_filter})
+	reg.zState.Env.Set("lazy", &val.ZNativeFunc{Fn: Z_lazy})
 	reg.zState.Env.Set("len", &val.ZNativeFunc{Fn: Z_len})
 	reg.zState.Env.Set("reduce", &val.ZNativeFunc{Fn: Z_reduce})
 	reg.zState.Env.Set("reverse", &val.ZNativeFunc{Fn: Z_reverse})
 	reg.zState.Env.Set("zip", &val.ZNativeFunc{Fn: Z_zip})
 
+	// tables
+	reg.zState.Env.Set("table", &val.ZNativeFunc{Fn: Z_table})
+	reg.zState.Env.Set("keys", &val.ZNativeFunc{Fn: Z_keys})
+	reg.zState.Env.Set("values", &val.ZNativeFunc{Fn: Z_values})
+	reg.zState.Env.Set("has_key", &val.ZNativeFunc{Fn: Z_has_key})
+	reg.zState.Env.Set("delete", &val.ZNativeFunc{Fn: Z_delete})
+
 	// string manipulation
 	reg.zState.Env.Set("split", &val.ZNativeFunc{Fn: Z_split})
+	reg.zState.Env.Set("encode", &val.ZNativeFunc{Fn: Z_encode})
+	reg.zState.Env.Set("decode", &val.ZNativeFunc{Fn: Z_decode})
 
 	// type conversion
 	reg.zState.Env.Set("int", &val.ZNativeFunc{Fn: Z_int})
 	reg.zState.Env.Set("float", &val.ZNativeFunc{Fn: Z_float})
+	reg.zState.Env.Set("decimal", &val.ZNativeFunc{Fn: std.Z_decimal})
+	reg.zState.Env.Set("bytes", &val.ZNativeFunc{Fn: Z_bytes})
+
+	// error handling
+	reg.zState.Env.Set("is_error", &val.ZNativeFunc{Fn: Z_is_error})
+
+	// concurrency
+	reg.zState.Env.Set("spawn", &val.ZNativeFunc{Fn: Z_spawn})
+	reg.zState.Env.Set("channel", &val.ZNativeFunc{Fn: Z_channel})
+	reg.zState.Env.Set("select", &val.ZNativeFunc{Fn: Z_select})
+	reg.zState.Env.Set("mutex", &val.ZNativeFunc{Fn: Z_mutex})
+	reg.zState.Env.Set("wait_group", &val.ZNativeFunc{Fn: Z_wait_group})
 }
 
 func (reg *NativeFuncRegistry) Z_import(args ...val.ZValue) val.ZValue {
@@ -58,25 +113,59 @@ func (reg *NativeFuncRegistry) Z_import(args ...val.ZValue) val.ZValue {
 		return &val.ZError{Message: "import takes 1 string"}
 	}
 
+	name := args[0].(*val.ZString).Value
+	if reg.Policy.denies(name) {
+		return &val.ZError{Message: fmt.Sprintf("import: module \"%s\" is denied by the sandbox policy", name)}
+	}
+
 	// Try import std lib
-	switch args[0].(*val.ZString).Value {
+	switch name {
+	case "crypto":
+		return std.CryptoModule
+	case "csv":
+		return std.CSVModule
+	case "decimal":
+		return std.DecimalModule
+	case "encoding":
+		return std.EncodingModule
+	case "fs":
+		return std.FSModule
+	case "functools":
+		return std.FunctoolsModule
 	case "goplugin":
 		return goplugin.GoPluginModule
+	case "grad":
+		return std.GradModule
+	case "http":
+		return std.HTTPModule
 	case "io":
 		return std.IOModule
+	case "json":
+		return std.JSONModule
 	case "math":
 		return std.MathModule
+	case "os":
+		return std.NewOSModule(reg.zState.Args)
+	case "parallel":
+		return std.ParallelModule
+	case "random":
+		return std.RandomModule
+	case "re":
+		return std.ReModule
+	case "stats":
+		return std.StatsModule
 	case "tensor":
 		return std.TensorModule
 	case "testing":
 		return std.TestingModule
+	case "time":
+		return std.TimeModule
 	}
 
 	// FIXME: handle !ok
 	dir, _ := reg.zState.Env.Get("__moddir__")
 
-	modulePath := args[0].(*val.ZString).Value
-	modulePath = path.Join(dir.Str(), modulePath)
+	modulePath := path.Join(dir.Str(), name)
 
 	// TODO: Check duplicate import
 
@@ -88,9 +177,13 @@ func (reg *NativeFuncRegistry) Z_import(args ...val.ZValue) val.ZValue {
 	}
 
 	zState := eval.NewZmolState(nil)
+	zState.Stdin, zState.Stdout, zState.Stderr = reg.zState.Stdin, reg.zState.Stdout, reg.zState.Stderr
+	zState.Args = reg.zState.Args
 	moduleDir := filepath.Dir(modulePath)
 	zState.Env.Set("__moddir__", &val.ZString{Value: moduleDir})
-	NewNativeFuncRegistry(zState).RegisterNativeFunc()
+	moduleReg := NewNativeFuncRegistry(zState)
+	moduleReg.Policy = reg.Policy
+	moduleReg.RegisterNativeFunc()
 
 	_, err = zState.Eval(string(content))
 	if err != nil {
@@ -100,21 +193,101 @@ func (reg *NativeFuncRegistry) Z_import(args ...val.ZValue) val.ZValue {
 	return val.MODULE(modulePath, zState.Env)
 }
 
-func Z_print(args ...val.ZValue) val.ZValue {
+func (reg *NativeFuncRegistry) Z_print(args ...val.ZValue) val.ZValue {
+	for _, arg := range args {
+		fmt.Fprint(reg.zState.Stdout, arg.Str())
+	}
+	return &val.ZNull{}
+}
+
+func (reg *NativeFuncRegistry) Z_println(args ...val.ZValue) val.ZValue {
+	for _, arg := range args {
+		fmt.Fprint(reg.zState.Stdout, arg.Str())
+	}
+	fmt.Fprintln(reg.zState.Stdout)
+	return &val.ZNull{}
+}
+
+// eprint prints to the standard error, e.g., for diagnostics that should not
+// mix with the output of a script in a pipeline.
+func (reg *NativeFuncRegistry) Z_eprint(args ...val.ZValue) val.ZValue {
 	for _, arg := range args {
-		fmt.Print(arg.Str())
+		fmt.Fprint(reg.zState.Stderr, arg.Str())
 	}
 	return &val.ZNull{}
 }
 
-func Z_println(args ...val.ZValue) val.ZValue {
+func (reg *NativeFuncRegistry) Z_eprintln(args ...val.ZValue) val.ZValue {
 	for _, arg := range args {
-		fmt.Print(arg.Str())
+		fmt.Fprint(reg.zState.Stderr, arg.Str())
 	}
-	fmt.Println()
+	fmt.Fprintln(reg.zState.Stderr)
 	return &val.ZNull{}
 }
 
+// Reads a line from the standard input, without its line ending. The second
+// return value is false at the end of the input.
+func (reg *NativeFuncRegistry) readLine() (string, bool, error) {
+	line, err := reg.zState.Stdin.ReadString('\n')
+	if err == io.EOF {
+		if line == "" {
+			return "", false, nil
+		}
+		err = nil
+	}
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r"), true, err
+}
+
+// input([prompt]) prints the prompt and returns a line from the standard
+// input. At the end of the input, it returns an error.
+func (reg *NativeFuncRegistry) Z_input(args ...val.ZValue) val.ZValue {
+	if len(args) > 1 {
+		return &val.ZError{Message: "input takes 0 or 1 argument"}
+	}
+	if len(args) == 1 {
+		fmt.Fprint(reg.zState.Stdout, args[0].Str())
+	}
+	line, ok, err := reg.readLine()
+	if err != nil {
+		return &val.ZError{Message: "input: " + err.Error()}
+	}
+	if !ok {
+		return &val.ZError{Message: "input: end of input"}
+	}
+	return val.STRING(line)
+}
+
+// read_all_stdin() returns the rest of the standard input.
+func (reg *NativeFuncRegistry) Z_read_all_stdin(args ...val.ZValue) val.ZValue {
+	if len(args) != 0 {
+		return &val.ZError{Message: "read_all_stdin takes no arguments"}
+	}
+	content, err := io.ReadAll(reg.zState.Stdin)
+	if err != nil {
+		return &val.ZError{Message: "read_all_stdin: " + err.Error()}
+	}
+	return val.STRING(string(content))
+}
+
+// stdin_lines() returns an iterator over the lines of the standard input, so
+// that large inputs can be processed one line at a time.
+func (reg *NativeFuncRegistry) Z_stdin_lines(args ...val.ZValue) val.ZValue {
+	if len(args) != 0 {
+		return &val.ZError{Message: "stdin_lines takes no arguments"}
+	}
+	return val.ITERATOR(func() (val.ZValue, bool) {
+		line, ok, err := reg.readLine()
+		if err != nil {
+			return &val.ZError{Message: "stdin_lines: " + err.Error()}, true
+		}
+		if !ok {
+			return nil, false
+		}
+		return val.STRING(line), true
+	})
+}
+
 func Z_range_list(args ...val.ZValue) val.ZValue {
 	if len(args) != 2 {
 		return &val.ZError{Message: "range_list takes 2 arguments"}
@@ -150,7 +323,7 @@ func (reg *NativeFuncRegistry) Z_class(args ...val.ZValue) val.ZValue {
 		if parentClass.Type() != val.ZCLASS {
 			eval.RuntimeErrorf(string(parentClass.Type()) + " is not a class")
 		}
-		for k, v := range parentClass.(*val.ZClass).Env().SymTable {
+		for k, v := range parentClass.(*val.ZClass).Env().Symbols() {
 			classDef.Env().Set(k, v)
 		}
 	}
@@ -164,17 +337,19 @@ func Z_int(args ...val.ZValue) val.ZValue {
 	}
 
 	switch args[0].Type() {
-	case val.ZINT:
+	case val.ZINT, val.ZBIGINT:
 		return args[0]
 	case val.ZFLOAT:
 		return val.INT(int64(args[0].(*val.ZFloat).Value))
+	case val.ZDECIMAL:
+		return val.INTEGER(args[0].(*val.ZDecimal).Int())
 	case val.ZSTRING:
 		strval := args[0].(*val.ZString).Value
-		res, err := strconv.ParseInt(strval, 10, 64)
-		if err != nil {
+		res, ok := new(big.Int).SetString(strval, 10)
+		if !ok {
 			eval.RuntimeErrorf("cannot convert string \"" + strval + "\" to int")
 		}
-		return val.INT(res)
+		return val.INTEGER(res)
 	default:
 		eval.RuntimeErrorf("int() takes a number or string as argument")
 	}
@@ -189,6 +364,10 @@ func Z_float(args ...val.ZValue) val.ZValue {
 	switch args[0].Type() {
 	case val.ZINT:
 		return val.FLOAT(float64(args[0].(*val.ZInt).Value))
+	case val.ZBIGINT:
+		return val.FLOAT(args[0].(*val.ZBigInt).Float())
+	case val.ZDECIMAL:
+		return val.FLOAT(args[0].(*val.ZDecimal).Float())
 	case val.ZFLOAT:
 		return args[0]
 	case val.ZSTRING:
@@ -203,3 +382,23 @@ func Z_float(args ...val.ZValue) val.ZValue {
 	}
 	return &val.ZNull{}
 }
+
+// bytes(x) converts a string, as its UTF-8 encoding, or a list of integers
+// from 0 to 255 to bytes.
+func Z_bytes(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "bytes takes 1 argument"}
+	}
+	b, ok := val.ToBytes(args[0])
+	if !ok {
+		return &val.ZError{Message: "bytes() takes a string or a list of integers from 0 to 255"}
+	}
+	return val.BYTES(b)
+}
+
+func Z_is_error(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "is_error takes 1 argument"}
+	}
+	return val.BOOL(args[0].Type() == val.ZERROR)
+}
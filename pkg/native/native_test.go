@@ -0,0 +1,54 @@
+package native_test
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ariaghora/zmol/pkg/eval"
+	"github.com/ariaghora/zmol/pkg/native"
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// Scripts, and the modules they import, use the streams of their state.
+func TestStandardStreams(t *testing.T) {
+	dir := t.TempDir()
+	module := "greet = @(name) { println(\"hi \", name)\n eprintln(\"greeted \", name) }\n"
+	if err := os.WriteFile(filepath.Join(dir, "greet.zmol"), []byte(module), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	state := eval.NewZmolState(nil)
+	state.Stdin = bufio.NewReader(strings.NewReader("ann\r\nbob"))
+	state.Stdout, state.Stderr = &stdout, &stderr
+	native.NewNativeFuncRegistry(state).RegisterNativeFunc()
+	state.Env.Set("__moddir__", val.STRING(dir))
+
+	input := `name = input("name? ")
+	print("hello ", name)
+	println("!")
+	eprint("warning: ")
+	eprintln("no ", 1, "st name")
+	shout = @(s) { println(s + "!") }
+	shout(input())
+	g = import("greet.zmol")
+	g.greet(name)
+	input()`
+	evaluated, err := state.Eval(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evaluated.Str() != "ERROR: input: end of input" {
+		t.Errorf("expected the end of the input, got=%s", evaluated.Str())
+	}
+	if want := "name? hello ann!\nbob!\nhi ann\n"; stdout.String() != want {
+		t.Errorf("stdout: got=%q, want=%q", stdout.String(), want)
+	}
+	if want := "warning: no 1st name\ngreeted ann\n"; stderr.String() != want {
+		t.Errorf("stderr: got=%q, want=%q", stderr.String(), want)
+	}
+}
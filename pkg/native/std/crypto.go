@@ -0,0 +1,135 @@
+package std
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// Hashes, HMACs and secure random values. The hash functions take bytes, or a
+// string as its UTF-8 encoding, and return the digest as bytes, e.g.,
+// `encoding.hex_encode(crypto.sha256(data))` for the usual hexadecimal form.
+// MD5 and SHA-1 are only suited to checksums, not to security.
+var CryptoModule = val.MODULE(
+	"crypto",
+	&val.Env{
+		SymTable: map[string]val.ZValue{
+			// Hashes
+			"md5":    hashFunc("md5"),
+			"sha1":   hashFunc("sha1"),
+			"sha256": hashFunc("sha256"),
+			"sha512": hashFunc("sha512"),
+			"hmac":   &val.ZNativeFunc{Fn: Z_crypto_hmac},
+			"equal":  &val.ZNativeFunc{Fn: Z_crypto_equal},
+			// Random values
+			"random_bytes": &val.ZNativeFunc{Fn: Z_crypto_random_bytes},
+			"uuid":         &val.ZNativeFunc{Fn: Z_crypto_uuid},
+		},
+	},
+)
+
+var hashes = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// Returns a function that computes the digest of data with a hash.
+func hashFunc(name string) *val.ZNativeFunc {
+	return &val.ZNativeFunc{Fn: func(args ...val.ZValue) val.ZValue {
+		if len(args) != 1 {
+			return &val.ZError{Message: name + "() takes 1 argument"}
+		}
+		data, zErr := bytesArg(name, args, 0)
+		if zErr != nil {
+			return zErr
+		}
+		h := hashes[name]()
+		h.Write(data)
+		return val.BYTES(h.Sum(nil))
+	}}
+}
+
+// hmac(algorithm, key, data) returns the HMAC of data with a key, using one
+// of the hashes "md5", "sha1", "sha256" or "sha512".
+func Z_crypto_hmac(args ...val.ZValue) val.ZValue {
+	if len(args) != 3 {
+		return &val.ZError{Message: "hmac() takes 3 arguments"}
+	}
+	algorithm, zErr := stringArg("hmac", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	newHash, ok := hashes[algorithm]
+	if !ok {
+		return &val.ZError{Message: fmt.Sprintf("hmac() unknown hash %q", algorithm)}
+	}
+	key, zErr := bytesArg("hmac", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+	data, zErr := bytesArg("hmac", args, 2)
+	if zErr != nil {
+		return zErr
+	}
+	mac := hmac.New(newHash, key)
+	mac.Write(data)
+	return val.BYTES(mac.Sum(nil))
+}
+
+// equal(a, b) compares two digests in constant time, so that checking a
+// signature does not reveal how much of it is correct.
+func Z_crypto_equal(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: "equal() takes 2 arguments"}
+	}
+	a, zErr := bytesArg("equal", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	b, zErr := bytesArg("equal", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+	return val.BOOL(hmac.Equal(a, b))
+}
+
+// random_bytes(n) returns n bytes from the secure random generator of the
+// system.
+func Z_crypto_random_bytes(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "random_bytes() takes 1 argument"}
+	}
+	n, zErr := countArg("random_bytes", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return &val.ZError{Message: "random_bytes() " + err.Error()}
+	}
+	return val.BYTES(b)
+}
+
+// uuid() returns a random (version 4) UUID, e.g.,
+// "f47ac10b-58cc-4372-a567-0e02b2c3d479".
+func Z_crypto_uuid(args ...val.ZValue) val.ZValue {
+	if len(args) != 0 {
+		return &val.ZError{Message: "uuid() takes no arguments"}
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return &val.ZError{Message: "uuid() " + err.Error()}
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return val.STRING(fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]))
+}
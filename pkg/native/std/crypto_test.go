@@ -0,0 +1,140 @@
+package std_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// Imports the modules for the inputs of the tests.
+const importCrypto = "crypto = import(\"crypto\")\nencoding = import(\"encoding\")\n"
+
+func TestCryptoHashes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`encoding.hex_encode(crypto.sha256("abc"))`, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{`encoding.hex_encode(crypto.sha256(bytes([97, 98, 99])))`, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{`encoding.hex_encode(crypto.sha1("abc"))`, "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{`encoding.hex_encode(crypto.md5(""))`, "d41d8cd98f00b204e9800998ecf8427e"},
+		{`len(crypto.sha512("abc"))`, "64"},
+		{`crypto.sha256(1)`, "ERROR: sha256() takes bytes or a string as argument 1"},
+		{`crypto.md5()`, "ERROR: md5() takes 1 argument"},
+		{`r = [crypto.equal(crypto.sha1("a"), crypto.sha1("a")), crypto.equal(crypto.sha1("a"), crypto.sha1("b"))]
+		r`, "[true, false]"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, importCrypto+tt.input, tt.expected)
+	}
+}
+
+// The test cases of RFC 4231 for HMAC-SHA-256 and HMAC-SHA-512, except for
+// the truncated output of test case 5 and the long data of test case 7.
+func TestCryptoHMAC(t *testing.T) {
+	tests := []struct {
+		key    string
+		data   string
+		sha256 string
+		sha512 string
+	}{
+		{
+			strings.Repeat("0b", 20),
+			"4869205468657265", // "Hi There"
+			"b0344c61d8db38535ca8afceaf0bf12b881dc200c9833da726e9376c2e32cff7",
+			"87aa7cdea5ef619d4ff0b4241a1d6cb02379f4e2ce4ec2787ad0b30545e17cdedaa833b7d6b8a702038b274eaea3f4e4be9d914eeb61f1702e696c203a126854",
+		},
+		{
+			"4a656665", // "Jefe"
+			"7768617420646f2079612077616e7420666f72206e6f7468696e673f", // "what do ya want for nothing?"
+			"5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+			"164b7a7bfcf819e2e395fbe73b56e0a387bd64222e831fd610270cd7ea2505549758bf75c05a994a6d034f65f8f0e6fdcaeab1a34d4a6b4b636e070a38bce737",
+		},
+		{
+			strings.Repeat("aa", 20),
+			strings.Repeat("dd", 50),
+			"773ea91e36800e46854db8ebd09181a72959098b3ef8c122d9635514ced565fe",
+			"fa73b0089d56a284efb0f0756c890be9b1b5dbdd8ee81a3655f83e33b2279d39bf3e848279a722c806b485a47e67c807b946a337bee8942674278859e13292fb",
+		},
+		{
+			"0102030405060708090a0b0c0d0e0f10111213141516171819",
+			strings.Repeat("cd", 50),
+			"82558a389a443c0ea4cc819899f2083a85f0faa3e578f8077a2e3ff46729665b",
+			"b0ba465637458c6990e5a8c5f61d4af7e576d97ff94b872de76f8050361ee3dba91ca5c11aa25eb4d679275cc5788063a5f19741120c4f2de2adebeb10a298dd",
+		},
+		{
+			strings.Repeat("aa", 131),
+			// "Test Using Larger Than Block-Size Key - Hash Key First"
+			"54657374205573696e67204c6172676572205468616e20426c6f636b2d53697a65204b6579202d2048617368204b6579204669727374",
+			"60e431591ee0b67f0d8a26aacbf5b77f8e0bc6213728c5140546040f0ee37f54",
+			"80b24263c7c1a3ebb71493c1dd7be8b49b46d1f41b4aeec1121b013783f8f3526b56d037e05f2598bd0fd2215d6a1e5295e64f73f63f0aec8b915a985d786598",
+		},
+	}
+
+	for _, tt := range tests {
+		state := newScriptState()
+		state.Env.Set("key", val.STRING(tt.key))
+		state.Env.Set("data", val.STRING(tt.data))
+		input := importCrypto + `key = encoding.hex_decode(key)
+		data = encoding.hex_decode(data)
+		r = [encoding.hex_encode(crypto.hmac("sha256", key, data)), encoding.hex_encode(crypto.hmac("sha512", key, data))]
+		r`
+		evaluated, err := state.Eval(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := "[" + tt.sha256 + ", " + tt.sha512 + "]"
+		if evaluated.Str() != expected {
+			t.Errorf("key %s: got=%s, want=%s", tt.key, evaluated.Str(), expected)
+		}
+	}
+
+	errors := []struct {
+		input    string
+		expected string
+	}{
+		{`crypto.hmac("sha3", "k", "d")`, `ERROR: hmac() unknown hash "sha3"`},
+		{`crypto.hmac("sha256", 1, "d")`, "ERROR: hmac() takes bytes or a string as argument 2"},
+		{`crypto.hmac("sha256", "k")`, "ERROR: hmac() takes 3 arguments"},
+	}
+	for _, tt := range errors {
+		testScript(t, importCrypto+tt.input, tt.expected)
+	}
+}
+
+func TestCryptoRandom(t *testing.T) {
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		evaluated, err := newScriptState().Eval(importCrypto + "crypto.uuid()")
+		if err != nil {
+			t.Fatal(err)
+		}
+		uuid := evaluated.Str()
+		if !uuidPattern.MatchString(uuid) {
+			t.Errorf("uuid() is not a version 4 UUID: %s", uuid)
+		}
+		if seen[uuid] {
+			t.Errorf("uuid() repeated %s", uuid)
+		}
+		seen[uuid] = true
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`len(crypto.random_bytes(16))`, "16"},
+		{`len(crypto.random_bytes(0))`, "0"},
+		{`crypto.random_bytes(16) == crypto.random_bytes(16)`, "false"},
+		{`crypto.random_bytes(-1)`, "ERROR: random_bytes() takes a non-negative integer as argument 1"},
+		{`crypto.uuid(1)`, "ERROR: uuid() takes no arguments"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, importCrypto+tt.input, tt.expected)
+	}
+}
@@ -0,0 +1,294 @@
+package std
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// Reading and writing CSV, and other delimited formats such as TSV with a
+// "\t" delimiter. Rows are lists of strings, or tables keyed by the header
+// when read with the *_tables functions. The streaming functions return
+// iterators, so rows can go through pipelines, e.g.,
+// `csv.rows("data.csv") >- is_valid{} |> csv.write{"valid.csv", _}`.
+var CSVModule = val.MODULE(
+	"csv",
+	&val.Env{
+		SymTable: map[string]val.ZValue{
+			// Reading
+			"read":         &val.ZNativeFunc{Fn: Z_csv_read},
+			"read_tables":  &val.ZNativeFunc{Fn: Z_csv_read_tables},
+			"parse":        &val.ZNativeFunc{Fn: Z_csv_parse},
+			"parse_tables": &val.ZNativeFunc{Fn: Z_csv_parse_tables},
+			// Streaming
+			"rows":       &val.ZNativeFunc{Fn: Z_csv_rows},
+			"table_rows": &val.ZNativeFunc{Fn: Z_csv_table_rows},
+			// Writing
+			"write":     &val.ZNativeFunc{Fn: Z_csv_write},
+			"stringify": &val.ZNativeFunc{Fn: Z_csv_stringify},
+		},
+	},
+)
+
+// Returns the single character delimiter argument at position i, or a comma
+// if there is none.
+func delimiterArg(name string, args []val.ZValue, i int) (rune, *val.ZError) {
+	if len(args) <= i {
+		return ',', nil
+	}
+	if args[i].Type() == val.ZSTRING {
+		if runes := []rune(args[i].(*val.ZString).Value); len(runes) == 1 {
+			return runes[0], nil
+		}
+	}
+	return 0, &val.ZError{Message: name + "() takes a single character delimiter"}
+}
+
+// Returns a CSV reader over the file or text at position 0, with the optional
+// delimiter at position 1. Files are opened, and must be closed by the caller.
+func csvReaderArgs(name string, args []val.ZValue, fromFile bool) (*csv.Reader, io.Closer, *val.ZError) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, nil, &val.ZError{Message: name + "() takes 1 or 2 arguments"}
+	}
+	source, zErr := stringArg(name, args, 0)
+	if zErr != nil {
+		return nil, nil, zErr
+	}
+	delimiter, zErr := delimiterArg(name, args, 1)
+	if zErr != nil {
+		return nil, nil, zErr
+	}
+
+	var r io.Reader = strings.NewReader(source)
+	var closer io.Closer = io.NopCloser(nil)
+	if fromFile {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, nil, fileError(name, err)
+		}
+		r, closer = f, f
+	}
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	return reader, closer, nil
+}
+
+func csvRow(record []string) *val.ZList {
+	row := make([]val.ZValue, len(record))
+	for i, field := range record {
+		row[i] = val.STRING(field)
+	}
+	return &val.ZList{Elements: row}
+}
+
+func csvTable(header, record []string) *val.ZTable {
+	table := val.TABLE()
+	for i, key := range header {
+		table.Set(key, val.STRING(record[i]))
+	}
+	return table
+}
+
+// Returns an error value for a failed read, with the position of malformed
+// input.
+func csvError(name string, err error) *val.ZError {
+	if pErr, ok := err.(*csv.ParseError); ok {
+		return &val.ZError{Message: fmt.Sprintf("%s() %v at line %d, column %d", name, pErr.Err, pErr.Line, pErr.Column)}
+	}
+	return &val.ZError{Message: name + "() " + err.Error()}
+}
+
+// Returns an iterator over the rows of a reader, as lists, or as tables keyed
+// by the first row if tables is true. Rows of tables must have as many fields
+// as the header, while rows of lists may vary in length. The closer is closed at the end of the
+// rows, or at the first error.
+func csvIterator(name string, reader *csv.Reader, closer io.Closer, tables bool) *val.ZIterator {
+	if !tables {
+		reader.FieldsPerRecord = -1
+	}
+	var header []string
+	done := false
+	return val.ITERATOR(func() (val.ZValue, bool) {
+		if done {
+			return nil, false
+		}
+		record, err := reader.Read()
+		if tables && header == nil && err == nil {
+			header = record
+			record, err = reader.Read()
+		}
+		if err != nil {
+			done = true
+			closer.Close()
+			if err == io.EOF {
+				return nil, false
+			}
+			return csvError(name, err), true
+		}
+		if tables {
+			return csvTable(header, record), true
+		}
+		return csvRow(record), true
+	})
+}
+
+// Shared implementation of the reading functions, which collect the rows.
+func csvCollect(name string, args []val.ZValue, fromFile, tables bool) val.ZValue {
+	reader, closer, zErr := csvReaderArgs(name, args, fromFile)
+	if zErr != nil {
+		return zErr
+	}
+	return val.Collect(csvIterator(name, reader, closer, tables))
+}
+
+// read(path[, delimiter]) returns the rows of a file, as lists of strings.
+func Z_csv_read(args ...val.ZValue) val.ZValue {
+	return csvCollect("read", args, true, false)
+}
+
+// read_tables(path[, delimiter]) returns the rows of a file after the header,
+// as tables keyed by the header.
+func Z_csv_read_tables(args ...val.ZValue) val.ZValue {
+	return csvCollect("read_tables", args, true, true)
+}
+
+// parse(text[, delimiter]) returns the rows of a string, as lists of strings.
+func Z_csv_parse(args ...val.ZValue) val.ZValue {
+	return csvCollect("parse", args, false, false)
+}
+
+// parse_tables(text[, delimiter]) returns the rows of a string after the
+// header, as tables keyed by the header.
+func Z_csv_parse_tables(args ...val.ZValue) val.ZValue {
+	return csvCollect("parse_tables", args, false, true)
+}
+
+// rows(path[, delimiter]) returns an iterator over the rows of a file, which
+// are read one at a time.
+func Z_csv_rows(args ...val.ZValue) val.ZValue {
+	reader, closer, zErr := csvReaderArgs("rows", args, true)
+	if zErr != nil {
+		return zErr
+	}
+	return csvIterator("rows", reader, closer, false)
+}
+
+// table_rows(path[, delimiter]) returns an iterator over the rows of a file
+// after the header, as tables keyed by the header.
+func Z_csv_table_rows(args ...val.ZValue) val.ZValue {
+	reader, closer, zErr := csvReaderArgs("table_rows", args, true)
+	if zErr != nil {
+		return zErr
+	}
+	return csvIterator("table_rows", reader, closer, true)
+}
+
+// Returns the text of a field. Floats are written in full, as the shortest
+// text that reads back as the same number, and null as an empty field.
+func csvText(field val.ZValue) string {
+	switch field := field.(type) {
+	case *val.ZFloat:
+		return strconv.FormatFloat(field.Value, 'g', -1, 64)
+	case *val.ZNull:
+		return ""
+	}
+	return field.Str()
+}
+
+// Writes rows, which are lists of values or tables. For tables, the keys of
+// the first table are written as the header, and select the values of all
+// rows. Fields are quoted where needed.
+func writeCSV(name string, w io.Writer, rows val.ZValue, delimiter rune) *val.ZError {
+	it, zErr := iterArg(name, rows)
+	if zErr != nil {
+		return zErr
+	}
+	writer := csv.NewWriter(w)
+	writer.Comma = delimiter
+
+	var header []string
+	for i := 1; ; i++ {
+		row, ok := it.Next()
+		if !ok {
+			break
+		}
+
+		var record []string
+		switch row := row.(type) {
+		case *val.ZError:
+			return row
+		case *val.ZList:
+			for _, field := range row.Elements {
+				record = append(record, csvText(field))
+			}
+		case *val.ZTable:
+			if header == nil {
+				header = row.Keys()
+				writer.Write(header)
+			}
+			for _, key := range header {
+				field, ok := row.Get(key)
+				if !ok {
+					return &val.ZError{Message: fmt.Sprintf("%s() row %d has no key %q", name, i, key)}
+				}
+				record = append(record, csvText(field))
+			}
+		default:
+			return &val.ZError{Message: fmt.Sprintf("%s() takes rows as lists or tables, got %s", name, row.Type())}
+		}
+		writer.Write(record)
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return &val.ZError{Message: name + "() " + err.Error()}
+	}
+	return nil
+}
+
+// write(path, rows[, delimiter]) writes rows to a file.
+func Z_csv_write(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 && len(args) != 3 {
+		return &val.ZError{Message: "write() takes 2 or 3 arguments"}
+	}
+	path, zErr := stringArg("write", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	delimiter, zErr := delimiterArg("write", args, 2)
+	if zErr != nil {
+		return zErr
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fileError("write", err)
+	}
+	defer f.Close()
+	if zErr := writeCSV("write", f, args[1], delimiter); zErr != nil {
+		return zErr
+	}
+	return val.NULL()
+}
+
+// stringify(rows[, delimiter]) returns rows as CSV text.
+func Z_csv_stringify(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 && len(args) != 2 {
+		return &val.ZError{Message: "stringify() takes 1 or 2 arguments"}
+	}
+	delimiter, zErr := delimiterArg("stringify", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+	var buf bytes.Buffer
+	if zErr := writeCSV("stringify", &buf, args[0], delimiter); zErr != nil {
+		return zErr
+	}
+	return val.STRING(buf.String())
+}
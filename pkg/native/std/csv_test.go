@@ -0,0 +1,149 @@
+package std_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+func TestCSVParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`csv = import("csv")
+		csv.parse("a,b\n1,2\n")`, "[[a, b], [1, 2]]"},
+		{`csv = import("csv")
+		csv.parse("")`, "[]"},
+		{`csv = import("csv")
+		csv.parse("a,b\n1\n")`, "[[a, b], [1]]"},
+		{`csv = import("csv")
+		rows = csv.parse("\"x,y\",\"say \"\"hi\"\"\"\n")
+		rows[0][1]`, `say "hi"`},
+		{`csv = import("csv")
+		rows = csv.parse("\"x,y\",z\n")
+		len(rows[0])`, "2"},
+		{`csv = import("csv")
+		csv.parse("a;b\n1;2\n", ";")`, "[[a, b], [1, 2]]"},
+		{`csv = import("csv")
+		csv.parse("a\tb\n", "\t")`, "[[a, b]]"},
+		{`csv = import("csv")
+		csv.parse("a,b", ";;")`, "ERROR: parse() takes a single character delimiter"},
+		{`csv = import("csv")
+		csv.parse(1)`, "ERROR: parse() takes a string as argument 1"},
+		{`csv = import("csv")
+		csv.parse("a,b\n1,x\"y\n")`, `ERROR: parse() bare " in non-quoted-field at line 2, column 4`},
+		{`csv = import("csv")
+		csv.parse("a\n\"b\n")`, `ERROR: parse() extraneous or missing " in quoted-field at line 2, column 4`},
+	}
+
+	for _, tt := range tests {
+		testScript(t, tt.input, tt.expected)
+	}
+}
+
+func TestCSVParseTables(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`csv = import("csv")
+		rows = csv.parse_tables("id,name\n1,ann\n2,bob\n")
+		rows -> @(r) { r.name }{}`, "[ann, bob]"},
+		{`csv = import("csv")
+		rows = csv.parse_tables("id;name\n1;ann\n", ";")
+		rows[0].id`, "1"},
+		{`csv = import("csv")
+		csv.parse_tables("id,name\n")`, "[]"},
+		{`csv = import("csv")
+		csv.parse_tables("id,name\n1,ann\n2\n")`, "ERROR: parse_tables() wrong number of fields at line 3, column 1"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, tt.input, tt.expected)
+	}
+}
+
+func TestCSVStringify(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`csv = import("csv")
+		csv.stringify([[1, 2], [3, 4]])`, "1,2\n3,4\n"},
+		{`csv = import("csv")
+		csv.stringify([[0.1, 2.5, 1.0 / 3, 1.0]])`, "0.1,2.5,0.3333333333333333,1\n"},
+		{`csv = import("csv")
+		nothing = import("re").find("x", "")
+		csv.stringify([["a", nothing, true]])`, "a,,true\n"},
+		{`csv = import("csv")
+		csv.stringify([["a,b", "say \"hi\"", "two\nlines"]])`, "\"a,b\",\"say \"\"hi\"\"\",\"two\nlines\"\n"},
+		{`csv = import("csv")
+		csv.stringify([["a,b", "c"]], ";")`, "a,b;c\n"},
+		{`csv = import("csv")
+		csv.stringify([["a", "b"]], "\t")`, "a\tb\n"},
+		{`csv = import("csv")
+		rows = [table([["id", 1], ["x", 0.5]]), table([["x", 0.25], ["id", 2]])]
+		csv.stringify(rows)`, "id,x\n1,0.5\n2,0.25\n"},
+		{`csv = import("csv")
+		rows = [table([["id", 1], ["x", 0.5]]), table([["id", 2]])]
+		csv.stringify(rows)`, `ERROR: stringify() row 2 has no key "x"`},
+		{`csv = import("csv")
+		csv.stringify(lazy(range_list(0, 3)) -> @(i) { [i, i * i] }{})`, "0,0\n1,1\n2,4\n"},
+		{`csv = import("csv")
+		csv.stringify([])`, ""},
+		{`csv = import("csv")
+		csv.stringify([1])`, "ERROR: stringify() takes rows as lists or tables, got Int"},
+		{`csv = import("csv")
+		csv.stringify([["a"]], "")`, "ERROR: stringify() takes a single character delimiter"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, tt.input, tt.expected)
+	}
+}
+
+func TestCSVFiles(t *testing.T) {
+	dir := t.TempDir()
+	state := newScriptState()
+	state.Env.Set("path", val.STRING(filepath.Join(dir, "data.csv")))
+	state.Env.Set("missing", val.STRING(filepath.Join(dir, "missing.csv")))
+	if _, err := state.Eval(`csv = import("csv")`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`csv.write(path, [table([["name", "a;b"], ["score", 0.1]]), table([["name", "c"], ["score", 0.0000001]])], ";")`, ""},
+		{`csv.read(path, ";")`, "[[name, score], [a;b, 0.1], [c, 1e-07]]"},
+		{`rows = csv.read_tables(path, ";")
+		rows -> @(r) { r.score }{}`, "[0.1, 1e-07]"},
+		{`csv.rows(path, ";") -> len{} |> collect{}`, "[2, 2, 2]"},
+		{`csv.table_rows(path, ";") -> @(r) { r.name }{} |> collect{}`, "[a;b, c]"},
+		{`csv.read(path)`, `ERROR: read() extraneous or missing " in quoted-field at line 2, column 5`},
+		{`is_error(csv.read(missing))`, "true"},
+		{`is_error(csv.rows(missing))`, "true"},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := state.Eval(tt.input)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.input, err)
+		}
+		if evaluated.Str() != tt.expected {
+			t.Errorf("%s: got=%s, want=%s", tt.input, evaluated.Str(), tt.expected)
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "data.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "name;score\n\"a;b\";0.1\nc;1e-07\n" {
+		t.Errorf("unexpected file content %q", content)
+	}
+}
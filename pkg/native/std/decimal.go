@@ -0,0 +1,203 @@
+package std
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// Exact decimal arithmetic. Decimals are created with the `12.50d` literal or
+// with `decimal()`, and this module controls how they are rounded and
+// formatted.
+var DecimalModule = val.MODULE(
+	"decimal",
+	&val.Env{
+		SymTable: map[string]val.ZValue{
+			"new": &val.ZNativeFunc{Fn: Z_decimal},
+			// Rounding and formatting
+			"round":  &val.ZNativeFunc{Fn: Z_decimal_round},
+			"format": &val.ZNativeFunc{Fn: Z_decimal_format},
+			"scale":  &val.ZNativeFunc{Fn: Z_decimal_scale},
+			// Context
+			"rounding":           &val.ZNativeFunc{Fn: Z_decimal_rounding},
+			"set_rounding":       &val.ZNativeFunc{Fn: Z_decimal_set_rounding},
+			"division_scale":     &val.ZNativeFunc{Fn: Z_decimal_division_scale},
+			"set_division_scale": &val.ZNativeFunc{Fn: Z_decimal_set_division_scale},
+		},
+	},
+)
+
+// decimal(x) converts an Int, a Float, a String or a Decimal to a decimal.
+// Floats are converted from their shortest representation, so decimal(0.1)
+// is exactly 0.1.
+func Z_decimal(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "decimal() takes 1 argument"}
+	}
+
+	var text string
+	switch arg := args[0].(type) {
+	case *val.ZDecimal:
+		return arg
+	case *val.ZInt, *val.ZBigInt:
+		d, _ := val.ToDecimal(arg)
+		return d
+	case *val.ZFloat:
+		text = strconv.FormatFloat(arg.Value, 'f', -1, 64)
+	case *val.ZString:
+		text = arg.Value
+	default:
+		return &val.ZError{Message: "decimal() takes a number or a string, got " + string(args[0].Type())}
+	}
+
+	d, err := val.ParseDecimal(text)
+	if err != nil {
+		return &val.ZError{Message: err.Error()}
+	}
+	return d
+}
+
+// Returns the scale argument at position i, a number of fractional digits
+// from 0 to val.MaxDecimalScale.
+func scaleArg(name string, args []val.ZValue, i int) (int, *val.ZError) {
+	scale, zErr := countArg(name, args, i)
+	if zErr != nil {
+		return 0, zErr
+	}
+	if scale > val.MaxDecimalScale {
+		return 0, &val.ZError{Message: fmt.Sprintf("%s() takes at most %d fractional digits, got %d", name, val.MaxDecimalScale, scale)}
+	}
+	return scale, nil
+}
+
+// Returns the decimal argument at position 0 and the rounding arguments
+// `places` and the optional `mode` that follow it.
+func roundingArgs(name string, args []val.ZValue) (*val.ZDecimal, int, val.RoundingMode, *val.ZError) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, 0, "", &val.ZError{Message: name + "() takes 2 or 3 arguments"}
+	}
+	d, ok := val.ToDecimal(args[0])
+	if !ok {
+		return nil, 0, "", &val.ZError{Message: name + "() takes a decimal as first argument, got " + string(args[0].Type())}
+	}
+	places, zErr := scaleArg(name, args, 1)
+	if zErr != nil {
+		return nil, 0, "", zErr
+	}
+
+	mode := val.DecimalCtx.Rounding()
+	if len(args) == 3 {
+		if mode, zErr = roundingModeArg(name, args[2]); zErr != nil {
+			return nil, 0, "", zErr
+		}
+	}
+	return d, places, mode, nil
+}
+
+func roundingModeArg(name string, arg val.ZValue) (val.RoundingMode, *val.ZError) {
+	if arg.Type() == val.ZSTRING {
+		if mode, ok := val.RoundingModes[arg.(*val.ZString).Value]; ok {
+			return mode, nil
+		}
+	}
+	modes := make([]string, 0, len(val.RoundingModes))
+	for _, mode := range []val.RoundingMode{
+		val.RoundHalfEven, val.RoundHalfUp, val.RoundHalfDown,
+		val.RoundUp, val.RoundDown, val.RoundCeiling, val.RoundFloor,
+	} {
+		modes = append(modes, string(mode))
+	}
+	return "", &val.ZError{Message: fmt.Sprintf("%s() takes a rounding mode, one of %s", name, strings.Join(modes, ", "))}
+}
+
+// round(d, places[, mode]) rounds to the given number of fractional digits.
+func Z_decimal_round(args ...val.ZValue) val.ZValue {
+	d, places, mode, zErr := roundingArgs("round", args)
+	if zErr != nil {
+		return zErr
+	}
+	return d.Round(places, mode)
+}
+
+// format(d, places[, mode]) rounds to the given number of fractional digits
+// and returns the digits with thousands separators, e.g., "1,234.50".
+func Z_decimal_format(args ...val.ZValue) val.ZValue {
+	d, places, mode, zErr := roundingArgs("format", args)
+	if zErr != nil {
+		return zErr
+	}
+
+	text := d.Round(places, mode).Str()
+	sign := ""
+	if strings.HasPrefix(text, "-") {
+		sign, text = "-", text[1:]
+	}
+	intPart, fracPart := text, ""
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		intPart, fracPart = text[:i], text[i:]
+	}
+
+	var sb strings.Builder
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(c)
+	}
+	return val.STRING(sign + sb.String() + fracPart)
+}
+
+// scale(d) returns the number of fractional digits.
+func Z_decimal_scale(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 || args[0].Type() != val.ZDECIMAL {
+		return &val.ZError{Message: "scale() takes a decimal"}
+	}
+	return val.INT(int64(args[0].(*val.ZDecimal).Scale()))
+}
+
+// rounding() returns the default rounding mode.
+func Z_decimal_rounding(args ...val.ZValue) val.ZValue {
+	if len(args) != 0 {
+		return &val.ZError{Message: "rounding() takes no arguments"}
+	}
+	return val.STRING(string(val.DecimalCtx.Rounding()))
+}
+
+// set_rounding(mode) sets the default rounding mode, used by division and
+// by round() and format() when no mode is given.
+func Z_decimal_set_rounding(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "set_rounding() takes 1 argument"}
+	}
+	mode, zErr := roundingModeArg("set_rounding", args[0])
+	if zErr != nil {
+		return zErr
+	}
+	val.DecimalCtx.SetRounding(mode)
+	return &val.ZNull{}
+}
+
+// division_scale() returns the maximum number of fractional digits of a
+// division result.
+func Z_decimal_division_scale(args ...val.ZValue) val.ZValue {
+	if len(args) != 0 {
+		return &val.ZError{Message: "division_scale() takes no arguments"}
+	}
+	return val.INT(int64(val.DecimalCtx.DivisionScale()))
+}
+
+// set_division_scale(n) sets the maximum number of fractional digits of a
+// division result.
+func Z_decimal_set_division_scale(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "set_division_scale() takes 1 argument"}
+	}
+	scale, zErr := scaleArg("set_division_scale", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	val.DecimalCtx.SetDivisionScale(scale)
+	return &val.ZNull{}
+}
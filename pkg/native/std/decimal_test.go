@@ -0,0 +1,90 @@
+package std_test
+
+import "testing"
+
+// Imports the module for the inputs of the tests.
+const importDecimal = "decimal = import(\"decimal\")\n"
+
+func TestDecimalNew(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`decimal.new("12.50")`, "12.50"},
+		{`decimal.new(" -0.001 ")`, "-0.001"},
+		{`decimal.new("1.5e3")`, "1500"},
+		{`decimal.new("1.5E+3")`, "1500"},
+		{`decimal.new("15e-3")`, "0.015"},
+		{`decimal.new(0.1)`, "0.1"},
+		{`decimal.new(2 ** 70)`, "1180591620717411303424"},
+		{`decimal.scale(decimal.new("1e-100000"))`, "100000"},
+		{`decimal.new("1e5x")`, "ERROR: invalid decimal: 1e5x"},
+		{`decimal.new("1e")`, "ERROR: invalid decimal: 1e"},
+		{`decimal.new("1e99999999999999999999")`, "ERROR: invalid decimal: 1e99999999999999999999"},
+		{`decimal.new("1e2000000000")`, "ERROR: decimal exponent is too large: 1e2000000000"},
+		{`decimal.new("1e-2000000000")`, "ERROR: decimal exponent is too large: 1e-2000000000"},
+		{`decimal.new("1.2.3")`, "ERROR: invalid decimal: 1.2.3"},
+		{`decimal.new(true)`, "ERROR: decimal() takes a number or a string, got Bool"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, importDecimal+tt.input, tt.expected)
+	}
+}
+
+func TestDecimalRounding(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`decimal.round(2.675d, 2)`, "2.68"},
+		{`decimal.round(2.665d, 2)`, "2.66"},
+		{`decimal.round(2.675d, 2, "down")`, "2.67"},
+		{`decimal.round(1.5d, 3)`, "1.500"},
+		{`decimal.round(3, 1)`, "3.0"},
+		{`decimal.format(1234567.891d, 2)`, "1,234,567.89"},
+		{`decimal.format(-1234.5d, 0, "half_up")`, "-1,235"},
+		{`decimal.scale(decimal.round(1.5d, 100000))`, "100000"},
+		{`decimal.round(1.5d, 2000000000)`, "ERROR: round() takes at most 100000 fractional digits, got 2000000000"},
+		{`decimal.format(1.5d, 100001)`, "ERROR: format() takes at most 100000 fractional digits, got 100001"},
+		{`decimal.round(1.5d, -1)`, "ERROR: round() takes a non-negative integer as argument 2"},
+		{`decimal.round(1.5d, 1, "nearest")`, "ERROR: round() takes a rounding mode, one of half_even, half_up, half_down, up, down, ceiling, floor"},
+		{`decimal.round(1.5, 1)`, "ERROR: round() takes a decimal as first argument, got Float"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, importDecimal+tt.input, tt.expected)
+	}
+}
+
+// The division scale is shared by the whole process, so it is restored after
+// the test.
+func TestDecimalDivisionScale(t *testing.T) {
+	state := newScriptState()
+	if _, err := state.Eval(importDecimal + "saved = decimal.division_scale()"); err != nil {
+		t.Fatal(err)
+	}
+	defer state.Eval("decimal.set_division_scale(saved)")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`decimal.set_division_scale(4)
+		1d / 3`, "0.3333"},
+		{`decimal.set_division_scale(100000)
+		decimal.scale(1d / 3)`, "100000"},
+		{`decimal.set_division_scale(2000000000)`, "ERROR: set_division_scale() takes at most 100000 fractional digits, got 2000000000"},
+		{`decimal.division_scale()`, "100000"},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := state.Eval(tt.input)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.input, err)
+		}
+		if evaluated.Str() != tt.expected {
+			t.Errorf("%s: got=%s, want=%s", tt.input, evaluated.Str(), tt.expected)
+		}
+	}
+}
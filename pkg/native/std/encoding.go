@@ -0,0 +1,155 @@
+package std
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// Text encodings of binary data. The encoding functions take bytes, or a
+// string as its UTF-8 encoding, and the decoding functions return bytes.
+var EncodingModule = val.MODULE(
+	"encoding",
+	&val.Env{
+		SymTable: map[string]val.ZValue{
+			"base64_encode": &val.ZNativeFunc{Fn: Z_encoding_base64_encode},
+			"base64_decode": &val.ZNativeFunc{Fn: Z_encoding_base64_decode},
+			"hex_encode":    &val.ZNativeFunc{Fn: Z_encoding_hex_encode},
+			"hex_decode":    &val.ZNativeFunc{Fn: Z_encoding_hex_decode},
+			"url_encode":    &val.ZNativeFunc{Fn: Z_encoding_url_encode},
+			"url_decode":    &val.ZNativeFunc{Fn: Z_encoding_url_decode},
+		},
+	},
+)
+
+// Returns the binary data argument at position i, either bytes, a string, or
+// a list of integers from 0 to 255.
+func bytesArg(name string, args []val.ZValue, i int) ([]byte, *val.ZError) {
+	if len(args) > i {
+		if b, ok := val.ToBytes(args[i]); ok {
+			return b, nil
+		}
+	}
+	return nil, &val.ZError{Message: fmt.Sprintf("%s() takes bytes or a string as argument %d", name, i+1)}
+}
+
+// Returns the base64 encoding, the standard one or the URL-safe one if the
+// argument at position i is true.
+func base64Encoding(name string, args []val.ZValue, i int) (*base64.Encoding, *val.ZError) {
+	if len(args) <= i {
+		return base64.StdEncoding, nil
+	}
+	if args[i].Type() != val.ZBOOL {
+		return nil, &val.ZError{Message: fmt.Sprintf("%s() takes a boolean as argument %d", name, i+1)}
+	}
+	if args[i].(*val.ZBool).Value {
+		return base64.URLEncoding, nil
+	}
+	return base64.StdEncoding, nil
+}
+
+// base64_encode(data[, url_safe]) returns the base64 encoding of data, with
+// the URL-safe alphabet if url_safe is true.
+func Z_encoding_base64_encode(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 && len(args) != 2 {
+		return &val.ZError{Message: "base64_encode() takes 1 or 2 arguments"}
+	}
+	data, zErr := bytesArg("base64_encode", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	enc, zErr := base64Encoding("base64_encode", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+	return val.STRING(enc.EncodeToString(data))
+}
+
+// base64_decode(text[, url_safe]) decodes base64 text.
+func Z_encoding_base64_decode(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 && len(args) != 2 {
+		return &val.ZError{Message: "base64_decode() takes 1 or 2 arguments"}
+	}
+	text, zErr := stringArg("base64_decode", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	enc, zErr := base64Encoding("base64_decode", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+	data, err := enc.DecodeString(text)
+	if err != nil {
+		return &val.ZError{Message: "base64_decode() " + err.Error()}
+	}
+	return val.BYTES(data)
+}
+
+// hex_encode(data) returns the lowercase hexadecimal encoding of data.
+func Z_encoding_hex_encode(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "hex_encode() takes 1 argument"}
+	}
+	data, zErr := bytesArg("hex_encode", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	return val.STRING(hex.EncodeToString(data))
+}
+
+// hex_decode(text) decodes hexadecimal text, in lower or upper case.
+func Z_encoding_hex_decode(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "hex_decode() takes 1 argument"}
+	}
+	text, zErr := stringArg("hex_decode", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	data, err := hex.DecodeString(text)
+	if err != nil {
+		return &val.ZError{Message: "hex_decode() " + err.Error()}
+	}
+	return val.BYTES(data)
+}
+
+// url_encode(text | table) escapes text for use in a URL query, or encodes a
+// table as a query string, e.g., "q=a+b&page=2". Table values are converted
+// to strings.
+func Z_encoding_url_encode(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "url_encode() takes 1 argument"}
+	}
+	if table, ok := args[0].(*val.ZTable); ok {
+		values := url.Values{}
+		for _, key := range table.Keys() {
+			value, _ := table.Get(key)
+			values.Set(key, value.Str())
+		}
+		return val.STRING(values.Encode())
+	}
+	text, zErr := stringArg("url_encode", args, 0)
+	if zErr != nil {
+		return &val.ZError{Message: "url_encode() takes a string or a table"}
+	}
+	return val.STRING(url.QueryEscape(text))
+}
+
+// url_decode(text) unescapes text from a URL query.
+func Z_encoding_url_decode(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "url_decode() takes 1 argument"}
+	}
+	text, zErr := stringArg("url_decode", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	decoded, err := url.QueryUnescape(text)
+	if err != nil {
+		return &val.ZError{Message: "url_decode() " + err.Error()}
+	}
+	return val.STRING(decoded)
+}
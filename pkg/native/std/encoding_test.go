@@ -0,0 +1,71 @@
+package std_test
+
+import "testing"
+
+// Imports the module for the inputs of the tests.
+const importEncoding = "encoding = import(\"encoding\")\n"
+
+func TestEncodingBase64(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`encoding.base64_encode("")`, ""},
+		{`encoding.base64_encode("f")`, "Zg=="},
+		{`encoding.base64_encode("foobar")`, "Zm9vYmFy"},
+		{`encoding.base64_encode(bytes([251, 255, 254]))`, "+//+"},
+		{`encoding.base64_encode(bytes([251, 255, 254]), true)`, "-__-"},
+		{`decode(encoding.base64_decode("Zm9vYmE="))`, "fooba"},
+		{`encoding.base64_decode("-__-", true) == bytes([251, 255, 254])`, "true"},
+		{`data = bytes(range_list(0, 256))
+		encoding.base64_decode(encoding.base64_encode(data)) == data`, "true"},
+		{`data = bytes(range_list(0, 256))
+		encoding.base64_decode(encoding.base64_encode(data, true), true) == data`, "true"},
+		{`encoding.base64_decode("Zm9v!")`, "ERROR: base64_decode() illegal base64 data at input byte 4"},
+		{`encoding.base64_decode("-__-")`, "ERROR: base64_decode() illegal base64 data at input byte 0"},
+		{`encoding.base64_encode("a", 1)`, "ERROR: base64_encode() takes a boolean as argument 2"},
+		{`encoding.base64_encode(1)`, "ERROR: base64_encode() takes bytes or a string as argument 1"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, importEncoding+tt.input, tt.expected)
+	}
+}
+
+func TestEncodingHex(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`encoding.hex_encode("")`, ""},
+		{`encoding.hex_encode("Hi")`, "4869"},
+		{`encoding.hex_encode(bytes([0, 15, 255]))`, "000fff"},
+		{`encoding.hex_decode("000FfF") == bytes([0, 15, 255])`, "true"},
+		{`data = bytes(range_list(0, 256))
+		encoding.hex_decode(encoding.hex_encode(data)) == data`, "true"},
+		{`decode(encoding.hex_decode(encoding.hex_encode("héllo")))`, "héllo"},
+		{`encoding.hex_decode("abc")`, "ERROR: hex_decode() encoding/hex: odd length hex string"},
+		{`encoding.hex_decode("zz")`, "ERROR: hex_decode() encoding/hex: invalid byte: U+007A 'z'"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, importEncoding+tt.input, tt.expected)
+	}
+}
+
+func TestEncodingURL(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`encoding.url_encode("a b&c=d/é")`, "a+b%26c%3Dd%2F%C3%A9"},
+		{`encoding.url_decode(encoding.url_encode("a b&c=d/é"))`, "a b&c=d/é"},
+		{`encoding.url_encode(table([["q", "a b"], ["page", 2]]))`, "page=2&q=a+b"},
+		{`encoding.url_decode("%zz")`, `ERROR: url_decode() invalid URL escape "%zz"`},
+		{`encoding.url_encode(1)`, "ERROR: url_encode() takes a string or a table"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, importEncoding+tt.input, tt.expected)
+	}
+}
@@ -0,0 +1,220 @@
+package std
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// Files and directories, and file paths in the `fs.path` submodule. Failures
+// are returned as errors that can be checked with is_error().
+var FSModule = val.MODULE(
+	"fs",
+	&val.Env{
+		SymTable: map[string]val.ZValue{
+			// Queries
+			"exists":  &val.ZNativeFunc{Fn: Z_fs_exists},
+			"stat":    &val.ZNativeFunc{Fn: Z_fs_stat},
+			"listdir": &val.ZNativeFunc{Fn: Z_fs_listdir},
+			"glob":    &val.ZNativeFunc{Fn: Z_fs_glob},
+			// Changes
+			"mkdir":  &val.ZNativeFunc{Fn: Z_fs_mkdir},
+			"remove": &val.ZNativeFunc{Fn: Z_fs_remove},
+			"rename": &val.ZNativeFunc{Fn: Z_fs_rename},
+			// Submodules
+			"path": PathModule,
+		},
+	},
+)
+
+var PathModule = val.MODULE(
+	"path",
+	&val.Env{
+		SymTable: map[string]val.ZValue{
+			"join":     &val.ZNativeFunc{Fn: Z_path_join},
+			"basename": pathFunc("basename", filepath.Base),
+			"dirname":  pathFunc("dirname", filepath.Dir),
+			"ext":      pathFunc("ext", filepath.Ext),
+			"abs":      &val.ZNativeFunc{Fn: Z_path_abs},
+		},
+	},
+)
+
+// Returns the path argument of a function that takes only a path.
+func pathArg(name string, args []val.ZValue) (string, *val.ZError) {
+	if len(args) != 1 {
+		return "", &val.ZError{Message: name + "() takes 1 argument"}
+	}
+	return stringArg(name, args, 0)
+}
+
+// exists(path) returns whether a file or directory exists.
+func Z_fs_exists(args ...val.ZValue) val.ZValue {
+	path, zErr := pathArg("exists", args)
+	if zErr != nil {
+		return zErr
+	}
+	_, err := os.Stat(path)
+	return val.BOOL(err == nil)
+}
+
+// stat(path) returns an object with the name, size, is_dir, mode (e.g.,
+// "-rw-r--r--") and modified (Unix time in seconds) of a file.
+func Z_fs_stat(args ...val.ZValue) val.ZValue {
+	path, zErr := pathArg("stat", args)
+	if zErr != nil {
+		return zErr
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileError("stat", err)
+	}
+	stat := val.OBJECT("Stat", nil)
+	stat.DotAssign("name", val.STRING(info.Name()))
+	stat.DotAssign("size", val.INT(info.Size()))
+	stat.DotAssign("is_dir", val.BOOL(info.IsDir()))
+	stat.DotAssign("mode", val.STRING(info.Mode().String()))
+	stat.DotAssign("modified", val.INT(info.ModTime().Unix()))
+	return stat
+}
+
+// listdir(path) returns the sorted names of the entries of a directory.
+func Z_fs_listdir(args ...val.ZValue) val.ZValue {
+	path, zErr := pathArg("listdir", args)
+	if zErr != nil {
+		return zErr
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return fileError("listdir", err)
+	}
+	names := make([]val.ZValue, len(entries))
+	for i, entry := range entries {
+		names[i] = val.STRING(entry.Name())
+	}
+	return &val.ZList{Elements: names}
+}
+
+// glob(pattern) returns the sorted paths matching a pattern such as "*.csv".
+func Z_fs_glob(args ...val.ZValue) val.ZValue {
+	pattern, zErr := pathArg("glob", args)
+	if zErr != nil {
+		return zErr
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return fileError("glob", err)
+	}
+	sort.Strings(matches)
+	paths := make([]val.ZValue, len(matches))
+	for i, match := range matches {
+		paths[i] = val.STRING(match)
+	}
+	return &val.ZList{Elements: paths}
+}
+
+// mkdir(path) creates a directory along with any missing parents. It is not an
+// error if the directory exists.
+func Z_fs_mkdir(args ...val.ZValue) val.ZValue {
+	path, zErr := pathArg("mkdir", args)
+	if zErr != nil {
+		return zErr
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return fileError("mkdir", err)
+	}
+	return &val.ZNull{}
+}
+
+// remove(path) removes a file or an empty directory, and remove(path, true) a
+// directory with all its contents.
+func Z_fs_remove(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 && len(args) != 2 {
+		return &val.ZError{Message: "remove() takes 1 or 2 arguments"}
+	}
+	path, zErr := stringArg("remove", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	recursive := false
+	if len(args) == 2 {
+		if args[1].Type() != val.ZBOOL {
+			return &val.ZError{Message: "remove() takes a boolean recursive flag"}
+		}
+		recursive = args[1].(*val.ZBool).Value
+	}
+
+	var err error
+	if recursive {
+		// RemoveAll succeeds for missing paths, which remove() reports
+		if _, err = os.Lstat(path); err == nil {
+			err = os.RemoveAll(path)
+		}
+	} else {
+		err = os.Remove(path)
+	}
+	if err != nil {
+		return fileError("remove", err)
+	}
+	return &val.ZNull{}
+}
+
+// rename(old, new) renames or moves a file or directory.
+func Z_fs_rename(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: "rename() takes 2 arguments"}
+	}
+	oldPath, zErr := stringArg("rename", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	newPath, zErr := stringArg("rename", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return fileError("rename", err)
+	}
+	return &val.ZNull{}
+}
+
+// Creates a function that takes a path and returns a part of it.
+func pathFunc(name string, fn func(string) string) val.ZValue {
+	return &val.ZNativeFunc{
+		Fn: func(args ...val.ZValue) val.ZValue {
+			path, zErr := pathArg(name, args)
+			if zErr != nil {
+				return zErr
+			}
+			return val.STRING(fn(path))
+		},
+	}
+}
+
+// join(a, b, ...) joins path elements with the separator of the system.
+func Z_path_join(args ...val.ZValue) val.ZValue {
+	elements := make([]string, len(args))
+	for i := range args {
+		element, zErr := stringArg("join", args, i)
+		if zErr != nil {
+			return zErr
+		}
+		elements[i] = element
+	}
+	return val.STRING(filepath.Join(elements...))
+}
+
+// abs(path) returns the absolute form of a path.
+func Z_path_abs(args ...val.ZValue) val.ZValue {
+	path, zErr := pathArg("abs", args)
+	if zErr != nil {
+		return zErr
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fileError("abs", err)
+	}
+	return val.STRING(abs)
+}
@@ -0,0 +1,76 @@
+package std_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFSChanges(t *testing.T) {
+	dir := t.TempDir()
+	testFileScripts(t, dir, []struct {
+		input    string
+		expected string
+	}{
+		{`sub = fs.path.join(dir, "a", "b")
+		r = [fs.exists(sub), fs.mkdir(sub), fs.mkdir(sub), fs.exists(sub)]
+		r`, "[false, , , true]"},
+		{`io.write_file(fs.path.join(sub, "x.csv"), "1,2\n")
+		io.write_file(fs.path.join(sub, "y.csv"), "")
+		io.write_file(fs.path.join(sub, "z.txt"), "")
+		fs.listdir(sub)`, "[x.csv, y.csv, z.txt]"},
+		{`fs.glob(fs.path.join(sub, "*.csv")) -> fs.path.basename{}`, "[x.csv, y.csv]"},
+		{`fs.glob(fs.path.join(sub, "*.json"))`, "[]"},
+		{`s = fs.stat(fs.path.join(sub, "x.csv"))
+		r = [s.name, s.size, s.is_dir, s.modified > 0]
+		r`, "[x.csv, 4, false, true]"},
+		{`fs.stat(sub).is_dir`, "true"},
+		{`fs.rename(fs.path.join(sub, "z.txt"), fs.path.join(dir, "z.txt"))
+		r = [fs.listdir(sub), fs.exists(fs.path.join(dir, "z.txt"))]
+		r`, "[[x.csv, y.csv], true]"},
+		{`is_error(fs.remove(fs.path.join(dir, "a")))`, "true"},
+		{`fs.remove(fs.path.join(sub, "y.csv"))
+		fs.listdir(sub)`, "[x.csv]"},
+		{`fs.remove(fs.path.join(dir, "a"), true)
+		fs.listdir(dir)`, "[z.txt]"},
+		{`fs.remove("no/such/dir", true)`, "ERROR: remove() no/such/dir: no such file or directory"},
+		{`fs.remove(dir, 1)`, "ERROR: remove() takes a boolean recursive flag"},
+		{`fs.stat("no/such/file.txt")`, "ERROR: stat() no/such/file.txt: no such file or directory"},
+		{`fs.listdir("no/such/dir")`, "ERROR: listdir() no/such/dir: no such file or directory"},
+		{`fs.rename("no/such/file.txt", "x.txt")`, "ERROR: rename() no/such/file.txt x.txt: no such file or directory"},
+		{`fs.glob("[")`, "ERROR: glob() syntax error in pattern"},
+		{`fs.exists(1)`, "ERROR: exists() takes a string as argument 1"},
+		{`fs.mkdir()`, "ERROR: mkdir() takes 1 argument"},
+	})
+
+	if _, err := os.Stat(filepath.Join(dir, "a")); !os.IsNotExist(err) {
+		t.Errorf("expected the directory to be removed, got %v", err)
+	}
+}
+
+func TestFSPath(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`fs.path.join("a", "b", "c.txt")`, filepath.Join("a", "b", "c.txt")},
+		{`fs.path.join("a/", "../b")`, "b"},
+		{`fs.path.join()`, ""},
+		{`fs.path.join("a", 1)`, "ERROR: join() takes a string as argument 2"},
+		{`fs.path.basename("data/x.csv")`, "x.csv"},
+		{`fs.path.dirname("data/x.csv")`, "data"},
+		{`fs.path.dirname("x.csv")`, "."},
+		{`fs.path.ext("data/x.tar.gz")`, ".gz"},
+		{`fs.path.ext("data/x")`, ""},
+		{`fs.path.abs("data/x.csv")`, filepath.Join(wd, "data", "x.csv")},
+		{`fs.path.basename()`, "ERROR: basename() takes 1 argument"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, "fs = import(\"fs\")\n"+tt.input, tt.expected)
+	}
+}
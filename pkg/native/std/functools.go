@@ -0,0 +1,764 @@
+package std
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ariaghora/zmol/pkg/eval"
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// Higher-order functions over lists and iterators. All functions take the list
+// as the first argument, so they can be used directly on the right side of
+// pipelines, e.g., `[3, 1, 2] |> functools.sort{}`.
+var FunctoolsModule = val.MODULE(
+	"functools",
+	&val.Env{
+		SymTable: map[string]val.ZValue{
+			// Ordering
+			"sort":    &val.ZNativeFunc{Fn: Z_functools_sort},
+			"sort_by": &val.ZNativeFunc{Fn: Z_functools_sort_by},
+			// Grouping
+			"group_by":  &val.ZNativeFunc{Fn: Z_functools_group_by},
+			"partition": &val.ZNativeFunc{Fn: Z_functools_partition},
+			"chunk":     &val.ZNativeFunc{Fn: Z_functools_chunk},
+			"window":    &val.ZNativeFunc{Fn: Z_functools_window},
+			// Transformation and slicing
+			"enumerate":  &val.ZNativeFunc{Fn: Z_functools_enumerate},
+			"flat_map":   &val.ZNativeFunc{Fn: Z_functools_flat_map},
+			"take":       &val.ZNativeFunc{Fn: Z_functools_take},
+			"drop":       &val.ZNativeFunc{Fn: Z_functools_drop},
+			"take_while": &val.ZNativeFunc{Fn: Z_functools_take_while},
+			"drop_while": &val.ZNativeFunc{Fn: Z_functools_drop_while},
+			"zip":        &val.ZNativeFunc{Fn: Z_functools_zip},
+			// Aggregation
+			"any":    &val.ZNativeFunc{Fn: Z_functools_any},
+			"all":    &val.ZNativeFunc{Fn: Z_functools_all},
+			"sum":    &val.ZNativeFunc{Fn: Z_functools_sum},
+			"min":    &val.ZNativeFunc{Fn: Z_functools_min},
+			"max":    &val.ZNativeFunc{Fn: Z_functools_max},
+			"unique": &val.ZNativeFunc{Fn: Z_functools_unique},
+			"count":  &val.ZNativeFunc{Fn: Z_functools_count},
+		},
+	},
+)
+
+// Returns the elements of a list argument, or an error if the argument is not
+// a list. Iterators are consumed into a list.
+func listArg(name string, arg val.ZValue) ([]val.ZValue, *val.ZError) {
+	switch arg.Type() {
+	case val.ZLIST:
+		return arg.(*val.ZList).Elements, nil
+	case val.ZITERATOR:
+		list := val.Collect(arg.(*val.ZIterator))
+		if list.Type() == val.ZERROR {
+			return nil, list.(*val.ZError)
+		}
+		return list.(*val.ZList).Elements, nil
+	}
+	return nil, &val.ZError{Message: name + "() takes a list as first argument, got " + string(arg.Type())}
+}
+
+// Returns an iterator over a list or iterator argument. It is used by the
+// functions that can consume their input lazily.
+func iterArg(name string, arg val.ZValue) (*val.ZIterator, *val.ZError) {
+	if arg.Type() != val.ZLIST && arg.Type() != val.ZITERATOR {
+		return nil, &val.ZError{Message: name + "() takes a list as first argument, got " + string(arg.Type())}
+	}
+	it, _ := val.Iterate(arg)
+	return it, nil
+}
+
+// Returns the callable argument at position i, or an error if it is not
+// callable.
+func callableArg(name string, args []val.ZValue, i int) (val.ZValue, *val.ZError) {
+	if len(args) <= i || !eval.IsCallable(args[i]) {
+		return nil, &val.ZError{Message: fmt.Sprintf("%s() takes a function as argument %d", name, i+1)}
+	}
+	return args[i], nil
+}
+
+// Returns a non-negative integer argument at position i.
+func countArg(name string, args []val.ZValue, i int) (int, *val.ZError) {
+	if len(args) <= i || args[i].Type() != val.ZINT || args[i].(*val.ZInt).Value < 0 {
+		return 0, &val.ZError{Message: fmt.Sprintf("%s() takes a non-negative integer as argument %d", name, i+1)}
+	}
+	return int(args[i].(*val.ZInt).Value), nil
+}
+
+// Calls a predicate and ensures it returns a boolean.
+func callPredicate(name string, pred val.ZValue, args ...val.ZValue) (bool, val.ZValue) {
+	result := eval.CallFunction(pred, args...)
+	if result.Type() == val.ZERROR {
+		return false, result
+	}
+	if result.Type() != val.ZBOOL {
+		return false, &val.ZError{Message: name + "() takes a function that returns a boolean"}
+	}
+	return result.(*val.ZBool).Value, nil
+}
+
+// Compares two values by their natural ordering. Numbers are compared by
+// value, strings lexicographically, and times and durations chronologically.
+// The second return value is false if the values are not mutually comparable.
+func compareValues(a, b val.ZValue) (int, bool) {
+	switch a := a.(type) {
+	case *val.ZTime:
+		return a.Compare(b)
+	case *val.ZDuration:
+		return a.Compare(b)
+	}
+	if a.Type() == val.ZSTRING || b.Type() == val.ZSTRING {
+		if a.Type() != b.Type() {
+			return 0, false
+		}
+		return strings.Compare(a.(*val.ZString).Value, b.(*val.ZString).Value), true
+	}
+	if x, ok := val.ToBigInt(a); ok {
+		if y, ok := val.ToBigInt(b); ok {
+			return x.Cmp(y), true
+		}
+	}
+	if x, ok := val.ToDecimal(a); ok {
+		if y, ok := val.ToDecimal(b); ok {
+			return x.Rat().Cmp(y.Rat()), true
+		}
+	}
+	x, errX := EnsureFloat(a)
+	y, errY := EnsureFloat(b)
+	if errX != nil || errY != nil {
+		return 0, false
+	}
+	switch {
+	case x < y:
+		return -1, true
+	case x > y:
+		return 1, true
+	}
+	return 0, true
+}
+
+// Returns a key identifying a value by its type and representation. It is
+// used to test equality of arbitrary values, e.g., for grouping.
+func valueKey(v val.ZValue) string {
+	return string(v.Type()) + ":" + v.Str()
+}
+
+// Sorts elements in place with a less function that may fail. The sort is
+// stable and the first error encountered is returned.
+func sortElements(elements []val.ZValue, less func(a, b val.ZValue) (bool, val.ZValue)) val.ZValue {
+	var err val.ZValue
+	sort.SliceStable(elements, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+		result, e := less(elements[i], elements[j])
+		if e != nil {
+			err = e
+		}
+		return result
+	})
+	return err
+}
+
+func naturalLess(name string) func(a, b val.ZValue) (bool, val.ZValue) {
+	return func(a, b val.ZValue) (bool, val.ZValue) {
+		cmp, ok := compareValues(a, b)
+		if !ok {
+			return false, &val.ZError{Message: fmt.Sprintf("%s() cannot compare %s with %s", name, a.Type(), b.Type())}
+		}
+		return cmp < 0, nil
+	}
+}
+
+// sort(list) sorts by natural ordering. sort(list, cmp) sorts with a custom
+// comparator `cmp(a, b)` returning either a boolean (true if a goes before b)
+// or an integer (negative if a goes before b).
+func Z_functools_sort(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 && len(args) != 2 {
+		return &val.ZError{Message: "sort() takes 1 or 2 arguments"}
+	}
+	elements, zErr := listArg("sort", args[0])
+	if zErr != nil {
+		return zErr
+	}
+	sorted := append([]val.ZValue{}, elements...)
+
+	less := naturalLess("sort")
+	if len(args) == 2 {
+		cmp, zErr := callableArg("sort", args, 1)
+		if zErr != nil {
+			return zErr
+		}
+		less = func(a, b val.ZValue) (bool, val.ZValue) {
+			result := eval.CallFunction(cmp, a, b)
+			switch result.Type() {
+			case val.ZBOOL:
+				return result.(*val.ZBool).Value, nil
+			case val.ZINT:
+				return result.(*val.ZInt).Value < 0, nil
+			case val.ZERROR:
+				return false, result
+			}
+			return false, &val.ZError{Message: "sort() comparator must return a boolean or an integer"}
+		}
+	}
+
+	if err := sortElements(sorted, less); err != nil {
+		return err
+	}
+	return &val.ZList{Elements: sorted}
+}
+
+// sort_by(list, key) sorts by the natural ordering of `key(element)`.
+func Z_functools_sort_by(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: "sort_by() takes 2 arguments"}
+	}
+	elements, zErr := listArg("sort_by", args[0])
+	if zErr != nil {
+		return zErr
+	}
+	keyFn, zErr := callableArg("sort_by", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+
+	// Compute each key once, then sort the elements along with their keys
+	type keyed struct{ key, elem val.ZValue }
+	pairs := make([]keyed, len(elements))
+	for i, e := range elements {
+		key := eval.CallFunction(keyFn, e)
+		if key.Type() == val.ZERROR {
+			return key
+		}
+		pairs[i] = keyed{key, e}
+	}
+
+	var err val.ZValue
+	less := naturalLess("sort_by")
+	sort.SliceStable(pairs, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+		result, e := less(pairs[i].key, pairs[j].key)
+		if e != nil {
+			err = e
+		}
+		return result
+	})
+	if err != nil {
+		return err
+	}
+
+	sorted := make([]val.ZValue, len(pairs))
+	for i, p := range pairs {
+		sorted[i] = p.elem
+	}
+	return &val.ZList{Elements: sorted}
+}
+
+// group_by(list, key) returns a list of [key, elements] pairs, in the order
+// each key first appears.
+func Z_functools_group_by(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: "group_by() takes 2 arguments"}
+	}
+	elements, zErr := listArg("group_by", args[0])
+	if zErr != nil {
+		return zErr
+	}
+	keyFn, zErr := callableArg("group_by", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+
+	groups := []val.ZValue{}
+	groupIndex := map[string]int{}
+	for _, e := range elements {
+		key := eval.CallFunction(keyFn, e)
+		if key.Type() == val.ZERROR {
+			return key
+		}
+		i, ok := groupIndex[valueKey(key)]
+		if !ok {
+			i = len(groups)
+			groupIndex[valueKey(key)] = i
+			groups = append(groups, &val.ZList{Elements: []val.ZValue{key, &val.ZList{Elements: []val.ZValue{}}}})
+		}
+		members := groups[i].(*val.ZList).Elements[1].(*val.ZList)
+		members.Elements = append(members.Elements, e)
+	}
+	return &val.ZList{Elements: groups}
+}
+
+// partition(list, pred) returns [matching, non_matching].
+func Z_functools_partition(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: "partition() takes 2 arguments"}
+	}
+	elements, zErr := listArg("partition", args[0])
+	if zErr != nil {
+		return zErr
+	}
+	pred, zErr := callableArg("partition", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+
+	matching := []val.ZValue{}
+	rest := []val.ZValue{}
+	for _, e := range elements {
+		ok, err := callPredicate("partition", pred, e)
+		if err != nil {
+			return err
+		}
+		if ok {
+			matching = append(matching, e)
+		} else {
+			rest = append(rest, e)
+		}
+	}
+	return &val.ZList{Elements: []val.ZValue{
+		&val.ZList{Elements: matching},
+		&val.ZList{Elements: rest},
+	}}
+}
+
+// chunk(list, n) splits a list into consecutive lists of n elements. The
+// last chunk may be shorter.
+func Z_functools_chunk(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: "chunk() takes 2 arguments"}
+	}
+	elements, zErr := listArg("chunk", args[0])
+	if zErr != nil {
+		return zErr
+	}
+	n, zErr := countArg("chunk", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+	if n == 0 {
+		return &val.ZError{Message: "chunk() size must be positive"}
+	}
+
+	chunks := []val.ZValue{}
+	for i := 0; i < len(elements); i += n {
+		end := i + n
+		if end > len(elements) {
+			end = len(elements)
+		}
+		chunks = append(chunks, &val.ZList{Elements: append([]val.ZValue{}, elements[i:end]...)})
+	}
+	return &val.ZList{Elements: chunks}
+}
+
+// window(list, n) returns all sliding windows of n consecutive elements.
+func Z_functools_window(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: "window() takes 2 arguments"}
+	}
+	elements, zErr := listArg("window", args[0])
+	if zErr != nil {
+		return zErr
+	}
+	n, zErr := countArg("window", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+	if n == 0 {
+		return &val.ZError{Message: "window() size must be positive"}
+	}
+
+	windows := []val.ZValue{}
+	for i := 0; i+n <= len(elements); i++ {
+		windows = append(windows, &val.ZList{Elements: append([]val.ZValue{}, elements[i:i+n]...)})
+	}
+	return &val.ZList{Elements: windows}
+}
+
+// enumerate(list) returns [index, element] pairs. The starting index can be
+// given as the second argument.
+func Z_functools_enumerate(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 && len(args) != 2 {
+		return &val.ZError{Message: "enumerate() takes 1 or 2 arguments"}
+	}
+	elements, zErr := listArg("enumerate", args[0])
+	if zErr != nil {
+		return zErr
+	}
+	var start int64
+	if len(args) == 2 {
+		if args[1].Type() != val.ZINT {
+			return &val.ZError{Message: "enumerate() takes an integer start index"}
+		}
+		start = args[1].(*val.ZInt).Value
+	}
+
+	pairs := make([]val.ZValue, len(elements))
+	for i, e := range elements {
+		pairs[i] = &val.ZList{Elements: []val.ZValue{val.INT(start + int64(i)), e}}
+	}
+	return &val.ZList{Elements: pairs}
+}
+
+// flat_map(list, fn) maps each element and flattens list results by one level.
+func Z_functools_flat_map(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: "flat_map() takes 2 arguments"}
+	}
+	elements, zErr := listArg("flat_map", args[0])
+	if zErr != nil {
+		return zErr
+	}
+	fn, zErr := callableArg("flat_map", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+
+	result := []val.ZValue{}
+	for _, e := range elements {
+		mapped := eval.CallFunction(fn, e)
+		switch mapped.Type() {
+		case val.ZERROR:
+			return mapped
+		case val.ZLIST:
+			result = append(result, mapped.(*val.ZList).Elements...)
+		default:
+			result = append(result, mapped)
+		}
+	}
+	return &val.ZList{Elements: result}
+}
+
+// take(list, n) returns the first n elements.
+func Z_functools_take(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: "take() takes 2 arguments"}
+	}
+	it, zErr := iterArg("take", args[0])
+	if zErr != nil {
+		return zErr
+	}
+	n, zErr := countArg("take", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+
+	elements := []val.ZValue{}
+	for len(elements) < n {
+		e, ok := it.Next()
+		if !ok {
+			break
+		}
+		if e.Type() == val.ZERROR {
+			return e
+		}
+		elements = append(elements, e)
+	}
+	return &val.ZList{Elements: elements}
+}
+
+// drop(list, n) returns all but the first n elements.
+func Z_functools_drop(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: "drop() takes 2 arguments"}
+	}
+	elements, zErr := listArg("drop", args[0])
+	if zErr != nil {
+		return zErr
+	}
+	n, zErr := countArg("drop", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+	if n > len(elements) {
+		n = len(elements)
+	}
+	return &val.ZList{Elements: append([]val.ZValue{}, elements[n:]...)}
+}
+
+// Returns the number of leading elements satisfying the predicate.
+func leadingMatches(name string, args []val.ZValue) (int, []val.ZValue, val.ZValue) {
+	if len(args) != 2 {
+		return 0, nil, &val.ZError{Message: name + "() takes 2 arguments"}
+	}
+	elements, zErr := listArg(name, args[0])
+	if zErr != nil {
+		return 0, nil, zErr
+	}
+	pred, zErr := callableArg(name, args, 1)
+	if zErr != nil {
+		return 0, nil, zErr
+	}
+	for i, e := range elements {
+		ok, err := callPredicate(name, pred, e)
+		if err != nil {
+			return 0, nil, err
+		}
+		if !ok {
+			return i, elements, nil
+		}
+	}
+	return len(elements), elements, nil
+}
+
+// take_while(list, pred) returns the leading elements satisfying pred.
+func Z_functools_take_while(args ...val.ZValue) val.ZValue {
+	n, elements, err := leadingMatches("take_while", args)
+	if err != nil {
+		return err
+	}
+	return &val.ZList{Elements: append([]val.ZValue{}, elements[:n]...)}
+}
+
+// drop_while(list, pred) drops the leading elements satisfying pred.
+func Z_functools_drop_while(args ...val.ZValue) val.ZValue {
+	n, elements, err := leadingMatches("drop_while", args)
+	if err != nil {
+		return err
+	}
+	return &val.ZList{Elements: append([]val.ZValue{}, elements[n:]...)}
+}
+
+// zip(list1, list2, ...) returns lists of the i-th elements of all lists. The
+// result is as long as the shortest list.
+func Z_functools_zip(args ...val.ZValue) val.ZValue {
+	if len(args) == 0 {
+		return &val.ZList{Elements: []val.ZValue{}}
+	}
+	lists := make([][]val.ZValue, len(args))
+	shortest := -1
+	for i, arg := range args {
+		elements, zErr := listArg("zip", arg)
+		if zErr != nil {
+			return zErr
+		}
+		lists[i] = elements
+		if shortest == -1 || len(lists[i]) < shortest {
+			shortest = len(lists[i])
+		}
+	}
+
+	tuples := make([]val.ZValue, shortest)
+	for i := 0; i < shortest; i++ {
+		tuple := make([]val.ZValue, len(lists))
+		for j, list := range lists {
+			tuple[j] = list[i]
+		}
+		tuples[i] = &val.ZList{Elements: tuple}
+	}
+	return &val.ZList{Elements: tuples}
+}
+
+// Shared implementation of any() and all(). Without a predicate, the elements
+// themselves must be booleans.
+func quantify(name string, args []val.ZValue, stopOn bool) val.ZValue {
+	if len(args) != 1 && len(args) != 2 {
+		return &val.ZError{Message: name + "() takes 1 or 2 arguments"}
+	}
+	it, zErr := iterArg(name, args[0])
+	if zErr != nil {
+		return zErr
+	}
+
+	var pred val.ZValue
+	if len(args) == 2 {
+		if pred, zErr = callableArg(name, args, 1); zErr != nil {
+			return zErr
+		}
+	}
+
+	for {
+		e, more := it.Next()
+		if !more {
+			break
+		}
+		if e.Type() == val.ZERROR {
+			return e
+		}
+
+		var ok bool
+		if pred != nil {
+			var err val.ZValue
+			if ok, err = callPredicate(name, pred, e); err != nil {
+				return err
+			}
+		} else {
+			if e.Type() != val.ZBOOL {
+				return &val.ZError{Message: name + "() takes a list of booleans when no predicate is given"}
+			}
+			ok = e.(*val.ZBool).Value
+		}
+		if ok == stopOn {
+			return val.BOOL(stopOn)
+		}
+	}
+	return val.BOOL(!stopOn)
+}
+
+// any(list[, pred]) returns true if at least one element satisfies pred.
+func Z_functools_any(args ...val.ZValue) val.ZValue {
+	return quantify("any", args, true)
+}
+
+// all(list[, pred]) returns true if every element satisfies pred.
+func Z_functools_all(args ...val.ZValue) val.ZValue {
+	return quantify("all", args, false)
+}
+
+// sum(list[, start]) adds up all elements with the `+` operator, starting
+// from 0 or the given start value.
+func Z_functools_sum(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 && len(args) != 2 {
+		return &val.ZError{Message: "sum() takes 1 or 2 arguments"}
+	}
+	it, zErr := iterArg("sum", args[0])
+	if zErr != nil {
+		return zErr
+	}
+
+	var total val.ZValue = val.INT(0)
+	if len(args) == 2 {
+		total = args[1]
+	}
+	for {
+		e, ok := it.Next()
+		if !ok {
+			break
+		}
+		if e.Type() == val.ZERROR {
+			return e
+		}
+		total = eval.EvalInfix("+", total, e)
+		if total.Type() == val.ZERROR {
+			return total
+		}
+	}
+	return total
+}
+
+// Shared implementation of min() and max(). An optional key function selects
+// the value to compare.
+func extremum(name string, args []val.ZValue, wantSign int) val.ZValue {
+	if len(args) != 1 && len(args) != 2 {
+		return &val.ZError{Message: name + "() takes 1 or 2 arguments"}
+	}
+	it, zErr := iterArg(name, args[0])
+	if zErr != nil {
+		return zErr
+	}
+	best, ok := it.Next()
+	if !ok {
+		return &val.ZError{Message: name + "() of an empty list"}
+	}
+	if best.Type() == val.ZERROR {
+		return best
+	}
+
+	key := func(v val.ZValue) val.ZValue { return v }
+	if len(args) == 2 {
+		keyFn, zErr := callableArg(name, args, 1)
+		if zErr != nil {
+			return zErr
+		}
+		key = func(v val.ZValue) val.ZValue { return eval.CallFunction(keyFn, v) }
+	}
+
+	bestKey := key(best)
+	if bestKey.Type() == val.ZERROR {
+		return bestKey
+	}
+	for {
+		e, ok := it.Next()
+		if !ok {
+			break
+		}
+		if e.Type() == val.ZERROR {
+			return e
+		}
+		k := key(e)
+		if k.Type() == val.ZERROR {
+			return k
+		}
+		cmp, ok := compareValues(k, bestKey)
+		if !ok {
+			return &val.ZError{Message: fmt.Sprintf("%s() cannot compare %s with %s", name, k.Type(), bestKey.Type())}
+		}
+		if cmp == wantSign {
+			best, bestKey = e, k
+		}
+	}
+	return best
+}
+
+// min(list[, key]) returns the smallest element.
+func Z_functools_min(args ...val.ZValue) val.ZValue {
+	return extremum("min", args, -1)
+}
+
+// max(list[, key]) returns the largest element.
+func Z_functools_max(args ...val.ZValue) val.ZValue {
+	return extremum("max", args, 1)
+}
+
+// unique(list) removes duplicate elements, keeping the first occurrence.
+func Z_functools_unique(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "unique() takes 1 argument"}
+	}
+	elements, zErr := listArg("unique", args[0])
+	if zErr != nil {
+		return zErr
+	}
+
+	seen := map[string]bool{}
+	result := []val.ZValue{}
+	for _, e := range elements {
+		if !seen[valueKey(e)] {
+			seen[valueKey(e)] = true
+			result = append(result, e)
+		}
+	}
+	return &val.ZList{Elements: result}
+}
+
+// count(list) returns the number of elements. count(list, pred) counts the
+// elements satisfying pred, and count(list, value) counts the elements equal
+// to value.
+func Z_functools_count(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 && len(args) != 2 {
+		return &val.ZError{Message: "count() takes 1 or 2 arguments"}
+	}
+	it, zErr := iterArg("count", args[0])
+	if zErr != nil {
+		return zErr
+	}
+
+	var n int64
+	for {
+		e, ok := it.Next()
+		if !ok {
+			break
+		}
+		if e.Type() == val.ZERROR {
+			return e
+		}
+		if len(args) == 1 {
+			n++
+		} else if eval.IsCallable(args[1]) {
+			ok, err := callPredicate("count", args[1], e)
+			if err != nil {
+				return err
+			}
+			if ok {
+				n++
+			}
+		} else if valueKey(e) == valueKey(args[1]) {
+			n++
+		}
+	}
+	return val.INT(n)
+}
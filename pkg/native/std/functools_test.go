@@ -0,0 +1,129 @@
+package std_test
+
+import "testing"
+
+// Imports the module for the inputs of the tests.
+const importFunctools = "f = import(\"functools\")\n"
+
+func TestFunctoolsSort(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`f.sort([3, 1, 2])`, "[1, 2, 3]"},
+		{`f.sort([2.5, 1, 2 ** 70, -1])`, "[-1, 1, 2.500000, 1180591620717411303424]"},
+		{`f.sort(["b", "a", "c"])`, "[a, b, c]"},
+		{`f.sort([])`, "[]"},
+		{`f.sort([3, 1, 2], @(a, b) { b - a })`, "[3, 2, 1]"},
+		{`f.sort([1, "a"])`, "ERROR: sort() cannot compare String with Int"},
+		{`f.sort(1)`, "ERROR: sort() takes a list as first argument, got Int"},
+		{`f.sort_by(["ccc", "a", "bb"], len)`, "[a, bb, ccc]"},
+		{`f.sort_by([[2, "b"], [1, "a"], [2, "a"]], @(p) { p[0] })`, "[[1, a], [2, b], [2, a]]"},
+		{`f.sort_by([], len)`, "[]"},
+		{`f.sort_by([1], 2)`, "ERROR: sort_by() takes a function as argument 2"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, importFunctools+tt.input, tt.expected)
+	}
+}
+
+func TestFunctoolsGrouping(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`f.group_by([1, 2, 3, 4, 5], @(x) { x % 2 })`, "[[1, [1, 3, 5]], [0, [2, 4]]]"},
+		{`f.group_by([], @(x) { x })`, "[]"},
+		{`f.partition([1, 2, 3, 4], @(x) { x > 2 })`, "[[3, 4], [1, 2]]"},
+		{`f.partition([1], @(x) { x })`, "ERROR: partition() takes a function that returns a boolean"},
+		{`f.chunk([1, 2, 3, 4, 5], 2)`, "[[1, 2], [3, 4], [5]]"},
+		{`f.chunk([], 2)`, "[]"},
+		{`f.chunk([1], 0)`, "ERROR: chunk() size must be positive"},
+		{`f.window([1, 2, 3, 4], 3)`, "[[1, 2, 3], [2, 3, 4]]"},
+		{`f.window([1, 2], 3)`, "[]"},
+		{`f.window([1], -1)`, "ERROR: window() takes a non-negative integer as argument 2"},
+		{`f.unique([1, 2, 1, "1", 2.0, 3])`, "[1, 2, 1, 2.000000, 3]"},
+		{`f.unique([])`, "[]"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, importFunctools+tt.input, tt.expected)
+	}
+}
+
+func TestFunctoolsSequences(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`f.enumerate(["a", "b"])`, "[[0, a], [1, b]]"},
+		{`f.enumerate(["a", "b"], 1)`, "[[1, a], [2, b]]"},
+		{`f.enumerate([])`, "[]"},
+		{`f.flat_map([1, 2], @(x) { [x, x * 10] })`, "[1, 10, 2, 20]"},
+		{`f.flat_map([1, 2], @(x) { x })`, "[1, 2]"},
+		{`f.take([1, 2, 3], 2)`, "[1, 2]"},
+		{`f.take([1, 2, 3], 5)`, "[1, 2, 3]"},
+		{`f.take([1, 2, 3], -1)`, "ERROR: take() takes a non-negative integer as argument 2"},
+		{`f.drop([1, 2, 3], 2)`, "[3]"},
+		{`f.drop([1, 2, 3], 5)`, "[]"},
+		{`f.take_while([1, 2, 5, 1], @(x) { x < 3 })`, "[1, 2]"},
+		{`f.drop_while([1, 2, 5, 1], @(x) { x < 3 })`, "[5, 1]"},
+		{`f.take_while([], @(x) { x < 3 })`, "[]"},
+		{`f.zip([1, 2, 3], ["a", "b"])`, "[[1, a], [2, b]]"},
+		{`f.zip([1, 2], ["a", "b"], [true, false])`, "[[1, a, true], [2, b, false]]"},
+		{`f.zip()`, "[]"},
+		{`f.zip([1], 2)`, "ERROR: zip() takes a list as first argument, got Int"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, importFunctools+tt.input, tt.expected)
+	}
+}
+
+func TestFunctoolsReductions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`f.any([false, true])`, "true"},
+		{`f.any([])`, "false"},
+		{`f.any([1, 2], @(x) { x > 1 })`, "true"},
+		{`f.all([1, 2], @(x) { x > 1 })`, "false"},
+		{`f.all([])`, "true"},
+		{`f.all([1])`, "ERROR: all() takes a list of booleans when no predicate is given"},
+		{`f.sum([1, 2, 3])`, "6"},
+		{`f.sum([1, 2.5])`, "3.500000"},
+		{`f.sum([])`, "0"},
+		{`f.sum(["a", "b"], "")`, "ab"},
+		{`f.min([3, 1, 2])`, "1"},
+		{`f.max(["bb", "a", "ccc"], len)`, "ccc"},
+		{`f.max([2, 2.0])`, "2"},
+		{`f.min([])`, "ERROR: min() of an empty list"},
+		{`f.count([1, 2, 3])`, "3"},
+		{`f.count([1, 2, 3], @(x) { x != 2 })`, "2"},
+		{`f.count([], @(x) { true })`, "0"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, importFunctools+tt.input, tt.expected)
+	}
+}
+
+// The functions take iterators as well as lists, and stop at the first error.
+func TestFunctoolsIterators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`f.take(lazy([1, 2, 3]) -> @(x) { x * 2 }{}, 2)`, "[2, 4]"},
+		{`f.sum(lazy(range_list(0, 5)))`, "10"},
+		{`f.sort(lazy([2, 1]))`, "[1, 2]"},
+		{`f.count(lazy([1, 0, 2]) -> @(x) { 2 / x }{})`, "ERROR: division by zero"},
+		{`f.group_by([1, 0], @(x) { 1 / x })`, "ERROR: division by zero"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, importFunctools+tt.input, tt.expected)
+	}
+}
@@ -0,0 +1,117 @@
+package std
+
+import (
+	"math"
+
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// Reverse-mode automatic differentiation. Variables created with `var()` are
+// combined with the arithmetic operators and the functions of this module,
+// and calling backward() on a scalar result fills the .grad of each variable.
+var GradModule = val.MODULE(
+	"grad",
+	&val.Env{
+		SymTable: map[string]val.ZValue{
+			"var": &val.ZNativeFunc{Fn: Z_grad_var},
+			// Elementwise functions
+			"exp":     gradMapFunc("exp", math.Exp, func(x, out float64) float64 { return out }),
+			"log":     gradMapFunc("log", math.Log, func(x, out float64) float64 { return 1 / x }),
+			"sigmoid": gradMapFunc("sigmoid", sigmoid, func(x, out float64) float64 { return out * (1 - out) }),
+			"tanh":    gradMapFunc("tanh", math.Tanh, func(x, out float64) float64 { return 1 - out*out }),
+			"relu":    gradMapFunc("relu", relu, func(x, out float64) float64 { return step(x) }),
+			// Reductions and products
+			"sum":    &val.ZNativeFunc{Fn: Z_grad_sum},
+			"mean":   &val.ZNativeFunc{Fn: Z_grad_mean},
+			"matmul": &val.ZNativeFunc{Fn: Z_grad_matmul},
+		},
+	},
+)
+
+func sigmoid(x float64) float64 { return 1 / (1 + math.Exp(-x)) }
+func relu(x float64) float64    { return math.Max(x, 0) }
+
+func step(x float64) float64 {
+	if x > 0 {
+		return 1
+	}
+	return 0
+}
+
+// var(x) returns a variable that requires gradients, with a copy of an Int, a
+// Float, a tensor or a list as value. Tensors are converted to float64.
+func Z_grad_var(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "var() takes 1 argument"}
+	}
+	v, ok := val.VARIABLE(args[0])
+	if !ok {
+		return &val.ZError{Message: "var() takes a number, a tensor or a list, got " + string(args[0].Type())}
+	}
+	return v
+}
+
+// Returns the argument at position i as a variable. Numbers and tensors are
+// constants.
+func variableArg(name string, args []val.ZValue, i int) (*val.ZVariable, *val.ZError) {
+	v, ok := val.ToVariable(args[i])
+	if !ok {
+		return nil, &val.ZError{Message: name + "() takes a variable, a number or a tensor, got " + string(args[i].Type())}
+	}
+	return v, nil
+}
+
+// Returns a native function applying f to each element, with derivative df
+// given the element and the result.
+func gradMapFunc(name string, f func(float64) float64, df func(x, out float64) float64) *val.ZNativeFunc {
+	return &val.ZNativeFunc{Fn: func(args ...val.ZValue) val.ZValue {
+		if len(args) != 1 {
+			return &val.ZError{Message: name + "() takes 1 argument"}
+		}
+		v, zErr := variableArg(name, args, 0)
+		if zErr != nil {
+			return zErr
+		}
+		return v.Map(f, df)
+	}}
+}
+
+// sum(x) returns the sum of all elements.
+func Z_grad_sum(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "sum() takes 1 argument"}
+	}
+	v, zErr := variableArg("sum", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	return v.Sum()
+}
+
+// mean(x) returns the mean of all elements.
+func Z_grad_mean(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "mean() takes 1 argument"}
+	}
+	v, zErr := variableArg("mean", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	return v.Mean()
+}
+
+// matmul(a, b) returns the matrix product of two matrices or vectors.
+func Z_grad_matmul(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: "matmul() takes 2 arguments"}
+	}
+	a, zErr := variableArg("matmul", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	b, zErr := variableArg("matmul", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+	return a.MatMul(b)
+}
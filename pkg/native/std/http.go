@@ -0,0 +1,323 @@
+package std
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ariaghora/zmol/pkg/eval"
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// An HTTP client, and a minimal server that routes requests to zmol
+// functions. Requests and responses are tables, with headers as tables from
+// header names to values. Bodies are strings, and tables or lists given as
+// bodies are sent as JSON.
+var HTTPModule = val.MODULE(
+	"http",
+	&val.Env{
+		SymTable: map[string]val.ZValue{
+			// Client
+			"get":     &val.ZNativeFunc{Fn: Z_http_get},
+			"post":    &val.ZNativeFunc{Fn: Z_http_post},
+			"request": &val.ZNativeFunc{Fn: Z_http_request},
+			// Server
+			"serve": &val.ZNativeFunc{Fn: Z_http_serve},
+		},
+	},
+)
+
+const defaultHTTPTimeout = 30 * time.Second
+
+// Returns the headers argument at position i, a table of strings, if given.
+func headersArg(name string, args []val.ZValue, i int) (map[string]string, *val.ZError) {
+	headers := map[string]string{}
+	if len(args) <= i {
+		return headers, nil
+	}
+	table, ok := args[i].(*val.ZTable)
+	if !ok {
+		return nil, &val.ZError{Message: fmt.Sprintf("%s() takes a table of headers as argument %d", name, i+1)}
+	}
+	for _, key := range table.Keys() {
+		value, _ := table.Get(key)
+		if value.Type() != val.ZSTRING {
+			return nil, &val.ZError{Message: fmt.Sprintf("%s() takes string header values, got %s for %q", name, value.Type(), key)}
+		}
+		headers[key] = value.(*val.ZString).Value
+	}
+	return headers, nil
+}
+
+// Returns the bytes of a body, and whether it was encoded as JSON.
+func httpBody(name string, body val.ZValue) ([]byte, bool, *val.ZError) {
+	switch body := body.(type) {
+	case *val.ZString:
+		return []byte(body.Value), false, nil
+	case *val.ZNull:
+		return nil, false, nil
+	case *val.ZTable, *val.ZList:
+		var buf bytes.Buffer
+		if err := encodeJSON(&buf, body, map[val.ZValue]bool{}); err != nil {
+			return nil, false, &val.ZError{Message: name + "() " + err.Error()}
+		}
+		return buf.Bytes(), true, nil
+	}
+	return nil, false, &val.ZError{Message: fmt.Sprintf("%s() takes a string, a table or a list as body, got %s", name, body.Type())}
+}
+
+// Returns a table of headers. Repeated headers are joined with commas.
+func headersTable(header http.Header) *val.ZTable {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	table := val.TABLE()
+	for _, name := range names {
+		table.Set(name, val.STRING(strings.Join(header[name], ", ")))
+	}
+	return table
+}
+
+// Sends a request, and returns the response as a table with the status,
+// headers and body.
+func sendRequest(name, method, url string, body val.ZValue, headers map[string]string, timeout time.Duration) val.ZValue {
+	content, isJSON, zErr := httpBody(name, body)
+	if zErr != nil {
+		return zErr
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(content))
+	if err != nil {
+		return &val.ZError{Message: name + "() " + err.Error()}
+	}
+	if isJSON {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return &val.ZError{Message: name + "() " + err.Error()}
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &val.ZError{Message: name + "() " + err.Error()}
+	}
+
+	response := val.TABLE()
+	response.Set("status", val.INT(int64(resp.StatusCode)))
+	response.Set("headers", headersTable(resp.Header))
+	response.Set("body", val.STRING(string(respBody)))
+	return response
+}
+
+// Shared implementation of the client functions, whose optional headers and
+// timeout arguments start at position i.
+func clientRequest(name, method string, args []val.ZValue, url, body val.ZValue, i int) val.ZValue {
+	if url.Type() != val.ZSTRING {
+		return &val.ZError{Message: name + "() takes a string URL"}
+	}
+	headers, zErr := headersArg(name, args, i)
+	if zErr != nil {
+		return zErr
+	}
+	timeout := defaultHTTPTimeout
+	if len(args) > i+1 {
+		if timeout, zErr = durationArg(name, args, i+1); zErr != nil {
+			return zErr
+		}
+	}
+	return sendRequest(name, method, url.(*val.ZString).Value, body, headers, timeout)
+}
+
+// get(url[, headers[, timeout]]) sends a GET request. The timeout is a duration
+// or a number of seconds, 30 seconds by default.
+func Z_http_get(args ...val.ZValue) val.ZValue {
+	if len(args) < 1 || len(args) > 3 {
+		return &val.ZError{Message: "get() takes 1 to 3 arguments"}
+	}
+	return clientRequest("get", http.MethodGet, args, args[0], &val.ZNull{}, 1)
+}
+
+// post(url, body[, headers[, timeout]]) sends a POST request.
+func Z_http_post(args ...val.ZValue) val.ZValue {
+	if len(args) < 2 || len(args) > 4 {
+		return &val.ZError{Message: "post() takes 2 to 4 arguments"}
+	}
+	return clientRequest("post", http.MethodPost, args, args[0], args[1], 2)
+}
+
+// request(method, url, body[, headers[, timeout]]) sends a request with any
+// method, e.g., "PUT" or "DELETE". The body can be null.
+func Z_http_request(args ...val.ZValue) val.ZValue {
+	if len(args) < 3 || len(args) > 5 {
+		return &val.ZError{Message: "request() takes 3 to 5 arguments"}
+	}
+	method, zErr := stringArg("request", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	return clientRequest("request", strings.ToUpper(method), args, args[1], args[2], 3)
+}
+
+// A route of the server, from a key such as "POST /hook" or "/health".
+type httpRoute struct {
+	method  string
+	path    string
+	handler val.ZValue
+}
+
+// HTTPHandler returns a handler that routes requests to zmol functions. Routes
+// map a path, optionally preceded by a method, to a function. Paths ending
+// with / match every path below them, and the longest matching path is used.
+// Handlers take the request as a table with the method, path, query, headers
+// and body, and return either the body as a string, or a table with the
+// status, headers and body of the response.
+//
+// Handlers may share lists and tables, which are not safe for concurrent use,
+// so they are called one at a time.
+func HTTPHandler(routes *val.ZTable) (http.Handler, *val.ZError) {
+	var table []httpRoute
+	for _, key := range routes.Keys() {
+		handler, _ := routes.Get(key)
+		if !eval.IsCallable(handler) {
+			return nil, &val.ZError{Message: fmt.Sprintf("route %q takes a function", key)}
+		}
+		route := httpRoute{path: key, handler: handler}
+		if method, path, ok := strings.Cut(key, " "); ok {
+			route.method, route.path = strings.ToUpper(method), strings.TrimSpace(path)
+		}
+		if !strings.HasPrefix(route.path, "/") {
+			return nil, &val.ZError{Message: fmt.Sprintf("route %q takes a path starting with /", key)}
+		}
+		table = append(table, route)
+	}
+
+	var mu sync.Mutex
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var match *httpRoute
+		pathMatched := false
+		for i, route := range table {
+			if route.path != r.URL.Path && !(strings.HasSuffix(route.path, "/") && strings.HasPrefix(r.URL.Path, route.path)) {
+				continue
+			}
+			pathMatched = true
+			if route.method != "" && route.method != r.Method {
+				continue
+			}
+			if match == nil || len(route.path) > len(match.path) {
+				match = &table[i]
+			}
+		}
+		if match == nil {
+			if pathMatched {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			} else {
+				http.NotFound(w, r)
+			}
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		query := val.TABLE()
+		for key, values := range r.URL.Query() {
+			query.Set(key, val.STRING(values[0]))
+		}
+		request := val.TABLE()
+		request.Set("method", val.STRING(r.Method))
+		request.Set("path", val.STRING(r.URL.Path))
+		request.Set("query", query)
+		request.Set("headers", headersTable(r.Header))
+		request.Set("body", val.STRING(string(body)))
+
+		mu.Lock()
+		result := eval.CallFunction(match.handler, request)
+		mu.Unlock()
+		writeHTTPResponse(w, result)
+	}), nil
+}
+
+// Writes the result of a handler as a response.
+func writeHTTPResponse(w http.ResponseWriter, result val.ZValue) {
+	if result.Type() == val.ZERROR {
+		http.Error(w, result.(*val.ZError).Message, http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	body := result
+	if response, ok := result.(*val.ZTable); ok {
+		body = &val.ZNull{}
+		if value, ok := response.Get("body"); ok {
+			body = value
+		}
+		if value, ok := response.Get("status"); ok {
+			if value.Type() != val.ZINT {
+				http.Error(w, "handler returned a non-integer status", http.StatusInternalServerError)
+				return
+			}
+			status = int(value.(*val.ZInt).Value)
+		}
+		if value, ok := response.Get("headers"); ok {
+			headers, zErr := headersArg("handler", []val.ZValue{value}, 0)
+			if zErr != nil {
+				http.Error(w, zErr.Message, http.StatusInternalServerError)
+				return
+			}
+			for key, value := range headers {
+				w.Header().Set(key, value)
+			}
+		}
+	}
+
+	content, isJSON, zErr := httpBody("handler", body)
+	if zErr != nil {
+		http.Error(w, zErr.Message, http.StatusInternalServerError)
+		return
+	}
+	if isJSON && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(status)
+	w.Write(content)
+}
+
+// serve(addr, routes) serves HTTP requests on an address such as ":8080" or
+// "127.0.0.1:8080", routing them to the functions of a table of routes, e.g.,
+// `table([["GET /health", health], ["POST /hook", on_hook]])`. It only returns
+// if the server cannot be started.
+func Z_http_serve(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: "serve() takes 2 arguments"}
+	}
+	addr, zErr := stringArg("serve", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	routes, ok := args[1].(*val.ZTable)
+	if !ok {
+		return &val.ZError{Message: "serve() takes a table of routes as argument 2"}
+	}
+	handler, zErr := HTTPHandler(routes)
+	if zErr != nil {
+		return &val.ZError{Message: "serve() " + zErr.Message}
+	}
+	if err := http.ListenAndServe(addr, handler); err != nil {
+		return &val.ZError{Message: "serve() " + err.Error()}
+	}
+	return &val.ZNull{}
+}
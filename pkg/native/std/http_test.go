@@ -0,0 +1,109 @@
+package std_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ariaghora/zmol/pkg/eval"
+	"github.com/ariaghora/zmol/pkg/native/std"
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+const handlers = `
+http = import("http")
+json = import("json")
+
+health = @(req) { "ok" }
+hook = @(req) {
+	event = json.parse(req.body)
+	response = table()
+	response.status = 201
+	response.headers = table([["X-Event", event.name]])
+	response.body = table([["received", event.name], ["token", req.headers["X-Token"]]])
+	response
+}
+file = @(req) { req.path + "?" + req.query.v }
+broken = @(req) { json.parse("{") }
+
+routes = table([["GET /health", health], ["POST /hook", hook], ["/files/", file], ["/broken", broken]])
+`
+
+// Returns a script state with the handlers defined.
+func newHandlerState(t *testing.T) *eval.ZmolState {
+	state := newScriptState()
+	if _, err := state.Eval(handlers); err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+func TestHTTPServer(t *testing.T) {
+	state := newHandlerState(t)
+	routes, _ := state.Env.Get("routes")
+	handler, zErr := std.HTTPHandler(routes.(*val.ZTable))
+	if zErr != nil {
+		t.Fatal(zErr.Message)
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	state.Env.Set("url", val.STRING(server.URL))
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`http.get(url + "/health").body`, "ok"},
+		{`http.get(url + "/health").status`, "200"},
+		{`http.post(url + "/health", "").status`, "405"},
+		{`http.get(url + "/missing").status`, "404"},
+		{`http.get(url + "/files/a/b.txt?v=2").body`, "/files/a/b.txt?2"},
+		{`http.get(url + "/broken").status`, "500"},
+		{`r = http.post(url + "/hook", table([["name", "push"]]), table([["X-Token", "s3cr3t"]]))
+		headers = r.headers
+		result = [r.status, headers["X-Event"], r.body]
+		result`, `[201, push, {"received":"push","token":"s3cr3t"}]`},
+		{`r = http.request("delete", url + "/files/x?v=1", "")
+		r.body`, "/files/x?1"},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := state.Eval(tt.input)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.input, err)
+		}
+		if evaluated.Str() != tt.expected {
+			t.Errorf("%s: got=%s, want=%s", tt.input, evaluated.Str(), tt.expected)
+		}
+	}
+}
+
+func TestHTTPClientTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	state := newHandlerState(t)
+	state.Env.Set("url", val.STRING(server.URL))
+	evaluated, err := state.Eval(`is_error(http.get(url, table(), 0.05))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evaluated.Str() != "true" {
+		t.Errorf("expected a timeout error, got=%s", evaluated.Str())
+	}
+}
+
+func TestHTTPHandlerRoutes(t *testing.T) {
+	state := newHandlerState(t)
+	if _, err := state.Eval(`bad = table([["health", health]])`); err != nil {
+		t.Fatal(err)
+	}
+	routes, _ := state.Env.Get("bad")
+	_, zErr := std.HTTPHandler(routes.(*val.ZTable))
+	if zErr == nil || zErr.Message != `route "health" takes a path starting with /` {
+		t.Errorf("expected an invalid route error, got=%v", zErr)
+	}
+}
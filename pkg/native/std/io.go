@@ -1,21 +1,56 @@
 package std
 
 import (
-	"io/ioutil"
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
 
-	"github.com/ariaghora/zmol/pkg/eval"
 	"github.com/ariaghora/zmol/pkg/val"
 )
 
+// Reading and writing files. Failures, such as a missing file, are returned
+// as errors that can be checked with is_error().
 var IOModule = val.MODULE(
 	"io",
 	&val.Env{
 		SymTable: map[string]val.ZValue{
+			// Whole files
 			"read_string_file": &val.ZNativeFunc{Fn: Z_read_string_file},
+			"read_lines":       &val.ZNativeFunc{Fn: Z_io_read_lines},
+			"read_bytes":       &val.ZNativeFunc{Fn: Z_io_read_bytes},
+			"write_file":       &val.ZNativeFunc{Fn: Z_io_write_file},
+			"append_file":      &val.ZNativeFunc{Fn: Z_io_append_file},
+			// File handles
+			"open": &val.ZNativeFunc{Fn: Z_io_open},
 		},
 	},
 )
 
+// Returns the string argument at position i.
+func stringArg(name string, args []val.ZValue, i int) (string, *val.ZError) {
+	if len(args) <= i || args[i].Type() != val.ZSTRING {
+		return "", &val.ZError{Message: fmt.Sprintf("%s() takes a string as argument %d", name, i+1)}
+	}
+	return args[i].(*val.ZString).Value, nil
+}
+
+// Returns an error value for a failed file operation. The operation is
+// dropped from path errors, as the function name takes its place, e.g.,
+// "open() data.csv: no such file or directory".
+func fileError(name string, err error) *val.ZError {
+	var pathErr *os.PathError
+	var linkErr *os.LinkError
+	switch {
+	case errors.As(err, &pathErr):
+		return &val.ZError{Message: fmt.Sprintf("%s() %s: %v", name, pathErr.Path, pathErr.Err)}
+	case errors.As(err, &linkErr):
+		return &val.ZError{Message: fmt.Sprintf("%s() %s %s: %v", name, linkErr.Old, linkErr.New, linkErr.Err)}
+	}
+	return &val.ZError{Message: name + "() " + err.Error()}
+}
+
 func Z_read_string_file(args ...val.ZValue) val.ZValue {
 	if len(args) != 1 {
 		return &val.ZError{Message: "read_string_file takes 1 argument"}
@@ -26,9 +61,123 @@ func Z_read_string_file(args ...val.ZValue) val.ZValue {
 	}
 
 	filePath := args[0].(*val.ZString).Value
-	content, err := ioutil.ReadFile(filePath)
+	content, err := os.ReadFile(filePath)
 	if err != nil {
-		eval.RuntimeErrorf("cannot read file " + filePath)
+		return &val.ZError{Message: "cannot read file " + filePath}
 	}
 	return val.STRING(string(content))
 }
+
+// read_lines(path) returns the lines of a file, without their newlines.
+func Z_io_read_lines(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "read_lines() takes 1 argument"}
+	}
+	path, zErr := stringArg("read_lines", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fileError("read_lines", err)
+	}
+	defer f.Close()
+
+	lines := []val.ZValue{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<30)
+	for scanner.Scan() {
+		lines = append(lines, val.STRING(strings.TrimSuffix(scanner.Text(), "\r")))
+	}
+	if err := scanner.Err(); err != nil {
+		return fileError("read_lines", err)
+	}
+	return &val.ZList{Elements: lines}
+}
+
+// read_bytes(path) returns the content of a file as bytes.
+func Z_io_read_bytes(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "read_bytes() takes 1 argument"}
+	}
+	path, zErr := stringArg("read_bytes", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fileError("read_bytes", err)
+	}
+	return val.BYTES(content)
+}
+
+// Shared implementation of write_file() and append_file(), which write a
+// string or bytes to a file opened with the given mode.
+func writeFile(name, mode string, args []val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: name + "() takes 2 arguments"}
+	}
+	path, zErr := stringArg(name, args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	var content []byte
+	switch data := args[1].(type) {
+	case *val.ZString:
+		content = []byte(data.Value)
+	case *val.ZBytes:
+		content = data.Value
+	default:
+		return &val.ZError{Message: name + "() takes a string or bytes as argument 2"}
+	}
+	f, err := os.OpenFile(path, val.FileModes[mode], 0644)
+	if err != nil {
+		return fileError(name, err)
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return fileError(name, err)
+	}
+	if err := f.Close(); err != nil {
+		return fileError(name, err)
+	}
+	return &val.ZNull{}
+}
+
+// write_file(path, content) creates or replaces a file with a string or bytes.
+func Z_io_write_file(args ...val.ZValue) val.ZValue {
+	return writeFile("write_file", "w", args)
+}
+
+// append_file(path, content) adds a string or bytes to the end of a file,
+// creating it if needed.
+func Z_io_append_file(args ...val.ZValue) val.ZValue {
+	return writeFile("append_file", "a", args)
+}
+
+// open(path[, mode]) returns a file handle. The mode is "r" to read (the
+// default), "w" to create or replace, or "a" to append.
+func Z_io_open(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 && len(args) != 2 {
+		return &val.ZError{Message: "open() takes 1 or 2 arguments"}
+	}
+	path, zErr := stringArg("open", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	mode := "r"
+	if len(args) == 2 {
+		if mode, zErr = stringArg("open", args, 1); zErr != nil {
+			return zErr
+		}
+	}
+	flag, ok := val.FileModes[mode]
+	if !ok {
+		return &val.ZError{Message: "open() takes a mode of \"r\", \"w\" or \"a\", got \"" + mode + "\""}
+	}
+	f, err := os.OpenFile(path, flag, 0644)
+	if err != nil {
+		return fileError("open", err)
+	}
+	return val.FILE(path, f)
+}
@@ -0,0 +1,110 @@
+package std_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// Evaluates the inputs in order in one state, with io imported and `dir` set
+// to a temporary directory.
+func testFileScripts(t *testing.T, dir string, tests []struct {
+	input    string
+	expected string
+}) {
+	t.Helper()
+	state := newScriptState()
+	state.Env.Set("dir", val.STRING(dir))
+	if _, err := state.Eval("io = import(\"io\")\nfs = import(\"fs\")"); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		evaluated, err := state.Eval(tt.input)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.input, err)
+		}
+		if evaluated.Str() != tt.expected {
+			t.Errorf("%s: got=%s, want=%s", tt.input, evaluated.Str(), tt.expected)
+		}
+	}
+}
+
+func TestIOWholeFiles(t *testing.T) {
+	dir := t.TempDir()
+	testFileScripts(t, dir, []struct {
+		input    string
+		expected string
+	}{
+		{`path = fs.path.join(dir, "notes.txt")
+		io.write_file(path, bytes([111, 110, 101, 13, 10]))
+		io.append_file(path, "two\n")`, ""},
+		{`io.read_string_file(path)`, "one\r\ntwo\n"},
+		{`io.read_lines(path)`, "[one, two]"},
+		{`io.append_file(path, bytes([116, 104, 114, 101, 101]))`, ""},
+		{`io.read_lines(path)`, "[one, two, three]"},
+		{`len(io.read_bytes(path))`, "14"},
+		{`io.write_file(path, "")
+		io.read_lines(path)`, "[]"},
+		{`io.append_file(fs.path.join(dir, "new.txt"), "x")
+		io.read_string_file(fs.path.join(dir, "new.txt"))`, "x"},
+		{`io.read_string_file("no/such/file.txt")`, "ERROR: cannot read file no/such/file.txt"},
+		{`is_error(io.read_string_file(dir))`, "true"},
+		{`io.read_lines("no/such/file.txt")`, "ERROR: read_lines() no/such/file.txt: no such file or directory"},
+		{`io.read_bytes("no/such/file.txt")`, "ERROR: read_bytes() no/such/file.txt: no such file or directory"},
+		{`io.write_file("no/such/file.txt", "x")`, "ERROR: write_file() no/such/file.txt: no such file or directory"},
+		{`io.write_file(path, 1)`, "ERROR: write_file() takes a string or bytes as argument 2"},
+		{`io.read_lines(1)`, "ERROR: read_lines() takes a string as argument 1"},
+		{`io.read_string_file()`, "ERROR: read_string_file takes 1 argument"},
+	})
+
+	content, err := os.ReadFile(filepath.Join(dir, "new.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "x" {
+		t.Errorf("unexpected file content %q", content)
+	}
+}
+
+func TestIOFileHandles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.txt")
+	testFileScripts(t, dir, []struct {
+		input    string
+		expected string
+	}{
+		{`path = fs.path.join(dir, "log.txt")
+		f = io.open(path, "w")
+		r = [f.write("a\n"), f.write(bytes([98, 13, 10])), f.write("c"), f.closed]
+		r`, "[2, 3, 1, false]"},
+		{`f.close()
+		r = [f.closed, f.close()]
+		r`, "[true, ]"},
+		{`f.write("d")`, "ERROR: write() on closed file " + path},
+		{`f = io.open(path)
+		r = [f.read_line(), f.read_line(), f.read_line(), f.read_line()]
+		r`, "[a\n, b\r\n, c, ]"},
+		{`f.close()
+		f = io.open(path)
+		f.lines() |> collect{}`, "[a, b, c]"},
+		{`f.close()
+		f = io.open(path)
+		r = [f.read_bytes(2), f.read(), f.read_bytes(), f.read_bytes(1)]
+		r`, "[b\"a\\n\", b\r\nc, b\"\", b\"\"]"},
+		{`f.close()
+		f = io.open(path, "a")
+		f.write("d")
+		f.close()
+		f = io.open(path)
+		f.read()`, "a\nb\r\ncd"},
+		{`f.read_bytes(-1)`, "ERROR: read_bytes() takes a non-negative integer"},
+		{`f.write(1)`, "ERROR: write() takes a string or bytes"},
+		{`f.close()
+		f.read()`, "ERROR: read() on closed file " + path},
+		{`io.open(path, "x")`, `ERROR: open() takes a mode of "r", "w" or "a", got "x"`},
+		{`io.open("no/such/file.txt")`, "ERROR: open() no/such/file.txt: no such file or directory"},
+		{`io.open()`, "ERROR: open() takes 1 or 2 arguments"},
+	})
+}
@@ -0,0 +1,245 @@
+package std
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// JSON encoding and decoding. Objects are decoded to tables, which keep the
+// order of their keys, arrays to lists, numbers to Int (or BigInt) when they
+// are integral and to Float otherwise, and null to null.
+var JSONModule = val.MODULE(
+	"json",
+	&val.Env{
+		SymTable: map[string]val.ZValue{
+			"parse":     &val.ZNativeFunc{Fn: Z_json_parse},
+			"stringify": &val.ZNativeFunc{Fn: Z_json_stringify},
+		},
+	},
+)
+
+// Returns the line and column, counted from 1, of a byte offset in a text.
+func lineColumn(text string, offset int) (int, int) {
+	if offset > len(text) {
+		offset = len(text)
+	}
+	before := text[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := utf8.RuneCountInString(before[strings.LastIndexByte(before, '\n')+1:]) + 1
+	return line, column
+}
+
+// parse(text) decodes a JSON value. Invalid JSON results in an error with the
+// line and column of the problem.
+func Z_json_parse(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "parse() takes 1 argument"}
+	}
+	text, zErr := stringArg("parse", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+	value, err := decodeJSON(dec)
+	if err == nil {
+		// Only whitespace may follow the value
+		offset := int(dec.InputOffset())
+		rest := strings.TrimLeft(text[offset:], " \t\r\n")
+		if rest != "" {
+			line, column := lineColumn(text, len(text)-len(rest))
+			return &val.ZError{Message: fmt.Sprintf("parse() unexpected data after the JSON value at line %d, column %d", line, column)}
+		}
+		return value
+	}
+
+	offset := int(dec.InputOffset())
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// The offset of a syntax error is just past the invalid character
+		offset = int(syntaxErr.Offset) - 1
+	}
+	// The end of the input is past the last character. A syntax error after
+	// all the input was consumed is a truncated value as well.
+	truncated := syntaxErr != nil && dec.InputOffset() >= int64(len(text))
+	if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) || truncated {
+		err, offset = errors.New("unexpected end of JSON input"), len(text)
+	}
+	line, column := lineColumn(text, offset)
+	return &val.ZError{Message: fmt.Sprintf("parse() %v at line %d, column %d", err, line, column)}
+}
+
+func decodeJSON(dec *json.Decoder) (val.ZValue, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		if token == '[' {
+			elements := []val.ZValue{}
+			for dec.More() {
+				element, err := decodeJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				elements = append(elements, element)
+			}
+			_, err := dec.Token()
+			return &val.ZList{Elements: elements}, err
+		}
+
+		table := val.TABLE()
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			table.Set(key.(string), value)
+		}
+		_, err := dec.Token()
+		return table, err
+	case string:
+		return val.STRING(token), nil
+	case bool:
+		return val.BOOL(token), nil
+	case json.Number:
+		if n, err := strconv.ParseInt(string(token), 10, 64); err == nil {
+			return val.INT(n), nil
+		}
+		if n, ok := new(big.Int).SetString(string(token), 10); ok {
+			return val.INTEGER(n), nil
+		}
+		x, err := strconv.ParseFloat(string(token), 64)
+		if err != nil {
+			return nil, fmt.Errorf("number %s is out of range", token)
+		}
+		return val.FLOAT(x), nil
+	}
+	return val.NULL(), nil
+}
+
+// stringify(value[, indent]) encodes a value as JSON. With an indent, which
+// is a number of spaces or a string, the output is pretty-printed with one
+// element per line. Cyclic lists and tables result in an error.
+func Z_json_stringify(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 && len(args) != 2 {
+		return &val.ZError{Message: "stringify() takes 1 or 2 arguments"}
+	}
+
+	var buf bytes.Buffer
+	if err := encodeJSON(&buf, args[0], map[val.ZValue]bool{}); err != nil {
+		return &val.ZError{Message: "stringify() " + err.Error()}
+	}
+	if len(args) == 1 {
+		return val.STRING(buf.String())
+	}
+
+	var indent string
+	switch arg := args[1].(type) {
+	case *val.ZInt:
+		if arg.Value < 0 {
+			return &val.ZError{Message: "stringify() takes a non-negative indent"}
+		}
+		indent = strings.Repeat(" ", int(arg.Value))
+	case *val.ZString:
+		indent = arg.Value
+	default:
+		return &val.ZError{Message: "stringify() takes an indent as a number of spaces or a string"}
+	}
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, buf.Bytes(), "", indent); err != nil {
+		return &val.ZError{Message: "stringify() " + err.Error()}
+	}
+	return val.STRING(pretty.String())
+}
+
+// Writes the JSON encoding of a value. The lists and tables being encoded are
+// kept in visiting, to detect cycles.
+func encodeJSON(buf *bytes.Buffer, v val.ZValue, visiting map[val.ZValue]bool) error {
+	switch v := v.(type) {
+	case *val.ZNull:
+		buf.WriteString("null")
+	case *val.ZBool, *val.ZInt, *val.ZBigInt, *val.ZDecimal:
+		buf.WriteString(v.Str())
+	case *val.ZFloat:
+		if math.IsNaN(v.Value) || math.IsInf(v.Value, 0) {
+			return fmt.Errorf("cannot encode %v as JSON", v.Value)
+		}
+		// Floats keep a fraction or an exponent, so that they are decoded
+		// as floats again
+		text := strconv.FormatFloat(v.Value, 'g', -1, 64)
+		if !strings.ContainsAny(text, ".e") {
+			text += ".0"
+		}
+		buf.WriteString(text)
+	case *val.ZString:
+		encodeJSONString(buf, v.Value)
+	case *val.ZList:
+		if visiting[v] {
+			return errors.New("cannot encode a list that contains itself")
+		}
+		visiting[v] = true
+		defer delete(visiting, v)
+
+		buf.WriteByte('[')
+		for i, e := range v.Elements {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeJSON(buf, e, visiting); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case *val.ZTable:
+		if visiting[v] {
+			return errors.New("cannot encode a table that contains itself")
+		}
+		visiting[v] = true
+		defer delete(visiting, v)
+
+		buf.WriteByte('{')
+		for i, key := range v.Keys() {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			encodeJSONString(buf, key)
+			buf.WriteByte(':')
+			value, _ := v.Get(key)
+			if err := encodeJSON(buf, value, visiting); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case *val.ZTensor:
+		return encodeJSON(buf, v.ToList(), visiting)
+	default:
+		return fmt.Errorf("cannot encode %s as JSON", v.Type())
+	}
+	return nil
+}
+
+func encodeJSONString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	// Encode ends the value with a newline
+	buf.Truncate(buf.Len() - 1)
+}
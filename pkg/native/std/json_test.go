@@ -0,0 +1,117 @@
+package std_test
+
+import (
+	"testing"
+
+	"github.com/ariaghora/zmol/pkg/native/std"
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+func TestJSONParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`json = import("json")
+		json.parse("{\"b\": 1, \"a\": [true, null, \"x\"]}")`, `{b: 1, a: [true, , x]}`},
+		{`json = import("json")
+		json.parse("123456789012345678901234567890") + 1`, "123456789012345678901234567891"},
+		{`json = import("json")
+		json.parse(" \n {} \n ")`, "{}"},
+		{`json = import("json")
+		json.parse("[]")`, "[]"},
+		{`json = import("json")
+		json.parse("\"caf\\u00e9\"")`, "café"},
+		{`json = import("json")
+		json.parse("")`, "ERROR: parse() unexpected end of JSON input at line 1, column 1"},
+		{`json = import("json")
+		json.parse("{\n  \"a\": 1,\n  \"b\": tru\n}")`, "ERROR: parse() invalid character '\\n' in literal true (expecting 'e') at line 3, column 11"},
+		{`json = import("json")
+		json.parse("[1, 2")`, "ERROR: parse() unexpected end of JSON input at line 1, column 6"},
+		{`json = import("json")
+		json.parse("{\"a\": tr")`, "ERROR: parse() unexpected end of JSON input at line 1, column 9"},
+		{`json = import("json")
+		json.parse("[1, 2x")`, "ERROR: parse() invalid character 'x' after array element at line 1, column 6"},
+		{`json = import("json")
+		json.parse("{\"a\": 1}\n x")`, "ERROR: parse() unexpected data after the JSON value at line 2, column 2"},
+		{`json = import("json")
+		json.parse("1e999")`, "ERROR: parse() number 1e999 is out of range at line 1, column 6"},
+		{`json = import("json")
+		json.parse(1)`, "ERROR: parse() takes a string as argument 1"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, tt.input, tt.expected)
+	}
+}
+
+func TestJSONNumbers(t *testing.T) {
+	parsed := std.Z_json_parse(val.STRING("[1, -0, 1.5, 2.0, 1e2, 9223372036854775807, 9223372036854775808, -12345678901234567890]"))
+	expected := []val.ZValueType{val.ZINT, val.ZINT, val.ZFLOAT, val.ZFLOAT, val.ZFLOAT, val.ZINT, val.ZBIGINT, val.ZBIGINT}
+	for i, e := range parsed.(*val.ZList).Elements {
+		if e.Type() != expected[i] {
+			t.Errorf("element %d: got=%s %s, want=%s", i, e.Type(), e.Str(), expected[i])
+		}
+	}
+}
+
+func TestJSONStringify(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`json = import("json")
+		json.stringify(table([["b", 1], ["a", [true, 0.5, "x\"y"]]]))`, `{"b":1,"a":[true,0.5,"x\"y"]}`},
+		{`json = import("json")
+		r = [1.0, 0.1, 1.0 / 3, 100000000000000000000.0]
+		json.stringify(r)`, "[1.0,0.1,0.3333333333333333,1e+20]"},
+		{`json = import("json")
+		json.stringify(2 ** 100)`, "1267650600228229401496703205376"},
+		{`json = import("json")
+		json.stringify(1.25d)`, "1.25"},
+		{`json = import("json")
+		json.stringify("<a & b>")`, `"<a & b>"`},
+		{`json = import("json")
+		json.stringify(table([["a", [1, 2]], ["b", table()]]), 2)`, "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": {}\n}"},
+		{`json = import("json")
+		json.stringify([1], "\t")`, "[\n\t1\n]"},
+		{`json = import("json")
+		json.stringify([1], -1)`, "ERROR: stringify() takes a non-negative indent"},
+		{`json = import("json")
+		json.stringify([1], 1.5)`, "ERROR: stringify() takes an indent as a number of spaces or a string"},
+		{`json = import("json")
+		t = table()
+		t.self = t
+		json.stringify(t)`, "ERROR: stringify() cannot encode a table that contains itself"},
+		{`json = import("json")
+		json.stringify(@(x) { x })`, "ERROR: stringify() cannot encode Function as JSON"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, tt.input, tt.expected)
+	}
+}
+
+func TestJSONStringifyCycles(t *testing.T) {
+	// A list that contains itself cannot be encoded, but a list that appears
+	// twice can
+	cyclic := &val.ZList{Elements: []val.ZValue{val.INT(1)}}
+	cyclic.Elements = append(cyclic.Elements, cyclic)
+	if got := std.Z_json_stringify(cyclic).Str(); got != "ERROR: stringify() cannot encode a list that contains itself" {
+		t.Errorf("cyclic list: got=%s", got)
+	}
+
+	shared := &val.ZList{Elements: []val.ZValue{val.INT(1)}}
+	twice := &val.ZList{Elements: []val.ZValue{shared, shared}}
+	if got := std.Z_json_stringify(twice).Str(); got != "[[1],[1]]" {
+		t.Errorf("shared list: got=%s", got)
+	}
+}
+
+// Values that JSON can represent are decoded to the values they were encoded
+// from.
+func TestJSONRoundTrip(t *testing.T) {
+	testScript(t, `json = import("json")
+	v = table([["n", 3], ["x", 0.1], ["big", 2 ** 70], ["s", "line\nbreak"], ["l", [false, table()]]])
+	json.stringify(json.parse(json.stringify(v)))`, `{"n":3,"x":0.1,"big":1180591620717411303424,"s":"line\nbreak","l":[false,{}]}`)
+}
@@ -0,0 +1,289 @@
+package std
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ariaghora/zmol/pkg/val"
+	"gonum.org/v1/gonum/mat"
+	"gorgonia.org/tensor"
+)
+
+// Linear algebra routines, available as `tensor.linalg`. They are computed
+// with gonum in float64, and return float64 tensors.
+var LinalgModule = val.MODULE(
+	"linalg",
+	&val.Env{
+		SymTable: map[string]val.ZValue{
+			// Products
+			"dot":    &val.ZNativeFunc{Fn: Z_tensor_matmul},
+			"matmul": &val.ZNativeFunc{Fn: Z_tensor_matmul},
+			// Inverse and systems of equations
+			"inv":   &val.ZNativeFunc{Fn: Z_linalg_inv},
+			"det":   &val.ZNativeFunc{Fn: Z_linalg_det},
+			"solve": &val.ZNativeFunc{Fn: Z_linalg_solve},
+			// Decompositions
+			"qr":   &val.ZNativeFunc{Fn: Z_linalg_qr},
+			"svd":  &val.ZNativeFunc{Fn: Z_linalg_svd},
+			"eigh": &val.ZNativeFunc{Fn: Z_linalg_eigh},
+			// Norms
+			"norm": &val.ZNativeFunc{Fn: Z_linalg_norm},
+		},
+	},
+)
+
+// Returns the matrix argument at position i as a gonum matrix.
+func matrixArg(name string, args []val.ZValue, i int) (*mat.Dense, *val.ZError) {
+	t, zErr := tensorArg(name, args, i)
+	if zErr != nil {
+		return nil, zErr
+	}
+	shape := t.Shape()
+	if shape.Dims() != 2 {
+		return nil, &val.ZError{Message: fmt.Sprintf("%s() takes a matrix, got shape %v", name, shape)}
+	}
+	data := t.AsType(tensor.Float64).Dense().Data().([]float64)
+	return mat.NewDense(shape[0], shape[1], append([]float64{}, data...)), nil
+}
+
+// Returns the square matrix argument at position i as a gonum matrix.
+func squareMatrixArg(name string, args []val.ZValue, i int) (*mat.Dense, *val.ZError) {
+	m, zErr := matrixArg(name, args, i)
+	if zErr != nil {
+		return nil, zErr
+	}
+	if r, c := m.Dims(); r != c {
+		return nil, &val.ZError{Message: fmt.Sprintf("%s() takes a square matrix, got shape (%d, %d)", name, r, c)}
+	}
+	return m, nil
+}
+
+func fromMatrix(m mat.Matrix) *val.ZTensor {
+	r, c := m.Dims()
+	return val.TENSOR(tensor.New(tensor.WithShape(r, c), tensor.WithBacking(mat.DenseCopyOf(m).RawMatrix().Data)))
+}
+
+func fromVector(data []float64) *val.ZTensor {
+	return val.TENSOR(tensor.New(tensor.WithShape(len(data)), tensor.WithBacking(data)))
+}
+
+// Returns an error value for a failed routine. Gonum reports singular
+// matrices with a Condition error.
+func linalgError(name string, err error) *val.ZError {
+	var cond mat.Condition
+	if errors.As(err, &cond) {
+		return &val.ZError{Message: name + "() matrix is singular"}
+	}
+	return &val.ZError{Message: name + "() " + err.Error()}
+}
+
+// inv(a) returns the inverse of a square matrix.
+func Z_linalg_inv(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "inv() takes 1 argument"}
+	}
+	a, zErr := squareMatrixArg("inv", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	var inv mat.Dense
+	if err := inv.Inverse(a); err != nil {
+		return linalgError("inv", err)
+	}
+	return fromMatrix(&inv)
+}
+
+// det(a) returns the determinant of a square matrix.
+func Z_linalg_det(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "det() takes 1 argument"}
+	}
+	a, zErr := squareMatrixArg("det", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	return val.FLOAT(mat.Det(a))
+}
+
+// solve(a, b) returns x such that a x = b, where b is a vector or a matrix.
+// If a has more rows than columns, x is the least squares solution.
+func Z_linalg_solve(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: "solve() takes 2 arguments"}
+	}
+	a, zErr := matrixArg("solve", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	t, zErr := tensorArg("solve", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+
+	// A vector is solved as a matrix with one column
+	isVector := t.Shape().Dims() == 1
+	if isVector {
+		t = val.TENSOR(tensor.New(tensor.WithShape(t.Shape()[0], 1), tensor.WithBacking(t.AsType(tensor.Float64).Dense().Data())))
+	}
+	b, zErr := matrixArg("solve", []val.ZValue{t}, 0)
+	if zErr != nil {
+		return zErr
+	}
+
+	ar, ac := a.Dims()
+	if br, _ := b.Dims(); ar != br {
+		return &val.ZError{Message: fmt.Sprintf("solve() cannot solve shapes (%d, %d) and %v", ar, ac, args[1].(*val.ZTensor).Shape())}
+	}
+	if ar < ac {
+		return &val.ZError{Message: fmt.Sprintf("solve() takes a matrix with at least as many rows as columns, got shape (%d, %d)", ar, ac)}
+	}
+
+	var x mat.Dense
+	if err := x.Solve(a, b); err != nil {
+		return linalgError("solve", err)
+	}
+	if isVector {
+		return fromVector(mat.Col(nil, 0, &x))
+	}
+	return fromMatrix(&x)
+}
+
+// qr(a) returns [q, r], where q is orthogonal and r upper triangular, and
+// a = q r. The matrix must have at least as many rows as columns.
+func Z_linalg_qr(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "qr() takes 1 argument"}
+	}
+	a, zErr := matrixArg("qr", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	if r, c := a.Dims(); r < c {
+		return &val.ZError{Message: fmt.Sprintf("qr() takes a matrix with at least as many rows as columns, got shape (%d, %d)", r, c)}
+	}
+
+	var qr mat.QR
+	qr.Factorize(a)
+	var q, r mat.Dense
+	qr.QTo(&q)
+	qr.RTo(&r)
+	return &val.ZList{Elements: []val.ZValue{fromMatrix(&q), fromMatrix(&r)}}
+}
+
+// svd(a) returns [u, s, vt], where s is the vector of singular values in
+// descending order, and a = u diag(s) vt.
+func Z_linalg_svd(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "svd() takes 1 argument"}
+	}
+	a, zErr := matrixArg("svd", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+
+	var svd mat.SVD
+	if !svd.Factorize(a, mat.SVDThin) {
+		return &val.ZError{Message: "svd() did not converge"}
+	}
+	var u, v mat.Dense
+	svd.UTo(&u)
+	svd.VTo(&v)
+	return &val.ZList{Elements: []val.ZValue{
+		fromMatrix(&u), fromVector(svd.Values(nil)), fromMatrix(v.T()),
+	}}
+}
+
+// eigh(a) returns [values, vectors] of a symmetric matrix. The eigenvalues
+// are in ascending order, and the columns of vectors are the eigenvectors.
+func Z_linalg_eigh(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "eigh() takes 1 argument"}
+	}
+	a, zErr := squareMatrixArg("eigh", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+
+	n, _ := a.Dims()
+	sym := mat.NewSymDense(n, nil)
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			if math.Abs(a.At(i, j)-a.At(j, i)) > 1e-10*math.Max(1, math.Abs(a.At(i, j))) {
+				return &val.ZError{Message: "eigh() takes a symmetric matrix"}
+			}
+			sym.SetSym(i, j, a.At(i, j))
+		}
+	}
+
+	var eig mat.EigenSym
+	if !eig.Factorize(sym, true) {
+		return &val.ZError{Message: "eigh() did not converge"}
+	}
+	var vectors mat.Dense
+	eig.VectorsTo(&vectors)
+	return &val.ZList{Elements: []val.ZValue{fromVector(eig.Values(nil)), fromMatrix(&vectors)}}
+}
+
+// norm(t) returns the Euclidean norm of a vector, or the Frobenius norm of a
+// matrix. norm(t, ord) takes the order 1, 2 or "inf". For matrices, these are
+// the maximum absolute column sum, the largest singular value, and the
+// maximum absolute row sum.
+func Z_linalg_norm(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 && len(args) != 2 {
+		return &val.ZError{Message: "norm() takes 1 or 2 arguments"}
+	}
+	t, zErr := tensorArg("norm", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+
+	ord := 2.0
+	if len(args) == 2 {
+		invalid := &val.ZError{Message: "norm() takes an order of 1, 2 or \"inf\""}
+		switch arg := args[1].(type) {
+		case *val.ZInt:
+			if arg.Value != 1 && arg.Value != 2 {
+				return invalid
+			}
+			ord = float64(arg.Value)
+		case *val.ZString:
+			if arg.Value != "inf" {
+				return invalid
+			}
+			ord = math.Inf(1)
+		default:
+			return invalid
+		}
+	}
+	data := t.AsType(tensor.Float64).Dense().Data().([]float64)
+
+	// Vectors, and the Frobenius norm of all other shapes
+	if t.Shape().Dims() != 2 || len(args) == 1 {
+		norm := 0.0
+		for _, x := range data {
+			switch {
+			case ord == 1:
+				norm += math.Abs(x)
+			case ord == 2:
+				norm += x * x
+			default:
+				norm = math.Max(norm, math.Abs(x))
+			}
+		}
+		if ord == 2 {
+			norm = math.Sqrt(norm)
+		}
+		return val.FLOAT(norm)
+	}
+
+	a, _ := matrixArg("norm", args, 0)
+	if ord == 2 {
+		var svd mat.SVD
+		if !svd.Factorize(a, mat.SVDNone) {
+			return &val.ZError{Message: "norm() did not converge"}
+		}
+		return val.FLOAT(svd.Values(nil)[0])
+	}
+	return val.FLOAT(mat.Norm(a, ord))
+}
@@ -0,0 +1,204 @@
+package std_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ariaghora/zmol/pkg/native/std"
+	"github.com/ariaghora/zmol/pkg/val"
+	"gonum.org/v1/gonum/mat"
+	"gorgonia.org/tensor"
+)
+
+func matrix(r, c int, data ...float64) *val.ZTensor {
+	return val.TENSOR(tensor.New(tensor.WithShape(r, c), tensor.WithBacking(data)))
+}
+
+func vector(data ...float64) *val.ZTensor {
+	return val.TENSOR(tensor.New(tensor.WithShape(len(data)), tensor.WithBacking(data)))
+}
+
+// Returns a tensor result as a gonum matrix, with vectors as one column.
+func toMatrix(t *testing.T, v val.ZValue) *mat.Dense {
+	t.Helper()
+	z, ok := v.(*val.ZTensor)
+	if !ok {
+		t.Fatalf("expected a tensor, got=%s", v.Str())
+	}
+	shape := z.Shape()
+	data := z.AsType(tensor.Float64).Dense().Data().([]float64)
+	if shape.Dims() == 1 {
+		return mat.NewDense(shape[0], 1, data)
+	}
+	return mat.NewDense(shape[0], shape[1], data)
+}
+
+func assertMatrix(t *testing.T, name string, got mat.Matrix, want mat.Matrix) {
+	t.Helper()
+	if !mat.EqualApprox(got, want, 1e-9) {
+		t.Errorf("%s: got=%v, want=%v", name, mat.Formatted(got), mat.Formatted(want))
+	}
+}
+
+func assertError(t *testing.T, name string, got val.ZValue, want string) {
+	t.Helper()
+	if got.Type() != val.ZERROR || got.Str() != "ERROR: "+want {
+		t.Errorf("%s: got=%s, want=ERROR: %s", name, got.Str(), want)
+	}
+}
+
+var (
+	square   = matrix(3, 3, 4, 1, 2, 0, 3, 1, 1, 0, 2)
+	tall     = matrix(3, 2, 1, 2, 3, 4, 5, 6)
+	symm     = matrix(3, 3, 2, 1, 0, 1, 2, 1, 0, 1, 2)
+	singular = matrix(2, 2, 1, 2, 2, 4)
+)
+
+func TestLinalgInv(t *testing.T) {
+	a := toMatrix(t, square)
+	var product mat.Dense
+	product.Mul(a, toMatrix(t, std.Z_linalg_inv(square)))
+	assertMatrix(t, "inv(a) a", &product, mat.NewDiagDense(3, []float64{1, 1, 1}))
+
+	assertError(t, "inv(singular)", std.Z_linalg_inv(singular), "inv() matrix is singular")
+	assertError(t, "inv(tall)", std.Z_linalg_inv(tall), "inv() takes a square matrix, got shape (3, 2)")
+	assertError(t, "inv(vector)", std.Z_linalg_inv(vector(1, 2)), "inv() takes a matrix, got shape (2)")
+	assertError(t, "inv()", std.Z_linalg_inv(), "inv() takes 1 argument")
+}
+
+func TestLinalgDet(t *testing.T) {
+	tests := []struct {
+		a        *val.ZTensor
+		expected float64
+	}{
+		{square, 19},
+		{singular, 0},
+		{matrix(1, 1, -3), -3},
+		{symm, 4},
+	}
+
+	for _, tt := range tests {
+		got, ok := std.Z_linalg_det(tt.a).(*val.ZFloat)
+		if !ok || math.Abs(got.Value-tt.expected) > 1e-9 {
+			t.Errorf("det(%s): got=%v, want=%v", tt.a.Str(), got, tt.expected)
+		}
+	}
+	assertError(t, "det(tall)", std.Z_linalg_det(tall), "det() takes a square matrix, got shape (3, 2)")
+}
+
+func TestLinalgSolve(t *testing.T) {
+	// Vectors give vectors, matrices give matrices
+	x := std.Z_linalg_solve(square, vector(12, 9, 7))
+	if shape := x.(*val.ZTensor).Shape(); shape.Dims() != 1 {
+		t.Errorf("solve() with a vector: got shape %v", shape)
+	}
+	assertMatrix(t, "solve(a, b)", toMatrix(t, x), mat.NewDense(3, 1, []float64{1, 2, 3}))
+
+	b := matrix(3, 2, 12, 4, 9, 0, 7, 1)
+	assertMatrix(t, "solve(a, B)", toMatrix(t, std.Z_linalg_solve(square, b)), mat.NewDense(3, 2, []float64{1, 1, 2, 0, 3, 0}))
+
+	// Least squares fit of a line through (1, 2), (2, 3), (3, 4.5)
+	design := matrix(3, 2, 1, 1, 1, 2, 1, 3)
+	fit := toMatrix(t, std.Z_linalg_solve(design, vector(2, 3, 4.5)))
+	assertMatrix(t, "least squares", fit, mat.NewDense(2, 1, []float64{2.0 / 3, 1.25}))
+
+	assertError(t, "solve(singular)", std.Z_linalg_solve(singular, vector(1, 2)), "solve() matrix is singular")
+	assertError(t, "solve(shapes)", std.Z_linalg_solve(square, vector(1, 2)), "solve() cannot solve shapes (3, 3) and (2)")
+	assertError(t, "solve(wide)", std.Z_linalg_solve(matrix(1, 2, 1, 2), vector(1)), "solve() takes a matrix with at least as many rows as columns, got shape (1, 2)")
+	assertError(t, "solve(a)", std.Z_linalg_solve(square), "solve() takes 2 arguments")
+}
+
+func TestLinalgQR(t *testing.T) {
+	for _, a := range []*val.ZTensor{square, tall} {
+		qr := std.Z_linalg_qr(a).(*val.ZList).Elements
+		q, r := toMatrix(t, qr[0]), toMatrix(t, qr[1])
+
+		var product, qtq mat.Dense
+		product.Mul(q, r)
+		assertMatrix(t, "q r", &product, toMatrix(t, a))
+		qtq.Mul(q.T(), q)
+		_, c := q.Dims()
+		assertMatrix(t, "qt q", &qtq, mat.NewDiagDense(c, onesOf(c)))
+		rr, rc := r.Dims()
+		for i := 0; i < rr; i++ {
+			for j := 0; j < i && j < rc; j++ {
+				if math.Abs(r.At(i, j)) > 1e-9 {
+					t.Errorf("r is not upper triangular: %v", mat.Formatted(r))
+				}
+			}
+		}
+	}
+	assertError(t, "qr(wide)", std.Z_linalg_qr(matrix(1, 2, 1, 2)), "qr() takes a matrix with at least as many rows as columns, got shape (1, 2)")
+}
+
+func TestLinalgSVD(t *testing.T) {
+	for _, a := range []*val.ZTensor{square, tall, singular} {
+		usv := std.Z_linalg_svd(a).(*val.ZList).Elements
+		u, s, vt := toMatrix(t, usv[0]), toMatrix(t, usv[1]), toMatrix(t, usv[2])
+
+		values := mat.Col(nil, 0, s)
+		for i := 1; i < len(values); i++ {
+			if values[i] > values[i-1] {
+				t.Errorf("singular values are not in descending order: %v", values)
+			}
+		}
+		var us, product mat.Dense
+		us.Mul(u, mat.NewDiagDense(len(values), values))
+		product.Mul(&us, vt)
+		assertMatrix(t, "u diag(s) vt", &product, toMatrix(t, a))
+	}
+	assertError(t, "svd(vector)", std.Z_linalg_svd(vector(1, 2)), "svd() takes a matrix, got shape (2)")
+}
+
+func TestLinalgEigh(t *testing.T) {
+	result := std.Z_linalg_eigh(symm).(*val.ZList).Elements
+	values, vectors := toMatrix(t, result[0]), toMatrix(t, result[1])
+	assertMatrix(t, "eigenvalues", values, mat.NewDense(3, 1, []float64{2 - math.Sqrt2, 2, 2 + math.Sqrt2}))
+
+	var av, vl mat.Dense
+	av.Mul(toMatrix(t, symm), vectors)
+	vl.Mul(vectors, mat.NewDiagDense(3, mat.Col(nil, 0, values)))
+	assertMatrix(t, "a v = v diag(values)", &av, &vl)
+
+	assertError(t, "eigh(square)", std.Z_linalg_eigh(square), "eigh() takes a symmetric matrix")
+	assertError(t, "eigh(tall)", std.Z_linalg_eigh(tall), "eigh() takes a square matrix, got shape (3, 2)")
+}
+
+func TestLinalgNorm(t *testing.T) {
+	m := matrix(2, 2, 1, -2, 3, 4)
+	tests := []struct {
+		args     []val.ZValue
+		expected float64
+	}{
+		{[]val.ZValue{vector(3, -4)}, 5},
+		{[]val.ZValue{vector(3, -4), val.INT(1)}, 7},
+		{[]val.ZValue{vector(3, -4), val.INT(2)}, 5},
+		{[]val.ZValue{vector(3, -4), val.STRING("inf")}, 4},
+		{[]val.ZValue{m}, math.Sqrt(30)},
+		{[]val.ZValue{m, val.INT(1)}, 6},
+		{[]val.ZValue{m, val.STRING("inf")}, 7},
+		{[]val.ZValue{matrix(2, 2, 3, 0, 0, 4), val.INT(2)}, 4},
+	}
+
+	for _, tt := range tests {
+		got, ok := std.Z_linalg_norm(tt.args...).(*val.ZFloat)
+		if !ok || math.Abs(got.Value-tt.expected) > 1e-9 {
+			t.Errorf("norm(%s, ...): got=%v, want=%v", tt.args[0].Str(), got, tt.expected)
+		}
+	}
+
+	invalid := []val.ZValue{val.INT(3), val.INT(0), val.STRING("bogus"), val.STRING("fro"), val.FLOAT(1.0), val.BOOL(true)}
+	for _, ord := range invalid {
+		assertError(t, "norm(v, "+ord.Str()+")", std.Z_linalg_norm(vector(3, -4), ord), `norm() takes an order of 1, 2 or "inf"`)
+		assertError(t, "norm(m, "+ord.Str()+")", std.Z_linalg_norm(m, ord), `norm() takes an order of 1, 2 or "inf"`)
+	}
+	assertError(t, "norm()", std.Z_linalg_norm(), "norm() takes 1 or 2 arguments")
+}
+
+func onesOf(n int) []float64 {
+	ones := make([]float64, n)
+	for i := range ones {
+		ones[i] = 1
+	}
+	return ones
+}
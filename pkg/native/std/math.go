@@ -2,8 +2,12 @@ package std
 
 import (
 	"errors"
+	"fmt"
 	"math"
+	"math/big"
+	"strconv"
 
+	"github.com/ariaghora/zmol/pkg/eval"
 	"github.com/ariaghora/zmol/pkg/val"
 )
 
@@ -13,10 +17,12 @@ var MathModule = val.MODULE(
 	&val.Env{
 		SymTable: map[string]val.ZValue{
 			// Constants
-			"PI": val.FLOAT(math.Pi),
-			"E":  val.FLOAT(math.E),
+			"PI":  val.FLOAT(math.Pi),
+			"TAU": val.FLOAT(2 * math.Pi),
+			"E":   val.FLOAT(math.E),
+			"INF": val.FLOAT(math.Inf(1)),
+			"NAN": val.FLOAT(math.NaN()),
 			// Unary functions
-			"abs":   Z_floatUfunc("abs", math.Abs),
 			"acos":  Z_floatUfunc("acos", math.Acos),
 			"asin":  Z_floatUfunc("asin", math.Asin),
 			"atan":  Z_floatUfunc("atan", math.Atan),
@@ -28,8 +34,28 @@ var MathModule = val.MODULE(
 			"sin":   Z_floatUfunc("sin", math.Sin),
 			"sqrt":  Z_floatUfunc("sqrt", math.Sqrt),
 			"tan":   Z_floatUfunc("tan", math.Tan),
-			// More functions
-			// ...
+			// Binary functions
+			"atan2": Z_floatBfunc("atan2", math.Atan2),
+			"hypot": Z_floatBfunc("hypot", math.Hypot),
+			"pow":   &val.ZNativeFunc{Fn: Z_math_pow},
+			// Comparison
+			"min":   &val.ZNativeFunc{Fn: Z_math_min},
+			"max":   &val.ZNativeFunc{Fn: Z_math_max},
+			"clamp": &val.ZNativeFunc{Fn: Z_math_clamp},
+			// Rounding, which keep integers and decimals
+			"abs":   &val.ZNativeFunc{Fn: Z_math_abs},
+			"floor": Z_roundingFunc("floor", math.Floor, val.RoundFloor),
+			"ceil":  Z_roundingFunc("ceil", math.Ceil, val.RoundCeiling),
+			"trunc": Z_roundingFunc("trunc", math.Trunc, val.RoundDown),
+			"round": &val.ZNativeFunc{Fn: Z_math_round},
+			// Integer functions
+			"gcd":       &val.ZNativeFunc{Fn: Z_math_gcd},
+			"lcm":       &val.ZNativeFunc{Fn: Z_math_lcm},
+			"factorial": &val.ZNativeFunc{Fn: Z_math_factorial},
+			"isqrt":     &val.ZNativeFunc{Fn: Z_math_isqrt},
+			// Classification
+			"is_nan": Z_floatPredicate("is_nan", math.IsNaN),
+			"is_inf": Z_floatPredicate("is_inf", func(x float64) bool { return math.IsInf(x, 0) }),
 		},
 	},
 )
@@ -37,6 +63,10 @@ var MathModule = val.MODULE(
 func EnsureFloat(n val.ZValue) (float64, error) {
 	if n.Type() == val.ZINT {
 		return float64(n.(*val.ZInt).Value), nil
+	} else if n.Type() == val.ZBIGINT {
+		return n.(*val.ZBigInt).Float(), nil
+	} else if n.Type() == val.ZDECIMAL {
+		return n.(*val.ZDecimal).Float(), nil
 	} else if n.Type() == val.ZFLOAT {
 		return n.(*val.ZFloat).Value, nil
 	} else {
@@ -47,6 +77,11 @@ func EnsureFloat(n val.ZValue) (float64, error) {
 func EnsureInt(n val.ZValue) (int64, error) {
 	if n.Type() == val.ZINT {
 		return n.(*val.ZInt).Value, nil
+	} else if n.Type() == val.ZBIGINT {
+		if !n.(*val.ZBigInt).Value.IsInt64() {
+			return 0, errors.New("integer too large")
+		}
+		return n.(*val.ZBigInt).Value.Int64(), nil
 	} else if n.Type() == val.ZFLOAT {
 		return int64(n.(*val.ZFloat).Value), nil
 	}
@@ -72,3 +107,313 @@ func Z_floatUfunc(name string, fn func(float64) float64) val.ZValue {
 		},
 	}
 }
+
+// Creates a function that takes two float arguments and returns a float.
+func Z_floatBfunc(name string, fn func(float64, float64) float64) val.ZValue {
+	return &val.ZNativeFunc{
+		Fn: func(args ...val.ZValue) val.ZValue {
+			if len(args) != 2 {
+				return &val.ZError{Message: name + "() takes exactly 2 arguments"}
+			}
+			x, zErr := floatArg(name, args, 0)
+			if zErr != nil {
+				return zErr
+			}
+			y, zErr := floatArg(name, args, 1)
+			if zErr != nil {
+				return zErr
+			}
+			return val.FLOAT(fn(x, y))
+		},
+	}
+}
+
+func isNumber(v val.ZValue) bool {
+	_, err := EnsureFloat(v)
+	return err == nil
+}
+
+// Returns the arguments as numbers. Functions taking any number of arguments
+// also accept a single list.
+func numberArgs(name string, args []val.ZValue) ([]val.ZValue, *val.ZError) {
+	if len(args) == 1 && (args[0].Type() == val.ZLIST || args[0].Type() == val.ZITERATOR) {
+		elements, zErr := listArg(name, args[0])
+		if zErr != nil {
+			return nil, zErr
+		}
+		args = elements
+	}
+	if len(args) == 0 {
+		return nil, &val.ZError{Message: name + "() takes at least 1 number"}
+	}
+	for i, arg := range args {
+		if !isNumber(arg) {
+			return nil, &val.ZError{Message: fmt.Sprintf("%s() takes numbers, got %s as argument %d", name, arg.Type(), i+1)}
+		}
+	}
+	return args, nil
+}
+
+// pow(x, y) returns x raised to the power y, as the `**` operator. Integers
+// with a non-negative exponent give an integer.
+func Z_math_pow(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: "pow() takes exactly 2 arguments"}
+	}
+	if !isNumber(args[0]) || !isNumber(args[1]) {
+		return &val.ZError{Message: "pow() takes numbers as arguments"}
+	}
+	return eval.EvalInfix("**", args[0], args[1])
+}
+
+// Returns the smallest (sign -1) or largest (sign 1) argument, as it is.
+func numberExtremum(name string, sign int, args []val.ZValue) val.ZValue {
+	args, zErr := numberArgs(name, args)
+	if zErr != nil {
+		return zErr
+	}
+	result := args[0]
+	for _, arg := range args[1:] {
+		if cmp, _ := compareValues(arg, result); cmp == sign {
+			result = arg
+		}
+	}
+	return result
+}
+
+// min(a, b, ...) or min(list) returns the smallest number.
+func Z_math_min(args ...val.ZValue) val.ZValue {
+	return numberExtremum("min", -1, args)
+}
+
+// max(a, b, ...) or max(list) returns the largest number.
+func Z_math_max(args ...val.ZValue) val.ZValue {
+	return numberExtremum("max", 1, args)
+}
+
+// clamp(x, low, high) returns x limited to the range [low, high].
+func Z_math_clamp(args ...val.ZValue) val.ZValue {
+	if len(args) != 3 {
+		return &val.ZError{Message: "clamp() takes exactly 3 arguments"}
+	}
+	if _, zErr := numberArgs("clamp", args); zErr != nil {
+		return zErr
+	}
+	x, low, high := args[0], args[1], args[2]
+	if cmp, _ := compareValues(low, high); cmp > 0 {
+		return &val.ZError{Message: fmt.Sprintf("clamp() takes low <= high, got %s and %s", low.Str(), high.Str())}
+	}
+	if cmp, _ := compareValues(x, low); cmp < 0 {
+		return low
+	}
+	if cmp, _ := compareValues(x, high); cmp > 0 {
+		return high
+	}
+	return x
+}
+
+// abs(x) returns the absolute value, of the same type as x.
+func Z_math_abs(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "abs() takes exactly 1 argument"}
+	}
+	switch x := args[0].(type) {
+	case *val.ZInt, *val.ZBigInt:
+		n, _ := val.ToBigInt(x)
+		return val.INTEGER(new(big.Int).Abs(n))
+	case *val.ZDecimal:
+		if x.Rat().Sign() < 0 {
+			return x.Neg()
+		}
+		return x
+	case *val.ZFloat:
+		return val.FLOAT(math.Abs(x.Value))
+	}
+	return &val.ZError{Message: "abs() takes a number as argument"}
+}
+
+// Creates a function that rounds a float with fn, rounds a decimal to an
+// integral decimal with mode, and returns integers as they are.
+func Z_roundingFunc(name string, fn func(float64) float64, mode val.RoundingMode) val.ZValue {
+	return &val.ZNativeFunc{
+		Fn: func(args ...val.ZValue) val.ZValue {
+			if len(args) != 1 {
+				return &val.ZError{Message: name + "() takes exactly 1 argument"}
+			}
+			switch x := args[0].(type) {
+			case *val.ZInt, *val.ZBigInt:
+				return x
+			case *val.ZDecimal:
+				return x.Round(0, mode)
+			case *val.ZFloat:
+				return val.FLOAT(fn(x.Value))
+			}
+			return &val.ZError{Message: name + "() takes a number as argument"}
+		},
+	}
+}
+
+// Rounds an integer to a multiple of 10^places, with halves away from zero.
+func roundInteger(n *big.Int, places int) *big.Int {
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)
+	quotient, remainder := new(big.Int).QuoRem(n, unit, new(big.Int))
+	twice := new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2))
+	if twice.Cmp(unit) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(n.Sign())))
+	}
+	return quotient.Mul(quotient, unit)
+}
+
+// round(x) rounds to the nearest integral value, and round(x, digits) to the
+// given number of fractional digits, which may be negative to round to tens,
+// hundreds, and so on, up to val.MaxDecimalScale digits either way. Halves
+// are rounded away from zero, and the result has the type of x.
+func Z_math_round(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 && len(args) != 2 {
+		return &val.ZError{Message: "round() takes 1 or 2 arguments"}
+	}
+	digits := 0
+	if len(args) == 2 {
+		if args[1].Type() != val.ZINT {
+			return &val.ZError{Message: "round() takes an integer number of digits"}
+		}
+		n := args[1].(*val.ZInt).Value
+		if n > val.MaxDecimalScale || n < -val.MaxDecimalScale {
+			return &val.ZError{Message: fmt.Sprintf("round() takes at most %d digits either way, got %d", val.MaxDecimalScale, n)}
+		}
+		digits = int(n)
+	}
+
+	switch x := args[0].(type) {
+	case *val.ZInt, *val.ZBigInt:
+		if digits >= 0 {
+			return x
+		}
+		n, _ := val.ToBigInt(x)
+		return val.INTEGER(roundInteger(n, -digits))
+	case *val.ZDecimal:
+		if digits >= 0 {
+			return x.Round(digits, val.RoundHalfUp)
+		}
+		// Truncating first does not change the result, as the remainder only
+		// decides ties, which are rounded away from zero anyway
+		return val.DECIMAL(roundInteger(x.Int(), -digits), 0)
+	case *val.ZFloat:
+		if math.IsInf(x.Value, 0) || math.IsNaN(x.Value) {
+			return x
+		}
+		if digits < 0 {
+			unit := math.Pow(10, float64(-digits))
+			return val.FLOAT(math.Round(x.Value/unit) * unit)
+		}
+		// Rounding the shortest decimal representation, as decimal() does,
+		// avoids the error of scaling by a power of ten, e.g., 2.675 is
+		// rounded to 2.68
+		d, _ := val.ParseDecimal(strconv.FormatFloat(x.Value, 'f', -1, 64))
+		f, _ := strconv.ParseFloat(d.Round(digits, val.RoundHalfUp).Str(), 64)
+		return val.FLOAT(f)
+	}
+	return &val.ZError{Message: "round() takes a number as argument"}
+}
+
+// Returns the arguments as integers. Functions taking any number of arguments
+// also accept a single list.
+func integerArgs(name string, args []val.ZValue) ([]*big.Int, *val.ZError) {
+	args, zErr := numberArgs(name, args)
+	if zErr != nil {
+		return nil, zErr
+	}
+	result := make([]*big.Int, len(args))
+	for i, arg := range args {
+		n, ok := val.ToBigInt(arg)
+		if !ok {
+			return nil, &val.ZError{Message: fmt.Sprintf("%s() takes integers, got %s as argument %d", name, arg.Type(), i+1)}
+		}
+		result[i] = n
+	}
+	return result, nil
+}
+
+// gcd(a, b, ...) or gcd(list) returns the greatest common divisor, which is
+// never negative.
+func Z_math_gcd(args ...val.ZValue) val.ZValue {
+	ns, zErr := integerArgs("gcd", args)
+	if zErr != nil {
+		return zErr
+	}
+	result := new(big.Int)
+	for _, n := range ns {
+		result.GCD(nil, nil, result, new(big.Int).Abs(n))
+	}
+	return val.INTEGER(result)
+}
+
+// lcm(a, b, ...) or lcm(list) returns the least common multiple, which is
+// never negative.
+func Z_math_lcm(args ...val.ZValue) val.ZValue {
+	ns, zErr := integerArgs("lcm", args)
+	if zErr != nil {
+		return zErr
+	}
+	result := big.NewInt(1)
+	for _, n := range ns {
+		if n.Sign() == 0 {
+			return val.INT(0)
+		}
+		gcd := new(big.Int).GCD(nil, nil, result, new(big.Int).Abs(n))
+		result.Mul(result, new(big.Int).Quo(new(big.Int).Abs(n), gcd))
+	}
+	return val.INTEGER(result)
+}
+
+// Returns the non-negative integer argument of a unary integer function.
+func naturalArg(name string, args []val.ZValue) (*big.Int, *val.ZError) {
+	if len(args) != 1 {
+		return nil, &val.ZError{Message: name + "() takes exactly 1 argument"}
+	}
+	n, ok := val.ToBigInt(args[0])
+	if !ok || n.Sign() < 0 {
+		return nil, &val.ZError{Message: name + "() takes a non-negative integer"}
+	}
+	return n, nil
+}
+
+// factorial(n) returns n!, which becomes a BigInt from 21!.
+func Z_math_factorial(args ...val.ZValue) val.ZValue {
+	n, zErr := naturalArg("factorial", args)
+	if zErr != nil {
+		return zErr
+	}
+	if !n.IsInt64() || n.Int64() > 100000 {
+		return &val.ZError{Message: "factorial() argument is too large"}
+	}
+	return val.INTEGER(new(big.Int).MulRange(1, n.Int64()))
+}
+
+// isqrt(n) returns the integer square root, the largest integer whose square
+// is at most n.
+func Z_math_isqrt(args ...val.ZValue) val.ZValue {
+	n, zErr := naturalArg("isqrt", args)
+	if zErr != nil {
+		return zErr
+	}
+	return val.INTEGER(new(big.Int).Sqrt(n))
+}
+
+// Creates a function that classifies a float. Other numbers are finite.
+func Z_floatPredicate(name string, fn func(float64) bool) val.ZValue {
+	return &val.ZNativeFunc{
+		Fn: func(args ...val.ZValue) val.ZValue {
+			if len(args) != 1 {
+				return &val.ZError{Message: name + "() takes exactly 1 argument"}
+			}
+			if !isNumber(args[0]) {
+				return &val.ZError{Message: name + "() takes a number as argument"}
+			}
+			if x, ok := args[0].(*val.ZFloat); ok {
+				return val.BOOL(fn(x.Value))
+			}
+			return val.BOOL(false)
+		},
+	}
+}
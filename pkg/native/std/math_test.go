@@ -0,0 +1,93 @@
+package std_test
+
+import "testing"
+
+// Imports the module for the inputs of the tests.
+const importMath = "math = import(\"math\")\n"
+
+func TestMathIntegers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`math.gcd(12, 18)`, "6"},
+		{`math.gcd(-12, 18, 27)`, "3"},
+		{`math.gcd([0, 0])`, "0"},
+		{`math.gcd(2 ** 70, 2 ** 65 * 3)`, "36893488147419103232"},
+		{`math.gcd(1.5, 3)`, "ERROR: gcd() takes integers, got Float as argument 1"},
+		{`math.gcd()`, "ERROR: gcd() takes at least 1 number"},
+		{`math.lcm(4, 6)`, "12"},
+		{`math.lcm([-4, 6, 10])`, "60"},
+		{`math.lcm(3, 0)`, "0"},
+		{`math.lcm(2, "a")`, "ERROR: lcm() takes numbers, got String as argument 2"},
+		{`math.factorial(0)`, "1"},
+		{`math.factorial(5)`, "120"},
+		{`math.factorial(20)`, "2432902008176640000"},
+		{`math.factorial(21)`, "51090942171709440000"},
+		{`math.factorial(-1)`, "ERROR: factorial() takes a non-negative integer"},
+		{`math.factorial(100001)`, "ERROR: factorial() argument is too large"},
+		{`math.isqrt(0)`, "0"},
+		{`math.isqrt(15)`, "3"},
+		{`math.isqrt(16)`, "4"},
+		{`math.isqrt(2 ** 100)`, "1125899906842624"},
+		{`math.isqrt(-4)`, "ERROR: isqrt() takes a non-negative integer"},
+		{`math.isqrt(2.0)`, "ERROR: isqrt() takes a non-negative integer"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, importMath+tt.input, tt.expected)
+	}
+}
+
+func TestMathKeepsTypes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`math.abs(-3)`, "3"},
+		{`math.abs(-3.5)`, "3.500000"},
+		{`math.abs(-3.25d)`, "3.25"},
+		{`math.abs(-(2 ** 70))`, "1180591620717411303424"},
+		{`math.abs("a")`, "ERROR: abs() takes a number as argument"},
+		{`math.min(3, 1.5, 2)`, "1.500000"},
+		{`math.min(3, 1, 2.5)`, "1"},
+		{`math.min([2.5d, 3])`, "2.5"},
+		{`math.max(1, 2 ** 70, 3.5)`, "1180591620717411303424"},
+		{`math.max(1.0, 2)`, "2"},
+		{`math.max([])`, "ERROR: max() takes at least 1 number"},
+		{`math.min(1, "a")`, "ERROR: min() takes numbers, got String as argument 2"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, importMath+tt.input, tt.expected)
+	}
+}
+
+func TestMathRound(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`math.round(2.5)`, "3.000000"},
+		{`math.round(-2.5)`, "-3.000000"},
+		{`math.round(2.675, 2)`, "2.680000"},
+		{`math.round(1234.5, -2)`, "1200.000000"},
+		{`math.round(7)`, "7"},
+		{`math.round(1250, -2)`, "1300"},
+		{`math.round(-1250, -2)`, "-1300"},
+		{`math.round(1249, -2)`, "1200"},
+		{`math.round(2.345d, 2)`, "2.35"},
+		{`math.round(-2.345d, 2)`, "-2.35"},
+		{`math.round(1250d, -2)`, "1300"},
+		{`math.round(5, -100000) == 0`, "true"},
+		{`math.round(5, -2000000000)`, "ERROR: round() takes at most 100000 digits either way, got -2000000000"},
+		{`math.round(1.5d, 2000000000)`, "ERROR: round() takes at most 100000 digits either way, got 2000000000"},
+		{`math.round(1.5, 1.0)`, "ERROR: round() takes an integer number of digits"},
+		{`math.round("a")`, "ERROR: round() takes a number as argument"},
+		{`math.round()`, "ERROR: round() takes 1 or 2 arguments"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, importMath+tt.input, tt.expected)
+	}
+}
@@ -0,0 +1,151 @@
+package std
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ariaghora/zmol/pkg/val"
+	"gorgonia.org/tensor"
+)
+
+// Reading and writing of the NumPy .npy format, as described in
+// https://numpy.org/doc/stable/reference/generated/numpy.lib.format.html
+
+var npyMagic = "\x93NUMPY"
+
+// Type descriptors of the supported dtypes, without the byte order
+var npyDescrs = map[tensor.Dtype]string{
+	tensor.Float32: "f4",
+	tensor.Float64: "f8",
+	tensor.Int32:   "i4",
+	tensor.Int64:   "i8",
+	tensor.Bool:    "b1",
+}
+
+var (
+	npyDescrRe   = regexp.MustCompile(`'descr':\s*'([<>|=])([a-z][0-9]+)'`)
+	npyFortranRe = regexp.MustCompile(`'fortran_order':\s*(True|False)`)
+	npyShapeRe   = regexp.MustCompile(`'shape':\s*\(([0-9,\s]*)\)`)
+)
+
+// Writes a tensor in the .npy format version 1.0, in little-endian order.
+func writeNpy(w io.Writer, t *val.ZTensor) error {
+	descr, ok := npyDescrs[t.Dtype()]
+	if !ok {
+		return errors.New("unsupported dtype " + t.Dtype().String())
+	}
+	byteOrder := "<"
+	if t.Dtype() == tensor.Bool {
+		byteOrder = "|"
+	}
+
+	dims := make([]string, len(t.Shape()))
+	for i, n := range t.Shape() {
+		dims[i] = strconv.Itoa(n)
+	}
+	shape := strings.Join(dims, ", ")
+	if len(dims) == 1 {
+		shape += ","
+	}
+	header := fmt.Sprintf("{'descr': '%s%s', 'fortran_order': False, 'shape': (%s), }", byteOrder, descr, shape)
+
+	// The header is padded with spaces and ends with a newline, so that the
+	// data starts at a multiple of 64 bytes
+	prefixLen := len(npyMagic) + 4
+	padding := 64 - (prefixLen+len(header)+1)%64
+	if padding == 64 {
+		padding = 0
+	}
+	header += strings.Repeat(" ", padding) + "\n"
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString(npyMagic)
+	bw.Write([]byte{1, 0})
+	binary.Write(bw, binary.LittleEndian, uint16(len(header)))
+	bw.WriteString(header)
+	if err := binary.Write(bw, binary.LittleEndian, t.Dense().Data()); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Reads a tensor in the .npy format, versions 1.0 to 3.0.
+func readNpy(r io.Reader) (*val.ZTensor, error) {
+	br := bufio.NewReader(r)
+	prefix := make([]byte, len(npyMagic)+2)
+	if _, err := io.ReadFull(br, prefix); err != nil || string(prefix[:len(npyMagic)]) != npyMagic {
+		return nil, errors.New("not a .npy file")
+	}
+
+	var headerLen uint32
+	switch prefix[len(npyMagic)] {
+	case 1:
+		var n uint16
+		if err := binary.Read(br, binary.LittleEndian, &n); err != nil {
+			return nil, err
+		}
+		headerLen = uint32(n)
+	case 2, 3:
+		if err := binary.Read(br, binary.LittleEndian, &headerLen); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported .npy version %d", prefix[len(npyMagic)])
+	}
+	headerBytes := make([]byte, headerLen)
+	if _, err := io.ReadFull(br, headerBytes); err != nil {
+		return nil, err
+	}
+	header := string(headerBytes)
+
+	descr := npyDescrRe.FindStringSubmatch(header)
+	fortran := npyFortranRe.FindStringSubmatch(header)
+	shapeMatch := npyShapeRe.FindStringSubmatch(header)
+	if descr == nil || fortran == nil || shapeMatch == nil {
+		return nil, errors.New("invalid .npy header")
+	}
+	if fortran[1] == "True" {
+		return nil, errors.New("Fortran-ordered .npy files are not supported")
+	}
+
+	var dt tensor.Dtype
+	for d, name := range npyDescrs {
+		if name == descr[2] {
+			dt = d
+		}
+	}
+	if dt.Type == nil {
+		return nil, errors.New("unsupported .npy dtype " + descr[1] + descr[2])
+	}
+	var byteOrder binary.ByteOrder = binary.LittleEndian
+	if descr[1] == ">" {
+		byteOrder = binary.BigEndian
+	}
+
+	var shape []int
+	for _, dim := range strings.Split(shapeMatch[1], ",") {
+		if dim = strings.TrimSpace(dim); dim == "" {
+			continue
+		}
+		n, err := strconv.Atoi(dim)
+		if err != nil || n <= 0 {
+			return nil, errors.New("unsupported .npy shape (" + shapeMatch[1] + ")")
+		}
+		shape = append(shape, n)
+	}
+	if len(shape) == 0 {
+		return nil, errors.New("scalar .npy files are not supported")
+	}
+
+	t := tensor.New(tensor.Of(dt), tensor.WithShape(shape...))
+	if err := binary.Read(br, byteOrder, t.Data()); err != nil {
+		return nil, errors.New("truncated .npy data")
+	}
+	return val.TENSOR(t), nil
+}
@@ -0,0 +1,196 @@
+package std
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// NewOSModule returns the os module, for a script run with the given
+// command-line arguments. The module gives access to the process, its
+// environment and subprocesses, so hosts running untrusted scripts should deny
+// it with a sandbox policy.
+func NewOSModule(args []string) *val.ZModule {
+	return val.MODULE(
+		"os",
+		&val.Env{
+			SymTable: map[string]val.ZValue{
+				// Process
+				"args": &val.ZNativeFunc{Fn: func(a ...val.ZValue) val.ZValue {
+					if len(a) != 0 {
+						return &val.ZError{Message: "args() takes no arguments"}
+					}
+					return stringList(args)
+				}},
+				"exit": &val.ZNativeFunc{Fn: Z_os_exit},
+				// Environment
+				"getenv": &val.ZNativeFunc{Fn: Z_os_getenv},
+				"setenv": &val.ZNativeFunc{Fn: Z_os_setenv},
+				"cwd":    &val.ZNativeFunc{Fn: Z_os_cwd},
+				"chdir":  &val.ZNativeFunc{Fn: Z_os_chdir},
+				// Subprocesses
+				"run": &val.ZNativeFunc{Fn: Z_os_run},
+			},
+		},
+	)
+}
+
+// exit([code]) ends the script with an exit code, 0 by default.
+func Z_os_exit(args ...val.ZValue) val.ZValue {
+	if len(args) > 1 {
+		return &val.ZError{Message: "exit() takes at most 1 argument"}
+	}
+	code := 0
+	if len(args) == 1 {
+		if args[0].Type() != val.ZINT {
+			return &val.ZError{Message: "exit() takes an integer exit code"}
+		}
+		code = int(args[0].(*val.ZInt).Value)
+	}
+	os.Exit(code)
+	return &val.ZNull{}
+}
+
+// getenv(name[, default]) returns the value of an environment variable, or the
+// default if it is not set. Without a default, it returns null.
+func Z_os_getenv(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 && len(args) != 2 {
+		return &val.ZError{Message: "getenv() takes 1 or 2 arguments"}
+	}
+	name, zErr := stringArg("getenv", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	if value, ok := os.LookupEnv(name); ok {
+		return val.STRING(value)
+	}
+	if len(args) == 2 {
+		return args[1]
+	}
+	return &val.ZNull{}
+}
+
+// setenv(name, value) sets an environment variable, which is also seen by the
+// processes started with run().
+func Z_os_setenv(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: "setenv() takes 2 arguments"}
+	}
+	name, zErr := stringArg("setenv", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	value, zErr := stringArg("setenv", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+	if err := os.Setenv(name, value); err != nil {
+		return &val.ZError{Message: "setenv() " + err.Error()}
+	}
+	return &val.ZNull{}
+}
+
+// cwd() returns the current working directory.
+func Z_os_cwd(args ...val.ZValue) val.ZValue {
+	if len(args) != 0 {
+		return &val.ZError{Message: "cwd() takes no arguments"}
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return &val.ZError{Message: "cwd() " + err.Error()}
+	}
+	return val.STRING(dir)
+}
+
+// chdir(path) changes the current working directory.
+func Z_os_chdir(args ...val.ZValue) val.ZValue {
+	path, zErr := pathArg("chdir", args)
+	if zErr != nil {
+		return zErr
+	}
+	if err := os.Chdir(path); err != nil {
+		return fileError("chdir", err)
+	}
+	return &val.ZNull{}
+}
+
+// How long run() waits for the output of a program once it has ended.
+const runWaitDelay = 100 * time.Millisecond
+
+// run(cmd[, args[, input[, timeout]]]) runs a program with a list of string
+// arguments, without a shell, and waits for it to end. The input is written
+// to its standard input, and the timeout is a duration or a number of
+// seconds. It returns a table with the `stdout`, `stderr` and exit `status`
+// of the program. A program that cannot be started or that times out results
+// in an error, while a non-zero status does not.
+func Z_os_run(args ...val.ZValue) val.ZValue {
+	if len(args) < 1 || len(args) > 4 {
+		return &val.ZError{Message: "run() takes 1 to 4 arguments"}
+	}
+	name, zErr := stringArg("run", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	var cmdArgs []string
+	if len(args) > 1 {
+		list, zErr := listArg("run", args[1])
+		if zErr != nil {
+			return &val.ZError{Message: "run() takes a list of arguments as argument 2"}
+		}
+		for _, arg := range list {
+			if arg.Type() != val.ZSTRING {
+				return &val.ZError{Message: "run() takes string arguments, got " + string(arg.Type())}
+			}
+			cmdArgs = append(cmdArgs, arg.(*val.ZString).Value)
+		}
+	}
+	input := ""
+	if len(args) > 2 {
+		if input, zErr = stringArg("run", args, 2); zErr != nil {
+			return zErr
+		}
+	}
+
+	ctx := context.Background()
+	if len(args) > 3 {
+		timeout, zErr := durationArg("run", args, 3)
+		if zErr != nil {
+			return zErr
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, cmdArgs...)
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Processes started by the program may keep its output open after it ends,
+	// or after it is killed, so waiting for the output is bounded.
+	cmd.WaitDelay = runWaitDelay
+	killProcessGroup(cmd)
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return &val.ZError{Message: fmt.Sprintf("run() %s timed out", name)}
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) && !errors.Is(err, exec.ErrWaitDelay) {
+		return &val.ZError{Message: "run() " + err.Error()}
+	}
+
+	result := val.TABLE()
+	result.Set("stdout", val.STRING(stdout.String()))
+	result.Set("stderr", val.STRING(stderr.String()))
+	result.Set("status", val.INT(int64(cmd.ProcessState.ExitCode())))
+	return result
+}
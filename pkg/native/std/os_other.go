@@ -0,0 +1,8 @@
+//go:build !unix
+
+package std
+
+import "os/exec"
+
+// Without process groups, only the command is killed when it is canceled.
+func killProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,115 @@
+package std_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ariaghora/zmol/pkg/eval"
+	"github.com/ariaghora/zmol/pkg/native"
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+func TestOSRun(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`os = import("os")
+		os.run("sh", ["-c", "echo out; echo err >&2"]).stdout`, "out\n"},
+		{`os = import("os")
+		os.run("sh", ["-c", "echo out; echo err >&2"]).stderr`, "err\n"},
+		{`os = import("os")
+		os.run("sh", ["-c", "exit 3"]).status`, "3"},
+		{`os = import("os")
+		os.run("cat", [], "hello").stdout`, "hello"},
+		{`os = import("os")
+		os.run("echo", ["a b", "$HOME"]).stdout`, "a b $HOME\n"},
+		{`os = import("os")
+		os.run("sh", ["-c", "sleep 1"], "", 0.05)`, "ERROR: run() sh timed out"},
+		{`os = import("os")
+		os.run("zmol-no-such-program")`, `ERROR: run() exec: "zmol-no-such-program": executable file not found in $PATH`},
+		{`os = import("os")
+		os.run("echo", [1])`, "ERROR: run() takes string arguments, got Int"},
+		{`os = import("os")
+		os.run()`, "ERROR: run() takes 1 to 4 arguments"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, tt.input, tt.expected)
+	}
+}
+
+// A timeout kills the processes started by the program too, which would
+// otherwise keep its output open until they end.
+func TestOSRunTimeoutKillsChildren(t *testing.T) {
+	start := time.Now()
+	testScript(t, `os = import("os")
+	os.run("sh", ["-c", "sleep 3; echo done"], "", 0.3)`, "ERROR: run() sh timed out")
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("run() returned after %s, want about 0.3s", elapsed)
+	}
+}
+
+func TestOSEnv(t *testing.T) {
+	t.Setenv("ZMOL_TEST_VAR", "")
+	os.Unsetenv("ZMOL_TEST_VAR")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`os = import("os")
+		os.getenv("ZMOL_TEST_VAR")`, ""},
+		{`os = import("os")
+		os.getenv("ZMOL_TEST_VAR", "default")`, "default"},
+		{`os = import("os")
+		os.setenv("ZMOL_TEST_VAR", "value")
+		os.getenv("ZMOL_TEST_VAR", "default")`, "value"},
+		{`os = import("os")
+		os.run("sh", ["-c", "echo $ZMOL_TEST_VAR"]).stdout`, "value\n"},
+		{`os = import("os")
+		os.getenv()`, "ERROR: getenv() takes 1 or 2 arguments"},
+		{`os = import("os")
+		os.setenv("ZMOL_TEST_VAR", 1)`, "ERROR: setenv() takes a string as argument 2"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, tt.input, tt.expected)
+	}
+}
+
+func TestDeniedModules(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "uses_os.zmol"), []byte(`os = import("os")`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	state := eval.NewZmolState(nil)
+	reg := native.NewNativeFuncRegistry(state)
+	reg.Policy = native.Policy{DeniedModules: []string{"os", "fs"}}
+	reg.RegisterNativeFunc()
+	state.Env.Set("__moddir__", val.STRING(dir))
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import("os")`, `ERROR: import: module "os" is denied by the sandbox policy`},
+		{`import("fs")`, `ERROR: import: module "fs" is denied by the sandbox policy`},
+		{`is_error(import("math"))`, "false"},
+		{`m = import("uses_os.zmol")
+		m.os`, `ERROR: import: module "os" is denied by the sandbox policy`},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := state.Eval(tt.input)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.input, err)
+		}
+		if evaluated.Str() != tt.expected {
+			t.Errorf("%s: got=%s, want=%s", tt.input, evaluated.Str(), tt.expected)
+		}
+	}
+}
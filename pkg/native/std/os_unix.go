@@ -0,0 +1,17 @@
+//go:build unix
+
+package std
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// Starts the command in its own process group, and kills the whole group when
+// the command is canceled, so that the processes it started do not outlive it.
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
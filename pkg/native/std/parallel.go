@@ -0,0 +1,87 @@
+package std
+
+import (
+	"github.com/ariaghora/zmol/pkg/eval"
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// Parallel versions of map and filter, which call a function on a pool of
+// goroutines and keep the order of the elements. They suit CPU-heavy functions
+// over lists and tables that are only read, e.g.,
+// `parallel.map(images, resize, 8)`.
+var ParallelModule = val.MODULE(
+	"parallel",
+	&val.Env{
+		SymTable: map[string]val.ZValue{
+			"map":    &val.ZNativeFunc{Fn: Z_parallel_map},
+			"filter": &val.ZNativeFunc{Fn: Z_parallel_filter},
+		},
+	},
+)
+
+// Returns the arguments of map() and filter(): the elements, the function, and
+// the number of workers, one per CPU by default.
+func parallelArgs(name string, args []val.ZValue) ([]val.ZValue, val.ZValue, int, *val.ZError) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, nil, 0, &val.ZError{Message: name + "() takes 2 or 3 arguments"}
+	}
+	elems, zErr := listArg(name, args[0])
+	if zErr != nil {
+		return nil, nil, 0, zErr
+	}
+	fn, zErr := callableArg(name, args, 1)
+	if zErr != nil {
+		return nil, nil, 0, zErr
+	}
+	workers := eval.DefaultWorkers()
+	if len(args) == 3 {
+		if workers, zErr = countArg(name, args, 2); zErr != nil {
+			return nil, nil, 0, zErr
+		}
+		if workers == 0 {
+			return nil, nil, 0, &val.ZError{Message: name + "() takes at least 1 worker"}
+		}
+	}
+	return elems, fn, workers, nil
+}
+
+// map(list, fn[, workers]) returns the results of fn on each element. At the
+// first error, in the order of the elements, it returns that error.
+func Z_parallel_map(args ...val.ZValue) val.ZValue {
+	elems, fn, workers, zErr := parallelArgs("map", args)
+	if zErr != nil {
+		return zErr
+	}
+	results, err := eval.ParallelApply(elems, workers, func(elem val.ZValue) val.ZValue {
+		return eval.CallFunction(fn, elem)
+	})
+	if err != nil {
+		return err
+	}
+	return &val.ZList{Elements: results}
+}
+
+// filter(list, fn[, workers]) returns the elements for which fn returns true.
+func Z_parallel_filter(args ...val.ZValue) val.ZValue {
+	elems, fn, workers, zErr := parallelArgs("filter", args)
+	if zErr != nil {
+		return zErr
+	}
+	results, err := eval.ParallelApply(elems, workers, func(elem val.ZValue) val.ZValue {
+		keep, err := callPredicate("filter", fn, elem)
+		if err != nil {
+			return err
+		}
+		return val.BOOL(keep)
+	})
+	if err != nil {
+		return err
+	}
+	kept := []val.ZValue{}
+	for i, keep := range results {
+		if keep.(*val.ZBool).Value {
+			kept = append(kept, elems[i])
+		}
+	}
+	return &val.ZList{Elements: kept}
+}
@@ -0,0 +1,215 @@
+package std
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// Pseudo-random numbers. All functions draw from one generator, which is
+// seeded from the clock, or with seed() for reproducible sequences. Tasks share
+// the generator, so only a single task gets a reproducible sequence. Functions
+// on lists take the list as the first argument, so they can be used in
+// pipelines, e.g., `deck |> random.shuffle{}`.
+var RandomModule = val.MODULE(
+	"random",
+	&val.Env{
+		SymTable: map[string]val.ZValue{
+			"seed": &val.ZNativeFunc{Fn: Z_random_seed},
+			// Numbers
+			"int":     &val.ZNativeFunc{Fn: Z_random_int},
+			"float":   &val.ZNativeFunc{Fn: Z_random_float},
+			"uniform": &val.ZNativeFunc{Fn: Z_random_uniform},
+			"normal":  &val.ZNativeFunc{Fn: Z_random_normal},
+			// Lists
+			"choice":  &val.ZNativeFunc{Fn: Z_random_choice},
+			"shuffle": &val.ZNativeFunc{Fn: Z_random_shuffle},
+			"sample":  &val.ZNativeFunc{Fn: Z_random_sample},
+		},
+	},
+)
+
+var rng = rand.New(&lockedSource{src: rand.NewSource(time.Now().UnixNano()).(rand.Source64)})
+
+// A source that is safe for concurrent use, as tasks share the generator.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source64
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
+
+// Returns the number argument at position i as a float.
+func floatArg(name string, args []val.ZValue, i int) (float64, *val.ZError) {
+	x, err := EnsureFloat(args[i])
+	if err != nil {
+		return 0, &val.ZError{Message: fmt.Sprintf("%s() takes a number as argument %d, got %s", name, i+1, args[i].Type())}
+	}
+	return x, nil
+}
+
+// seed(n) resets the generator, so that the same sequence of numbers follows.
+func Z_random_seed(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 || args[0].Type() != val.ZINT {
+		return &val.ZError{Message: "seed() takes an integer"}
+	}
+	rng.Seed(args[0].(*val.ZInt).Value)
+	return &val.ZNull{}
+}
+
+// int(n) returns an integer in [0, n), and int(a, b) an integer in [a, b), as
+// range_list().
+func Z_random_int(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 && len(args) != 2 {
+		return &val.ZError{Message: "int() takes 1 or 2 arguments"}
+	}
+	bounds := make([]int64, len(args))
+	for i, arg := range args {
+		if arg.Type() != val.ZINT {
+			return &val.ZError{Message: "int() takes integer bounds"}
+		}
+		bounds[i] = arg.(*val.ZInt).Value
+	}
+	low, high := int64(0), bounds[0]
+	if len(bounds) == 2 {
+		low, high = bounds[0], bounds[1]
+	}
+	if high <= low {
+		return &val.ZError{Message: fmt.Sprintf("int() takes a non-empty range, got [%d, %d)", low, high)}
+	}
+	if span := high - low; span > 0 {
+		return val.INT(low + rng.Int63n(span))
+	}
+	// The range is wider than the largest int64, so the offset is drawn as an
+	// unsigned integer, rejecting the values that would bias the result
+	span := uint64(high) - uint64(low)
+	limit := math.MaxUint64 - math.MaxUint64%span
+	for {
+		if n := rng.Uint64(); n < limit {
+			return val.INT(low + int64(n%span))
+		}
+	}
+}
+
+// float() returns a float in [0, 1).
+func Z_random_float(args ...val.ZValue) val.ZValue {
+	if len(args) != 0 {
+		return &val.ZError{Message: "float() takes no arguments"}
+	}
+	return val.FLOAT(rng.Float64())
+}
+
+// uniform(a, b) returns a float in [a, b).
+func Z_random_uniform(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: "uniform() takes 2 arguments"}
+	}
+	low, zErr := floatArg("uniform", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	high, zErr := floatArg("uniform", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+	return val.FLOAT(low + (high-low)*rng.Float64())
+}
+
+// normal() returns a float from the standard normal distribution, and
+// normal(mean, stddev) from the normal distribution with the given parameters.
+func Z_random_normal(args ...val.ZValue) val.ZValue {
+	if len(args) != 0 && len(args) != 2 {
+		return &val.ZError{Message: "normal() takes 0 or 2 arguments"}
+	}
+	mean, stddev := 0.0, 1.0
+	if len(args) == 2 {
+		var zErr *val.ZError
+		if mean, zErr = floatArg("normal", args, 0); zErr != nil {
+			return zErr
+		}
+		if stddev, zErr = floatArg("normal", args, 1); zErr != nil {
+			return zErr
+		}
+		if stddev < 0 {
+			return &val.ZError{Message: "normal() takes a non-negative standard deviation"}
+		}
+	}
+	return val.FLOAT(mean + stddev*rng.NormFloat64())
+}
+
+// choice(list) returns a random element of a non-empty list.
+func Z_random_choice(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "choice() takes 1 argument"}
+	}
+	elements, zErr := listArg("choice", args[0])
+	if zErr != nil {
+		return zErr
+	}
+	if len(elements) == 0 {
+		return &val.ZError{Message: "choice() takes a non-empty list"}
+	}
+	return elements[rng.Intn(len(elements))]
+}
+
+// shuffle(list) returns a new list with the elements in random order.
+func Z_random_shuffle(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "shuffle() takes 1 argument"}
+	}
+	elements, zErr := listArg("shuffle", args[0])
+	if zErr != nil {
+		return zErr
+	}
+	result := append([]val.ZValue{}, elements...)
+	rng.Shuffle(len(result), func(i, j int) {
+		result[i], result[j] = result[j], result[i]
+	})
+	return &val.ZList{Elements: result}
+}
+
+// sample(list, k) returns k distinct elements of a list, in random order.
+func Z_random_sample(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: "sample() takes 2 arguments"}
+	}
+	elements, zErr := listArg("sample", args[0])
+	if zErr != nil {
+		return zErr
+	}
+	k, zErr := countArg("sample", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+	if k > len(elements) {
+		return &val.ZError{Message: fmt.Sprintf("sample() cannot take %d elements from a list of %d", k, len(elements))}
+	}
+
+	// A partial Fisher-Yates shuffle of the first k positions
+	pool := append([]val.ZValue{}, elements...)
+	for i := 0; i < k; i++ {
+		j := i + rng.Intn(len(pool)-i)
+		pool[i], pool[j] = pool[j], pool[i]
+	}
+	return &val.ZList{Elements: pool[:k]}
+}
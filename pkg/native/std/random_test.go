@@ -0,0 +1,78 @@
+package std_test
+
+import (
+	"testing"
+
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// Draws a value from each function of the random module after seeding it.
+const randomDraws = `random = import("random")
+random.seed(seed)
+xs = range_list(0, 10)
+r = [random.int(100), random.int(-5, 5), random.float(), random.uniform(2, 3), random.normal(), random.normal(10, 2), random.choice(xs), random.shuffle(xs), random.sample(xs, 3)]
+r`
+
+func drawRandom(t *testing.T, seed int64) string {
+	t.Helper()
+	state := newScriptState()
+	state.Env.Set("seed", val.INT(seed))
+	evaluated, err := state.Eval(randomDraws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return evaluated.Str()
+}
+
+func TestRandomSeed(t *testing.T) {
+	first := drawRandom(t, 42)
+	if again := drawRandom(t, 42); again != first {
+		t.Errorf("seed(42) gave different values: %s and %s", first, again)
+	}
+	if other := drawRandom(t, 7); other == first {
+		t.Errorf("seed(7) gave the same values as seed(42): %s", other)
+	}
+}
+
+func TestRandomRanges(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`random = import("random")
+		random.seed(1)
+		xs = range_list(0, 1000) -> @(i) { random.int(3, 6) }{}
+		r = [xs >- @(x) { x < 3 || x >= 6 }{} |> len{}, xs >- @(x) { x == 3 }{} |> len{} > 0, xs >- @(x) { x == 5 }{} |> len{} > 0]
+		r`, "[0, true, true]"},
+		{`random = import("random")
+		random.seed(1)
+		xs = range_list(0, 1000) -> @(i) { random.int(-9223372036854775807, 9223372036854775807) }{}
+		r = [xs >- @(x) { x < 0 }{} |> len{} > 0, xs >- @(x) { x > 0 }{} |> len{} > 0, xs >- @(x) { x == 9223372036854775807 }{} |> len{}]
+		r`, "[true, true, 0]"},
+		{`random = import("random")
+		random.seed(1)
+		xs = range_list(0, 1000) -> @(i) { random.uniform(-1, 1) }{}
+		xs >- @(x) { x < -1 || x >= 1 }{} |> len{}`, "0"},
+		{`random = import("random")
+		random.seed(1)
+		xs = random.shuffle(range_list(0, 20))
+		r = [len(xs), reduce(xs, @(a, b) { a + b }, 0)]
+		r`, "[20, 190]"},
+		{`random = import("random")
+		random.seed(1)
+		xs = random.sample(range_list(0, 5), 5)
+		reduce(xs, @(a, b) { a + b }, 0)`, "10"},
+		{`random = import("random")
+		random.int(0)`, "ERROR: int() takes a non-empty range, got [0, 0)"},
+		{`random = import("random")
+		random.choice([])`, "ERROR: choice() takes a non-empty list"},
+		{`random = import("random")
+		random.sample([1, 2], 3)`, "ERROR: sample() cannot take 3 elements from a list of 2"},
+		{`random = import("random")
+		random.seed(1.5)`, "ERROR: seed() takes an integer"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, tt.input, tt.expected)
+	}
+}
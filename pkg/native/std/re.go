@@ -0,0 +1,285 @@
+package std
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/ariaghora/zmol/pkg/eval"
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// Regular expressions, with the syntax of Go's regexp package. Every function
+// takes a pattern, either a string or a regex from compile(), followed by the
+// text. Regexes have the same functions as methods without the pattern, e.g.,
+// `lines >- r.match{}` keeps the lines that contain a match of r.
+var ReModule = val.MODULE(
+	"re",
+	&val.Env{
+		SymTable: map[string]val.ZValue{
+			"compile":      &val.ZNativeFunc{Fn: Z_re_compile},
+			"match":        &val.ZNativeFunc{Fn: Z_re_match},
+			"find":         &val.ZNativeFunc{Fn: Z_re_find},
+			"find_all":     &val.ZNativeFunc{Fn: Z_re_find_all},
+			"groups":       &val.ZNativeFunc{Fn: Z_re_groups},
+			"named_groups": &val.ZNativeFunc{Fn: Z_re_named_groups},
+			"replace":      &val.ZNativeFunc{Fn: Z_re_replace},
+			"split":        &val.ZNativeFunc{Fn: Z_re_split},
+			"escape":       &val.ZNativeFunc{Fn: Z_re_escape},
+		},
+	},
+)
+
+// The functions that are also methods of regexes.
+var regexMethods = map[string]func(args ...val.ZValue) val.ZValue{
+	"match":        Z_re_match,
+	"find":         Z_re_find,
+	"find_all":     Z_re_find_all,
+	"groups":       Z_re_groups,
+	"named_groups": Z_re_named_groups,
+	"replace":      Z_re_replace,
+	"split":        Z_re_split,
+}
+
+// Patterns given as strings are compiled once and cached, since the same
+// pattern is usually applied to every element of a list.
+var (
+	patternCache   = map[string]*regexp.Regexp{}
+	patternCacheMu sync.Mutex
+)
+
+const patternCacheSize = 256
+
+func compilePattern(name, pattern string) (*regexp.Regexp, *val.ZError) {
+	patternCacheMu.Lock()
+	defer patternCacheMu.Unlock()
+	if re, ok := patternCache[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, &val.ZError{Message: name + "() " + err.Error()}
+	}
+	if len(patternCache) >= patternCacheSize {
+		patternCache = map[string]*regexp.Regexp{}
+	}
+	patternCache[pattern] = re
+	return re, nil
+}
+
+// Returns the pattern at position 0 and the text at position 1, after checking
+// that there are between min and max arguments.
+func regexArgs(name string, args []val.ZValue, min, max int) (*regexp.Regexp, string, *val.ZError) {
+	if len(args) < min || len(args) > max {
+		if min == max {
+			return nil, "", &val.ZError{Message: fmt.Sprintf("%s() takes %d arguments", name, min)}
+		}
+		return nil, "", &val.ZError{Message: fmt.Sprintf("%s() takes %d or %d arguments", name, min, max)}
+	}
+	var re *regexp.Regexp
+	switch pattern := args[0].(type) {
+	case *val.ZRegex:
+		re = pattern.Re
+	case *val.ZString:
+		var zErr *val.ZError
+		if re, zErr = compilePattern(name, pattern.Value); zErr != nil {
+			return nil, "", zErr
+		}
+	default:
+		return nil, "", &val.ZError{Message: name + "() takes a string or a regex as argument 1"}
+	}
+	text, zErr := stringArg(name, args, 1)
+	if zErr != nil {
+		return nil, "", zErr
+	}
+	return re, text, nil
+}
+
+// Returns the optional limit on the number of results at position i, which is
+// -1 for no limit.
+func limitArg(name string, args []val.ZValue, i int) (int, *val.ZError) {
+	if len(args) <= i {
+		return -1, nil
+	}
+	return countArg(name, args, i)
+}
+
+// Returns the captured groups of a match, with null for the groups that did
+// not participate in the match.
+func submatches(text string, loc []int) []val.ZValue {
+	groups := make([]val.ZValue, 0, len(loc)/2-1)
+	for i := 2; i < len(loc); i += 2 {
+		if loc[i] < 0 {
+			groups = append(groups, val.NULL())
+		} else {
+			groups = append(groups, val.STRING(text[loc[i]:loc[i+1]]))
+		}
+	}
+	return groups
+}
+
+func stringList(strs []string) *val.ZList {
+	elements := make([]val.ZValue, len(strs))
+	for i, s := range strs {
+		elements[i] = val.STRING(s)
+	}
+	return &val.ZList{Elements: elements}
+}
+
+// compile(pattern) returns a regex, or an error if the pattern is invalid.
+func Z_re_compile(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "compile() takes 1 argument"}
+	}
+	pattern, zErr := stringArg("compile", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return &val.ZError{Message: "compile() " + err.Error()}
+	}
+
+	regex := val.REGEX(re, map[string]val.ZValue{})
+	for name, fn := range regexMethods {
+		fn := fn
+		regex.Methods[name] = &val.ZNativeFunc{Fn: func(args ...val.ZValue) val.ZValue {
+			return fn(append([]val.ZValue{regex}, args...)...)
+		}}
+	}
+	return regex
+}
+
+// match(pattern, text) returns whether the text contains a match. Use ^ and $
+// in the pattern to match the whole text.
+func Z_re_match(args ...val.ZValue) val.ZValue {
+	re, text, zErr := regexArgs("match", args, 2, 2)
+	if zErr != nil {
+		return zErr
+	}
+	return val.BOOL(re.MatchString(text))
+}
+
+// find(pattern, text) returns the first match, or null if there is none.
+func Z_re_find(args ...val.ZValue) val.ZValue {
+	re, text, zErr := regexArgs("find", args, 2, 2)
+	if zErr != nil {
+		return zErr
+	}
+	loc := re.FindStringIndex(text)
+	if loc == nil {
+		return val.NULL()
+	}
+	return val.STRING(text[loc[0]:loc[1]])
+}
+
+// find_all(pattern, text[, n]) returns the matches, at most n if given.
+func Z_re_find_all(args ...val.ZValue) val.ZValue {
+	re, text, zErr := regexArgs("find_all", args, 2, 3)
+	if zErr != nil {
+		return zErr
+	}
+	n, zErr := limitArg("find_all", args, 2)
+	if zErr != nil {
+		return zErr
+	}
+	return stringList(re.FindAllString(text, n))
+}
+
+// groups(pattern, text) returns the captured groups of the first match as a
+// list, or null if there is no match.
+func Z_re_groups(args ...val.ZValue) val.ZValue {
+	re, text, zErr := regexArgs("groups", args, 2, 2)
+	if zErr != nil {
+		return zErr
+	}
+	loc := re.FindStringSubmatchIndex(text)
+	if loc == nil {
+		return val.NULL()
+	}
+	return &val.ZList{Elements: submatches(text, loc)}
+}
+
+// named_groups(pattern, text) returns the named groups of the first match as
+// a table keyed by group name, or null if there is no match.
+func Z_re_named_groups(args ...val.ZValue) val.ZValue {
+	re, text, zErr := regexArgs("named_groups", args, 2, 2)
+	if zErr != nil {
+		return zErr
+	}
+	loc := re.FindStringSubmatchIndex(text)
+	if loc == nil {
+		return val.NULL()
+	}
+	groups := val.TABLE()
+	for i, group := range submatches(text, loc) {
+		if name := re.SubexpNames()[i+1]; name != "" {
+			groups.Set(name, group)
+		}
+	}
+	return groups
+}
+
+// replace(pattern, text, replacement) replaces every match. The replacement
+// is either a string, where $1 or ${name} stand for groups, or a function
+// that takes the matched text and returns its replacement.
+func Z_re_replace(args ...val.ZValue) val.ZValue {
+	re, text, zErr := regexArgs("replace", args, 3, 3)
+	if zErr != nil {
+		return zErr
+	}
+	if args[2].Type() == val.ZSTRING {
+		return val.STRING(re.ReplaceAllString(text, args[2].(*val.ZString).Value))
+	}
+
+	fn, zErr := callableArg("replace", args, 2)
+	if zErr != nil {
+		return &val.ZError{Message: "replace() takes a string or a function as argument 3"}
+	}
+	var failure val.ZValue
+	result := re.ReplaceAllStringFunc(text, func(match string) string {
+		if failure != nil {
+			return match
+		}
+		replacement := eval.CallFunction(fn, val.STRING(match))
+		switch replacement.Type() {
+		case val.ZSTRING:
+			return replacement.(*val.ZString).Value
+		case val.ZERROR:
+			failure = replacement
+		default:
+			failure = &val.ZError{Message: "replace() takes a function that returns a string"}
+		}
+		return match
+	})
+	if failure != nil {
+		return failure
+	}
+	return val.STRING(result)
+}
+
+// split(pattern, text[, n]) splits the text around the matches, into at most
+// n parts if given.
+func Z_re_split(args ...val.ZValue) val.ZValue {
+	re, text, zErr := regexArgs("split", args, 2, 3)
+	if zErr != nil {
+		return zErr
+	}
+	n, zErr := limitArg("split", args, 2)
+	if zErr != nil {
+		return zErr
+	}
+	return stringList(re.Split(text, n))
+}
+
+// escape(text) returns a pattern that matches the text literally.
+func Z_re_escape(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "escape() takes 1 argument"}
+	}
+	text, zErr := stringArg("escape", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	return val.STRING(regexp.QuoteMeta(text))
+}
@@ -0,0 +1,121 @@
+package std_test
+
+import "testing"
+
+// Imports the module for the inputs of the tests.
+const importRe = "re = import(\"re\")\n"
+
+func TestReCompile(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`r = re.compile("[0-9]+")
+		r`, `<Regex "[0-9]+">`},
+		{`r = re.compile("[0-9]+")
+		r.match("a1")`, "true"},
+		{`r = re.compile("[0-9]+")
+		r.find("ab 12 34")`, "12"},
+		{`r = re.compile("[0-9]+")
+		r.find_all("1 22 333", 2)`, "[1, 22]"},
+		{`r = re.compile("[0-9]+")
+		re.match(r, "abc")`, "false"},
+		{`re.compile("(")`, "ERROR: compile() error parsing regexp: missing closing ): `(`"},
+		{`re.compile(1)`, "ERROR: compile() takes a string as argument 1"},
+		{`re.match("(", "a")`, "ERROR: match() error parsing regexp: missing closing ): `(`"},
+		{`re.match(1, "a")`, "ERROR: match() takes a string or a regex as argument 1"},
+		{`re.find("x", "")`, ""},
+		{`re.escape("a.b*")`, "a\\.b\\*"},
+		{`re.match(re.escape("a.b"), "axb")`, "false"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, importRe+tt.input, tt.expected)
+	}
+}
+
+func TestReGroups(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`re.groups("([a-z]+)=([0-9]+)", "x a=1 b=2")`, "[a, 1]"},
+		{`re.groups("(a)|(b)", "b")`, "[, b]"},
+		{`re.groups("([0-9])", "abc")`, ""},
+		{`re.groups("[0-9]", "a1")`, "[]"},
+		{`g = re.named_groups("(?P<key>[a-z]+)=(?P<value>[0-9]+)", "x a=1")
+		r = [g.key, g.value]
+		r`, "[a, 1]"},
+		{`g = re.named_groups("(?P<key>[a-z]+)=([0-9]+)", "a=1")
+		keys(g)`, "[key]"},
+		{`re.named_groups("(?P<key>[a-z]+)", "123")`, ""},
+		{`r = re.compile("(?P<year>[0-9]{4})-(?P<month>[0-9]{2})")
+		r.named_groups("on 2024-05").month`, "05"},
+		{`re.groups("(a)")`, "ERROR: groups() takes 2 arguments"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, importRe+tt.input, tt.expected)
+	}
+}
+
+func TestReReplace(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`re.replace("[0-9]+", "a1b22", "#")`, "a#b#"},
+		{`re.replace("(?P<k>[a-z])=([0-9])", "a=1 b=2", "$2=${k}")`, "1=a 2=b"},
+		{`re.replace("[0-9]+", "a1b22", @(m) { m + m })`, "a11b2222"},
+		{`re.replace("[a-z]+", "ab cd", len)`, "ERROR: replace() takes a function that returns a string"},
+		{`re.replace("[0-9]+", "a1b22", @(m) { int(m) * 2 })`, "ERROR: replace() takes a function that returns a string"},
+		{`re.replace("[0-9]+", "a1b", @(m) { 1 / 0 })`, "ERROR: division by zero"},
+		{`re.replace("x", "y", 1)`, "ERROR: replace() takes a string or a function as argument 3"},
+		{`r = re.compile("o")
+		r.replace("foo", "0")`, "f00"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, importRe+tt.input, tt.expected)
+	}
+}
+
+func TestReSplit(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`re.split(" *, *", "a, b ,c")`, "[a, b, c]"},
+		{`re.split(",", "a,b,c", 2)`, "[a, b,c]"},
+		{`re.split(",", "a,b,c", 1)`, "[a,b,c]"},
+		{`re.split(",", "a,b,c", 0)`, "[]"},
+		{`re.split(",", "a,b", -1)`, "ERROR: split() takes a non-negative integer as argument 3"},
+		{`re.split(",", "a,b", 1.5)`, "ERROR: split() takes a non-negative integer as argument 3"},
+		{`re.find_all("[0-9]", "1 2 3", 0)`, "[]"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, importRe+tt.input, tt.expected)
+	}
+}
+
+func TestRePredicate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`xs = ["a1", "b", "c22"]
+		xs >- re.match{"[0-9]", _}`, "[a1, c22]"},
+		{`r = re.compile("^[a-z]+$")
+		xs = ["ab", "a b", "", "cd"]
+		xs >- r.match{}`, "[ab, cd]"},
+		{`xs = ["a1", "b"]
+		xs >- re.find{"[0-9]", _}`, "ERROR: Filter function must return a boolean, got String"},
+		{`lines = ["x=1", "y", "z=2"] >- re.match{"=", _}
+		lines -> re.groups{"([a-z])=([0-9])", _}`, "[[x, 1], [z, 2]]"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, importRe+tt.input, tt.expected)
+	}
+}
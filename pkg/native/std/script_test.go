@@ -0,0 +1,26 @@
+package std_test
+
+import (
+	"testing"
+
+	"github.com/ariaghora/zmol/pkg/eval"
+	"github.com/ariaghora/zmol/pkg/native"
+)
+
+// Returns a state with the builtins, to evaluate the scripts of the tests in.
+func newScriptState() *eval.ZmolState {
+	state := eval.NewZmolState(nil)
+	native.NewNativeFuncRegistry(state).RegisterNativeFunc()
+	return state
+}
+
+func testScript(t *testing.T, input string, expected string) {
+	t.Helper()
+	evaluated, err := newScriptState().Eval(input)
+	if err != nil {
+		t.Fatalf("%s: %v", input, err)
+	}
+	if evaluated.Str() != expected {
+		t.Errorf("%s: got=%s, want=%s", input, evaluated.Str(), expected)
+	}
+}
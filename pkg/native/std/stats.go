@@ -0,0 +1,264 @@
+package std
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// Descriptive statistics over lists of numbers. The list is the first
+// argument, so they can be used in pipelines, e.g., `xs |> stats.mean{}`.
+// Results are floats.
+var StatsModule = val.MODULE(
+	"stats",
+	&val.Env{
+		SymTable: map[string]val.ZValue{
+			// Central tendency
+			"mean":   &val.ZNativeFunc{Fn: Z_stats_mean},
+			"median": &val.ZNativeFunc{Fn: Z_stats_median},
+			// Spread
+			"variance":   &val.ZNativeFunc{Fn: Z_stats_variance},
+			"stddev":     &val.ZNativeFunc{Fn: Z_stats_stddev},
+			"percentile": &val.ZNativeFunc{Fn: Z_stats_percentile},
+			"histogram":  &val.ZNativeFunc{Fn: Z_stats_histogram},
+			// Relationship
+			"correlation": &val.ZNativeFunc{Fn: Z_stats_correlation},
+		},
+	},
+)
+
+// Returns the list argument at position i as floats. The list must have at
+// least min elements.
+func floatsArg(name string, args []val.ZValue, i, min int) ([]float64, *val.ZError) {
+	elements, zErr := listArg(name, args[i])
+	if zErr != nil {
+		return nil, zErr
+	}
+	xs := make([]float64, len(elements))
+	for j, e := range elements {
+		x, err := EnsureFloat(e)
+		if err != nil {
+			return nil, &val.ZError{Message: fmt.Sprintf("%s() takes a list of numbers, got %s", name, e.Type())}
+		}
+		xs[j] = x
+	}
+	if len(xs) == 0 {
+		return nil, &val.ZError{Message: name + "() takes a non-empty list"}
+	}
+	if len(xs) < min {
+		return nil, &val.ZError{Message: fmt.Sprintf("%s() takes a list of at least %d numbers", name, min)}
+	}
+	return xs, nil
+}
+
+func mean(xs []float64) float64 {
+	sum := 0.0
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+// Returns the value below which p percent of the sorted values fall,
+// interpolating linearly between neighbours.
+func percentile(sorted []float64, p float64) float64 {
+	pos := p / 100 * float64(len(sorted)-1)
+	i := int(math.Floor(pos))
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (pos-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+// Returns the variance, dividing by n for a population, or by n - 1 for a
+// sample.
+func variance(xs []float64, population bool) float64 {
+	m := mean(xs)
+	sum := 0.0
+	for _, x := range xs {
+		sum += (x - m) * (x - m)
+	}
+	if population {
+		return sum / float64(len(xs))
+	}
+	return sum / float64(len(xs)-1)
+}
+
+// Shared argument handling of variance() and stddev(), which take a list and
+// an optional flag that selects the population variance.
+func varianceArgs(name string, args []val.ZValue) ([]float64, bool, *val.ZError) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, false, &val.ZError{Message: name + "() takes 1 or 2 arguments"}
+	}
+	population := false
+	if len(args) == 2 {
+		if args[1].Type() != val.ZBOOL {
+			return nil, false, &val.ZError{Message: name + "() takes a boolean population flag"}
+		}
+		population = args[1].(*val.ZBool).Value
+	}
+	min := 2
+	if population {
+		min = 1
+	}
+	xs, zErr := floatsArg(name, args, 0, min)
+	return xs, population, zErr
+}
+
+// mean(xs) returns the arithmetic mean.
+func Z_stats_mean(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "mean() takes 1 argument"}
+	}
+	xs, zErr := floatsArg("mean", args, 0, 1)
+	if zErr != nil {
+		return zErr
+	}
+	return val.FLOAT(mean(xs))
+}
+
+// median(xs) returns the middle value, or the mean of the two middle values.
+func Z_stats_median(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "median() takes 1 argument"}
+	}
+	xs, zErr := floatsArg("median", args, 0, 1)
+	if zErr != nil {
+		return zErr
+	}
+	sort.Float64s(xs)
+	return val.FLOAT(percentile(xs, 50))
+}
+
+// variance(xs) returns the sample variance, and variance(xs, true) the
+// population variance.
+func Z_stats_variance(args ...val.ZValue) val.ZValue {
+	xs, population, zErr := varianceArgs("variance", args)
+	if zErr != nil {
+		return zErr
+	}
+	return val.FLOAT(variance(xs, population))
+}
+
+// stddev(xs) returns the sample standard deviation, and stddev(xs, true) the
+// population standard deviation.
+func Z_stats_stddev(args ...val.ZValue) val.ZValue {
+	xs, population, zErr := varianceArgs("stddev", args)
+	if zErr != nil {
+		return zErr
+	}
+	return val.FLOAT(math.Sqrt(variance(xs, population)))
+}
+
+// percentile(xs, p) returns the p-th percentile, for p from 0 to 100.
+func Z_stats_percentile(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: "percentile() takes 2 arguments"}
+	}
+	xs, zErr := floatsArg("percentile", args, 0, 1)
+	if zErr != nil {
+		return zErr
+	}
+	p, zErr := floatArg("percentile", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+	if p < 0 || p > 100 {
+		return &val.ZError{Message: "percentile() takes a percentage from 0 to 100"}
+	}
+	sort.Float64s(xs)
+	return val.FLOAT(percentile(xs, p))
+}
+
+// The largest number of bins of histogram(), beyond which the counts and
+// edges would take too much memory.
+const maxHistogramBins = 1 << 20
+
+// histogram(xs, bins) divides the range of the values into bins of equal
+// width, and returns [counts, edges]. There is one more edge than bins, and
+// the last bin includes its upper edge.
+func Z_stats_histogram(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: "histogram() takes 2 arguments"}
+	}
+	xs, zErr := floatsArg("histogram", args, 0, 1)
+	if zErr != nil {
+		return zErr
+	}
+	bins, zErr := countArg("histogram", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+	if bins == 0 {
+		return &val.ZError{Message: "histogram() takes at least 1 bin"}
+	}
+	if bins > maxHistogramBins {
+		return &val.ZError{Message: fmt.Sprintf("histogram() takes at most %d bins, got %d", maxHistogramBins, bins)}
+	}
+
+	low, high := xs[0], xs[0]
+	for _, x := range xs {
+		low, high = math.Min(low, x), math.Max(high, x)
+	}
+	// All values equal: center a unit-width range on them
+	if low == high {
+		low, high = low-0.5, high+0.5
+	}
+	width := (high - low) / float64(bins)
+
+	counts := make([]int64, bins)
+	for _, x := range xs {
+		bin := int((x - low) / width)
+		if bin >= bins {
+			bin = bins - 1
+		}
+		counts[bin]++
+	}
+
+	countList := make([]val.ZValue, bins)
+	for i, n := range counts {
+		countList[i] = val.INT(n)
+	}
+	edgeList := make([]val.ZValue, bins+1)
+	for i := range edgeList {
+		edgeList[i] = val.FLOAT(low + float64(i)*width)
+	}
+	edgeList[bins] = val.FLOAT(high)
+	return &val.ZList{Elements: []val.ZValue{
+		&val.ZList{Elements: countList}, &val.ZList{Elements: edgeList},
+	}}
+}
+
+// correlation(xs, ys) returns the Pearson correlation coefficient of two lists
+// of the same length.
+func Z_stats_correlation(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: "correlation() takes 2 arguments"}
+	}
+	xs, zErr := floatsArg("correlation", args, 0, 2)
+	if zErr != nil {
+		return zErr
+	}
+	ys, zErr := floatsArg("correlation", args, 1, 2)
+	if zErr != nil {
+		return zErr
+	}
+	if len(xs) != len(ys) {
+		return &val.ZError{Message: fmt.Sprintf("correlation() takes lists of the same length, got %d and %d", len(xs), len(ys))}
+	}
+
+	mx, my := mean(xs), mean(ys)
+	var sxy, sxx, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-mx, ys[i]-my
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return &val.ZError{Message: "correlation() is undefined for constant values"}
+	}
+	return val.FLOAT(sxy / math.Sqrt(sxx*syy))
+}
@@ -0,0 +1,101 @@
+package std_test
+
+import (
+	"testing"
+
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+func TestStats(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`stats = import("stats")
+		stats.mean([1, 2, 3, 4])`, "2.500000"},
+		{`stats = import("stats")
+		stats.median([3, 1, 2])`, "2.000000"},
+		{`stats = import("stats")
+		stats.median([4, 1, 3, 2])`, "2.500000"},
+		{`stats = import("stats")
+		stats.variance([2, 4, 4, 4, 5, 5, 7, 9])`, "4.571429"},
+		{`stats = import("stats")
+		stats.variance([2, 4, 4, 4, 5, 5, 7, 9], true)`, "4.000000"},
+		{`stats = import("stats")
+		stats.stddev([2, 4, 4, 4, 5, 5, 7, 9], true)`, "2.000000"},
+		{`stats = import("stats")
+		stats.percentile([1, 2, 3, 4, 5], 25)`, "2.000000"},
+		{`stats = import("stats")
+		stats.percentile([1, 2, 3, 4], 50)`, "2.500000"},
+		{`stats = import("stats")
+		stats.percentile([1, 2, 3, 4], 100)`, "4.000000"},
+		{`stats = import("stats")
+		stats.histogram([1, 2, 2, 3, 4], 3)`, "[[1, 2, 2], [1.000000, 2.000000, 3.000000, 4.000000]]"},
+		{`stats = import("stats")
+		stats.histogram([5, 5], 2)`, "[[0, 2], [4.500000, 5.000000, 5.500000]]"},
+		{`stats = import("stats")
+		stats.correlation([1, 2, 3], [2, 4, 6])`, "1.000000"},
+		{`stats = import("stats")
+		stats.correlation([1, 2, 3], [3, 2, 1])`, "-1.000000"},
+		{`stats = import("stats")
+		stats.mean([])`, "ERROR: mean() takes a non-empty list"},
+		{`stats = import("stats")
+		stats.variance([1])`, "ERROR: variance() takes a list of at least 2 numbers"},
+		{`stats = import("stats")
+		stats.percentile([1], 101)`, "ERROR: percentile() takes a percentage from 0 to 100"},
+		{`stats = import("stats")
+		stats.histogram([1], 0)`, "ERROR: histogram() takes at least 1 bin"},
+		{`stats = import("stats")
+		stats.histogram([1, 2, 3], 100000000000)`, "ERROR: histogram() takes at most 1048576 bins, got 100000000000"},
+		{`stats = import("stats")
+		stats.correlation([1, 2], [1, 2, 3])`, "ERROR: correlation() takes lists of the same length, got 2 and 3"},
+		{`stats = import("stats")
+		stats.correlation([1, 1], [1, 2])`, "ERROR: correlation() is undefined for constant values"},
+		{`stats = import("stats")
+		stats.mean(["a"])`, "ERROR: mean() takes a list of numbers, got String"},
+	}
+
+	for _, tt := range tests {
+		testScript(t, tt.input, tt.expected)
+	}
+}
+
+// Statistics of seeded random samples are the same on every run, and close to
+// the parameters of the distribution.
+func TestStatsOfSeededSamples(t *testing.T) {
+	input := `random = import("random")
+	stats = import("stats")
+	random.seed(2024)
+	xs = range_list(0, 20000) -> @(i) { random.normal(5, 2) }{}
+	ys = xs -> @(x) { 3 * x + random.normal() }{}
+	r = [stats.mean(xs), stats.stddev(xs), stats.median(xs), stats.correlation(xs, ys), stats.histogram(xs, 4)[0]]
+	r`
+	first, err := newScriptState().Eval(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := newScriptState().Eval(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Str() != again.Str() {
+		t.Fatalf("seeded samples gave different statistics: %s and %s", first.Str(), again.Str())
+	}
+
+	// Mean, standard deviation, median and correlation
+	expected := []struct{ low, high float64 }{{4.9, 5.1}, {1.9, 2.1}, {4.9, 5.1}, {0.98, 0.999}}
+	results := first.(*val.ZList).Elements
+	for i, want := range expected {
+		got := results[i].(*val.ZFloat).Value
+		if got < want.low || got > want.high {
+			t.Errorf("statistic %d: got=%f, want in [%f, %f]", i, got, want.low, want.high)
+		}
+	}
+	total := int64(0)
+	for _, count := range results[4].(*val.ZList).Elements {
+		total += count.(*val.ZInt).Value
+	}
+	if total != 20000 {
+		t.Errorf("histogram counts %d values, want 20000", total)
+	}
+}
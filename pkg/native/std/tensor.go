@@ -1,6 +1,17 @@
 package std
 
 import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/ariaghora/zmol/pkg/val"
 	"gorgonia.org/tensor"
 )
@@ -9,32 +20,819 @@ var TensorModule = val.MODULE(
 	"tensor",
 	&val.Env{
 		SymTable: map[string]val.ZValue{
-			"zeros": &val.ZNativeFunc{Fn: Z_tensor_zeros},
+			// Constructors
+			"zeros":     &val.ZNativeFunc{Fn: Z_tensor_zeros},
+			"ones":      &val.ZNativeFunc{Fn: Z_tensor_ones},
+			"full":      &val.ZNativeFunc{Fn: Z_tensor_full},
+			"arange":    &val.ZNativeFunc{Fn: Z_tensor_arange},
+			"from_list": &val.ZNativeFunc{Fn: Z_tensor_from_list},
+			"random":    &val.ZNativeFunc{Fn: Z_tensor_random},
+			// Shape manipulation
+			"shape":     &val.ZNativeFunc{Fn: Z_tensor_shape},
+			"reshape":   &val.ZNativeFunc{Fn: Z_tensor_reshape},
+			"transpose": &val.ZNativeFunc{Fn: Z_tensor_transpose},
+			"slice":     &val.ZNativeFunc{Fn: Z_tensor_slice},
+			"to_list":   &val.ZNativeFunc{Fn: Z_tensor_to_list},
+			// Element types
+			"dtype":  &val.ZNativeFunc{Fn: Z_tensor_dtype},
+			"astype": &val.ZNativeFunc{Fn: Z_tensor_astype},
+			// Linear algebra and reductions
+			"matmul": &val.ZNativeFunc{Fn: Z_tensor_matmul},
+			"sum":    &val.ZNativeFunc{Fn: Z_tensor_sum},
+			"mean":   &val.ZNativeFunc{Fn: Z_tensor_mean},
+			"argmax": &val.ZNativeFunc{Fn: Z_tensor_argmax},
+			// File I/O
+			"load_npy": &val.ZNativeFunc{Fn: Z_tensor_load_npy},
+			"save_npy": &val.ZNativeFunc{Fn: Z_tensor_save_npy},
+			"load_csv": &val.ZNativeFunc{Fn: Z_tensor_load_csv},
+			"save_csv": &val.ZNativeFunc{Fn: Z_tensor_save_csv},
+			// Submodules
+			"linalg": LinalgModule,
 		},
 	},
 )
 
+// Element type of tensors created by the constructors, unless a dtype is given
+var defaultDtype = tensor.Float32
+
+// Returns the dtype named by the last argument if it is a string, and the
+// remaining arguments. All constructors take the dtype this way, e.g.,
+// `zeros(2, 3, "float64")`.
+func dtypeArg(name string, args []val.ZValue) ([]val.ZValue, tensor.Dtype, *val.ZError) {
+	if len(args) == 0 || args[len(args)-1].Type() != val.ZSTRING {
+		return args, defaultDtype, nil
+	}
+	dt, zErr := parseDtype(name, args[len(args)-1])
+	return args[:len(args)-1], dt, zErr
+}
+
+func parseDtype(name string, arg val.ZValue) (tensor.Dtype, *val.ZError) {
+	if arg.Type() == val.ZSTRING {
+		if dt, ok := val.TensorDtypes[arg.(*val.ZString).Value]; ok {
+			return dt, nil
+		}
+	}
+	return tensor.Dtype{}, &val.ZError{Message: name + "() takes a dtype, one of float32, float64, int32, int64, bool"}
+}
+
+// Returns the shape given either as a single list of integers, or as integer
+// arguments.
+func shapeArgs(name string, args []val.ZValue) ([]int, *val.ZError) {
+	if len(args) == 1 && args[0].Type() == val.ZLIST {
+		args = args[0].(*val.ZList).Elements
+	}
+	if len(args) == 0 {
+		return nil, &val.ZError{Message: name + "() takes a shape"}
+	}
+
+	shape := make([]int, len(args))
+	for i, v := range args {
+		if v.Type() != val.ZINT || v.(*val.ZInt).Value <= 0 {
+			return nil, &val.ZError{Message: name + "() takes a shape of positive integers"}
+		}
+		shape[i] = int(v.(*val.ZInt).Value)
+	}
+	return shape, nil
+}
+
+// Returns the tensor argument at position i.
+func tensorArg(name string, args []val.ZValue, i int) (*val.ZTensor, *val.ZError) {
+	if len(args) <= i || args[i].Type() != val.ZTENSOR {
+		return nil, &val.ZError{Message: fmt.Sprintf("%s() takes a tensor as argument %d", name, i+1)}
+	}
+	return args[i].(*val.ZTensor), nil
+}
+
+// Returns an axis argument, counting from the end if negative.
+func axisArg(name string, arg val.ZValue, ndim int) (int, *val.ZError) {
+	if arg.Type() != val.ZINT {
+		return 0, &val.ZError{Message: name + "() takes an integer axis"}
+	}
+	axis := int(arg.(*val.ZInt).Value)
+	if axis < 0 {
+		axis += ndim
+	}
+	if axis < 0 || axis >= ndim {
+		return 0, &val.ZError{Message: fmt.Sprintf("%s() axis %d is out of range for %d dimensions", name, arg.(*val.ZInt).Value, ndim)}
+	}
+	return axis, nil
+}
+
+// Creates a tensor of the given shape with all elements set to value.
+func fullTensor(shape []int, value val.ZValue, dt tensor.Dtype) val.ZValue {
+	elem, ok := val.ToTensorElem(dt, value)
+	if !ok {
+		return &val.ZError{Message: "tensor fill value must be a number, got " + string(value.Type())}
+	}
+	t := tensor.New(tensor.Of(dt), tensor.WithShape(shape...))
+	if err := t.Memset(elem); err != nil {
+		return &val.ZError{Message: err.Error()}
+	}
+	return val.TENSOR(t)
+}
+
+// zeros(shape) creates a tensor filled with zeros.
 func Z_tensor_zeros(args ...val.ZValue) val.ZValue {
-	shape := args
+	args, dt, zErr := dtypeArg("zeros", args)
+	if zErr != nil {
+		return zErr
+	}
+	shape, zErr := shapeArgs("zeros", args)
+	if zErr != nil {
+		return zErr
+	}
+	return val.TENSOR(tensor.New(tensor.Of(dt), tensor.WithShape(shape...)))
+}
+
+// ones(shape) creates a tensor filled with ones.
+func Z_tensor_ones(args ...val.ZValue) val.ZValue {
+	args, dt, zErr := dtypeArg("ones", args)
+	if zErr != nil {
+		return zErr
+	}
+	shape, zErr := shapeArgs("ones", args)
+	if zErr != nil {
+		return zErr
+	}
+	return fullTensor(shape, val.INT(1), dt)
+}
 
-	// ensure all shape values are integers
-	for _, v := range shape {
+// full(shape, value) creates a tensor filled with value.
+func Z_tensor_full(args ...val.ZValue) val.ZValue {
+	args, dt, zErr := dtypeArg("full", args)
+	if zErr != nil {
+		return zErr
+	}
+	if len(args) != 2 {
+		return &val.ZError{Message: "full() takes 2 arguments"}
+	}
+	shape, zErr := shapeArgs("full", args[:1])
+	if zErr != nil {
+		return zErr
+	}
+	return fullTensor(shape, args[1], dt)
+}
+
+// arange(stop), arange(start, stop) or arange(start, stop, step) creates a
+// 1-dimensional tensor of evenly spaced values in [start, stop).
+func Z_tensor_arange(args ...val.ZValue) val.ZValue {
+	args, dt, zErr := dtypeArg("arange", args)
+	if zErr != nil {
+		return zErr
+	}
+	if len(args) < 1 || len(args) > 3 {
+		return &val.ZError{Message: "arange() takes 1 to 3 arguments"}
+	}
+	bounds := []float64{0, 0, 1}
+	offset := 0
+	if len(args) == 1 {
+		offset = 1
+	}
+	for i, arg := range args {
+		f, err := EnsureFloat(arg)
+		if err != nil {
+			return &val.ZError{Message: "arange() takes numbers as arguments"}
+		}
+		bounds[i+offset] = f
+	}
+
+	start, stop, step := bounds[0], bounds[1], bounds[2]
+	if step == 0 {
+		return &val.ZError{Message: "arange() step must not be zero"}
+	}
+	n := int(math.Ceil((stop - start) / step))
+	if n <= 0 {
+		return &val.ZError{Message: "arange() range must not be empty"}
+	}
+
+	values := make([]val.ZValue, n)
+	for i := range values {
+		values[i] = val.FLOAT(start + float64(i)*step)
+	}
+	t, zErr := val.TensorFromList(&val.ZList{Elements: values}, dt)
+	if zErr != nil {
+		return zErr
+	}
+	return t
+}
+
+// from_list(list) creates a tensor from (nested) lists of numbers. The shape
+// is given by the nesting, e.g., [[1, 2, 3], [4, 5, 6]] has shape [2, 3].
+func Z_tensor_from_list(args ...val.ZValue) val.ZValue {
+	args, dt, zErr := dtypeArg("from_list", args)
+	if zErr != nil {
+		return zErr
+	}
+	if len(args) != 1 || args[0].Type() != val.ZLIST {
+		return &val.ZError{Message: "from_list() takes a list"}
+	}
+	t, zErr := val.TensorFromList(args[0].(*val.ZList), dt)
+	if zErr != nil {
+		return zErr
+	}
+	return t
+}
+
+// random(shape) or random(shape, seed) creates a tensor of uniformly
+// distributed values in [0, 1). The same seed always gives the same values.
+func Z_tensor_random(args ...val.ZValue) val.ZValue {
+	args, dt, zErr := dtypeArg("random", args)
+	if zErr != nil {
+		return zErr
+	}
+	if dt != tensor.Float32 && dt != tensor.Float64 {
+		return &val.ZError{Message: "random() takes a float dtype"}
+	}
+	if len(args) != 1 && len(args) != 2 {
+		return &val.ZError{Message: "random() takes 1 or 2 arguments"}
+	}
+	shape, zErr := shapeArgs("random", args[:1])
+	if zErr != nil {
+		return zErr
+	}
+	seed := time.Now().UnixNano()
+	if len(args) == 2 {
+		if args[1].Type() != val.ZINT {
+			return &val.ZError{Message: "random() takes an integer seed"}
+		}
+		seed = args[1].(*val.ZInt).Value
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	t := tensor.New(tensor.Of(dt), tensor.WithShape(shape...))
+	switch data := t.Data().(type) {
+	case []float32:
+		for i := range data {
+			data[i] = rng.Float32()
+		}
+	case []float64:
+		for i := range data {
+			data[i] = rng.Float64()
+		}
+	}
+	return val.TENSOR(t)
+}
+
+// shape(t) returns the shape as a list of integers.
+func Z_tensor_shape(args ...val.ZValue) val.ZValue {
+	t, zErr := tensorArg("shape", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	shape := &val.ZList{}
+	for _, n := range t.Shape() {
+		shape.Elements = append(shape.Elements, val.INT(int64(n)))
+	}
+	return shape
+}
+
+// reshape(t, shape) returns the elements in a new shape. One dimension can be
+// -1, it is then inferred from the number of elements.
+func Z_tensor_reshape(args ...val.ZValue) val.ZValue {
+	t, zErr := tensorArg("reshape", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	dims := args[1:]
+	if len(dims) == 1 && dims[0].Type() == val.ZLIST {
+		dims = dims[0].(*val.ZList).Elements
+	}
+
+	shape := make([]int, len(dims))
+	inferred, known := -1, 1
+	for i, v := range dims {
 		if v.Type() != val.ZINT {
-			return &val.ZError{Message: "shape values must be integers"}
+			return &val.ZError{Message: "reshape() takes a shape of integers"}
+		}
+		shape[i] = int(v.(*val.ZInt).Value)
+		switch {
+		case shape[i] == -1 && inferred < 0:
+			inferred = i
+		case shape[i] <= 0:
+			return &val.ZError{Message: "reshape() takes a shape of positive integers, and at most one -1"}
+		default:
+			known *= shape[i]
+		}
+	}
+	if inferred >= 0 {
+		shape[inferred] = t.Shape().TotalSize() / known
+	}
+
+	d := t.Dense().Clone().(*tensor.Dense)
+	if err := d.Reshape(shape...); err != nil {
+		return &val.ZError{Message: fmt.Sprintf("cannot reshape tensor of shape %v into %v", t.Shape(), tensor.Shape(shape))}
+	}
+	return val.TENSOR(d)
+}
+
+// transpose(t) reverses the axes. transpose(t, axes...) permutes the axes
+// in the given order.
+func Z_tensor_transpose(args ...val.ZValue) val.ZValue {
+	t, zErr := tensorArg("transpose", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	axes := make([]int, len(args)-1)
+	for i, v := range args[1:] {
+		if axes[i], zErr = axisArg("transpose", v, t.Shape().Dims()); zErr != nil {
+			return zErr
+		}
+	}
+
+	d := t.Dense().Clone().(*tensor.Dense)
+	if t.Shape().Dims() < 2 {
+		return val.TENSOR(d)
+	}
+	if err := d.T(axes...); err != nil {
+		return &val.ZError{Message: err.Error()}
+	}
+	if err := d.Transpose(); err != nil {
+		return &val.ZError{Message: err.Error()}
+	}
+	return val.TENSOR(d)
+}
+
+// Converts a slice argument, either an index or a list [start, stop] or
+// [start, stop, step], into a range along an axis of size n. Negative indices
+// count from the end.
+func tensorRangeArg(arg val.ZValue, n int) (val.TensorRange, *val.ZError) {
+	normalize := func(i int64) int {
+		if i < 0 {
+			i += int64(n)
+		}
+		return int(i)
+	}
+
+	switch arg := arg.(type) {
+	case *val.ZInt:
+		i := normalize(arg.Value)
+		if i < 0 || i >= n {
+			return val.TensorRange{}, &val.ZError{Message: fmt.Sprintf("index %d out of range for axis of size %d", arg.Value, n)}
+		}
+		return val.TensorRange{Start: i, Stop: i + 1, Step: 1, Single: true}, nil
+	case *val.ZList:
+		bounds := []int64{0, 0, 1}
+		valid := len(arg.Elements) == 2 || len(arg.Elements) == 3
+		for i, e := range arg.Elements {
+			if !valid || e.Type() != val.ZINT {
+				valid = false
+				break
+			}
+			bounds[i] = e.(*val.ZInt).Value
+		}
+		if valid {
+			start, stop := normalize(bounds[0]), normalize(bounds[1])
+			if start < 0 {
+				start = 0
+			}
+			if stop > n {
+				stop = n
+			}
+			if bounds[2] > 0 {
+				return val.TensorRange{Start: start, Stop: stop, Step: int(bounds[2])}, nil
+			}
+		}
+	}
+	return val.TensorRange{}, &val.ZError{Message: "slice() takes indices or [start, stop, step] ranges with a positive step"}
+}
+
+// slice(t, ranges...) selects elements along the leading axes. Each range is
+// either an index, which removes the axis, or a list [start, stop] or
+// [start, stop, step].
+func Z_tensor_slice(args ...val.ZValue) val.ZValue {
+	t, zErr := tensorArg("slice", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	shape := t.Shape()
+	if len(args)-1 > shape.Dims() {
+		return &val.ZError{Message: fmt.Sprintf("slice() takes at most %d ranges for tensor of shape %v", shape.Dims(), shape)}
+	}
+
+	ranges := make([]val.TensorRange, len(args)-1)
+	for i, arg := range args[1:] {
+		if ranges[i], zErr = tensorRangeArg(arg, shape[i]); zErr != nil {
+			return zErr
+		}
+	}
+	return t.Slice(ranges)
+}
+
+// to_list(t) converts a tensor to nested lists.
+func Z_tensor_to_list(args ...val.ZValue) val.ZValue {
+	t, zErr := tensorArg("to_list", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	return t.ToList()
+}
+
+// matmul(a, b) returns the matrix product of matrices and vectors, or the
+// inner product of two vectors.
+func Z_tensor_matmul(args ...val.ZValue) val.ZValue {
+	a, zErr := tensorArg("matmul", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	b, zErr := tensorArg("matmul", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+	if a.Shape().Dims() > 2 || b.Shape().Dims() > 2 {
+		return &val.ZError{Message: "matmul() takes vectors or matrices"}
+	}
+	dt := val.PromoteDtypes(a.Dtype(), b.Dtype())
+	result, err := tensor.Dot(a.AsType(dt).Dense(), b.AsType(dt).Dense())
+	if err != nil {
+		return &val.ZError{Message: fmt.Sprintf("matmul() cannot multiply shapes %v and %v", a.Shape(), b.Shape())}
+	}
+	return val.TensorResult(result, nil)
+}
+
+// dtype(t) returns the name of the element type.
+func Z_tensor_dtype(args ...val.ZValue) val.ZValue {
+	t, zErr := tensorArg("dtype", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	return val.STRING(val.DtypeName(t.Dtype()))
+}
+
+// astype(t, dtype) converts the elements to another element type. Floats are
+// truncated toward zero when converted to integers.
+func Z_tensor_astype(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: "astype() takes 2 arguments"}
+	}
+	t, zErr := tensorArg("astype", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	dt, zErr := parseDtype("astype", args[1])
+	if zErr != nil {
+		return zErr
+	}
+	return t.AsType(dt)
+}
+
+// Returns the tensor argument and the optional axis argument of a reduction.
+// The axis is -1 if it is not given.
+func reductionArgs(name string, args []val.ZValue) (*val.ZTensor, int, *val.ZError) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, 0, &val.ZError{Message: name + "() takes 1 or 2 arguments"}
+	}
+	t, zErr := tensorArg(name, args, 0)
+	if zErr != nil {
+		return nil, 0, zErr
+	}
+	axis := -1
+	if len(args) == 2 {
+		if axis, zErr = axisArg(name, args[1], t.Shape().Dims()); zErr != nil {
+			return nil, 0, zErr
 		}
 	}
+	return t, axis, nil
+}
 
-	// convert shape to []int
-	shapeInt := make([]int, len(shape))
-	for i, v := range shape {
-		shapeInt[i] = int(v.(*val.ZInt).Value)
+// Sums all elements, or along an axis if it is not negative.
+func sumTensor(t *val.ZTensor, axis int) val.ZValue {
+	if axis < 0 {
+		return val.TensorResult(t.Dense().Sum())
 	}
+	return val.TensorResult(t.Dense().Sum(axis))
+}
 
-	tensor := tensor.New(
-		tensor.Of(tensor.Float32), tensor.WithShape(shapeInt...),
-	)
+// sum(t) returns the sum of all elements. sum(t, axis) sums along an axis.
+// Bools are summed as integers, so the sum counts the true elements.
+func Z_tensor_sum(args ...val.ZValue) val.ZValue {
+	t, axis, zErr := reductionArgs("sum", args)
+	if zErr != nil {
+		return zErr
+	}
+	if t.Dtype() == tensor.Bool {
+		t = t.AsType(tensor.Int64)
+	}
+	return sumTensor(t, axis)
+}
 
-	return &val.ZTensor{
-		Data: tensor,
+// mean(t) returns the mean of all elements. mean(t, axis) averages along an
+// axis. The mean of integers and bools is a float64.
+func Z_tensor_mean(args ...val.ZValue) val.ZValue {
+	t, axis, zErr := reductionArgs("mean", args)
+	if zErr != nil {
+		return zErr
+	}
+	if t.Dtype() != tensor.Float32 && t.Dtype() != tensor.Float64 {
+		t = t.AsType(tensor.Float64)
+	}
+	sum := sumTensor(t, axis)
+	if sum.Type() == val.ZERROR {
+		return sum
+	}
+
+	n := t.Shape().TotalSize()
+	if axis >= 0 {
+		n = t.Shape()[axis]
+	}
+	if sum.Type() == val.ZTENSOR {
+		return sum.(*val.ZTensor).Div(val.INT(int64(n)))
+	}
+	total, _ := EnsureFloat(sum)
+	return val.FLOAT(total / float64(n))
+}
+
+// argmax(t) returns the index of the largest element in the flattened tensor.
+// argmax(t, axis) returns the indices of the largest elements along an axis.
+func Z_tensor_argmax(args ...val.ZValue) val.ZValue {
+	t, axis, zErr := reductionArgs("argmax", args)
+	if zErr != nil {
+		return zErr
+	}
+	if t.Dtype() == tensor.Bool {
+		t = t.AsType(tensor.Int64)
+	}
+	if axis < 0 {
+		axis = tensor.AllAxes
+	}
+	return val.TensorResult(t.Dense().Argmax(axis))
+}
+
+// save_npy(t, path) writes a tensor to a NumPy .npy file.
+func Z_tensor_save_npy(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 || args[1].Type() != val.ZSTRING {
+		return &val.ZError{Message: "save_npy() takes a tensor and a path"}
+	}
+	t, zErr := tensorArg("save_npy", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+
+	f, err := os.Create(args[1].(*val.ZString).Value)
+	if err != nil {
+		return fileError("save_npy", err)
+	}
+	defer f.Close()
+	if err := writeNpy(f, t); err != nil {
+		return fileError("save_npy", err)
+	}
+	return &val.ZNull{}
+}
+
+// load_npy(path) reads a tensor from a NumPy .npy file, with its dtype and
+// shape.
+func Z_tensor_load_npy(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 || args[0].Type() != val.ZSTRING {
+		return &val.ZError{Message: "load_npy() takes a path"}
+	}
+
+	f, err := os.Open(args[0].(*val.ZString).Value)
+	if err != nil {
+		return fileError("load_npy", err)
+	}
+	defer f.Close()
+	t, err := readNpy(f)
+	if err != nil {
+		return fileError("load_npy", err)
+	}
+	return t
+}
+
+// save_csv(t, path[, header[, delimiter]]) writes a matrix to a CSV file, one
+// row per line. A vector is written as a single column. The header is a list
+// of column names, and the delimiter defaults to a comma. The first line is a
+// comment with the dtype and shape, e.g., "# dtype=float32 shape=3", so that
+// load_csv() returns the same tensor.
+func Z_tensor_save_csv(args ...val.ZValue) val.ZValue {
+	if len(args) < 2 || len(args) > 4 || args[1].Type() != val.ZSTRING {
+		return &val.ZError{Message: "save_csv() takes a tensor, a path, and optionally a header and a delimiter"}
+	}
+	t, zErr := tensorArg("save_csv", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	shape := t.Shape()
+	if shape.Dims() > 2 {
+		return &val.ZError{Message: fmt.Sprintf("save_csv() takes a vector or a matrix, got shape %v", shape)}
+	}
+	cols := 1
+	if shape.Dims() == 2 {
+		cols = shape[1]
+	}
+
+	var header []string
+	if len(args) >= 3 {
+		if args[2].Type() != val.ZLIST {
+			return &val.ZError{Message: "save_csv() takes a list of column names as header"}
+		}
+		for _, name := range args[2].(*val.ZList).Elements {
+			header = append(header, name.Str())
+		}
+		if len(header) > 0 && len(header) != cols {
+			return &val.ZError{Message: fmt.Sprintf("save_csv() header has %d names for %d columns", len(header), cols)}
+		}
+	}
+	delimiter, zErr := delimiterArg("save_csv", args, 3)
+	if zErr != nil {
+		return zErr
+	}
+
+	f, err := os.Create(args[1].(*val.ZString).Value)
+	if err != nil {
+		return fileError("save_csv", err)
+	}
+	defer f.Close()
+
+	fmt.Fprintf(f, "%c dtype=%s shape=%s\n", csvMetaPrefix, val.DtypeName(t.Dtype()), formatCSVShape(shape))
+	w := csv.NewWriter(f)
+	w.Comma = delimiter
+	if len(header) > 0 {
+		w.Write(header)
+	}
+	elements := t.Elements()
+	for i := 0; i < len(elements); i += cols {
+		record := make([]string, cols)
+		for j, e := range elements[i : i+cols] {
+			record[j] = csvField(e, t.Dtype())
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fileError("save_csv", err)
+	}
+	return &val.ZNull{}
+}
+
+// Formats an element for CSV. Floats always have a decimal point or an
+// exponent, so that they are read back as floats.
+func csvField(e val.ZValue, dt tensor.Dtype) string {
+	f, ok := e.(*val.ZFloat)
+	if !ok {
+		return e.Str()
+	}
+	bitSize := 64
+	if dt == tensor.Float32 {
+		bitSize = 32
+	}
+	text := strconv.FormatFloat(f.Value, 'g', -1, bitSize)
+	if !strings.ContainsAny(text, ".eNI") {
+		text += ".0"
+	}
+	return text
+}
+
+// Starts the comment line written by save_csv(), which NumPy's loadtxt()
+// skips too.
+const csvMetaPrefix = '#'
+
+// Formats a shape for the comment line of save_csv(), e.g., "2x3".
+func formatCSVShape(shape tensor.Shape) string {
+	dims := make([]string, len(shape))
+	for i, n := range shape {
+		dims[i] = strconv.Itoa(n)
+	}
+	return strings.Join(dims, "x")
+}
+
+// Reads the comment line written by save_csv(), if the file starts with one,
+// and returns its dtype and shape. The shape is nil if there is no comment.
+func readCSVMeta(r *bufio.Reader) (tensor.Dtype, tensor.Shape, *val.ZError) {
+	if next, err := r.Peek(1); err != nil || next[0] != csvMetaPrefix {
+		return tensor.Dtype{}, nil, nil
+	}
+	line, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return tensor.Dtype{}, nil, fileError("load_csv", err)
+	}
+
+	var dt tensor.Dtype
+	shape := tensor.Shape{}
+	hasDtype, hasShape := false, false
+	for _, field := range strings.Fields(line[1:]) {
+		key, value, _ := strings.Cut(field, "=")
+		switch key {
+		case "dtype":
+			dt, hasDtype = val.TensorDtypes[value]
+		case "shape":
+			hasShape = true
+			if value == "" {
+				continue
+			}
+			for _, dim := range strings.Split(value, "x") {
+				n, err := strconv.Atoi(dim)
+				if err != nil || n < 0 {
+					hasShape = false
+					break
+				}
+				shape = append(shape, n)
+			}
+		}
+	}
+	if !hasDtype || !hasShape {
+		return tensor.Dtype{}, nil, &val.ZError{Message: fmt.Sprintf("load_csv() cannot parse the comment line %q", strings.TrimSpace(line))}
+	}
+	return dt, shape, nil
+}
+
+// load_csv(path[, header[, delimiter[, dtype]]]) reads a matrix from a CSV
+// file. If header is true, the first line after the comment line of
+// save_csv() is skipped. The dtype and shape are those of the comment line.
+// Without it, the result is a matrix, and unless a dtype is given, it is
+// inferred from the values: bool if all are true or false, int64 if all are
+// integers, and float64 otherwise.
+func Z_tensor_load_csv(args ...val.ZValue) val.ZValue {
+	if len(args) < 1 || len(args) > 4 || args[0].Type() != val.ZSTRING {
+		return &val.ZError{Message: "load_csv() takes a path, and optionally a header flag, a delimiter and a dtype"}
+	}
+	hasHeader := false
+	if len(args) >= 2 {
+		if args[1].Type() != val.ZBOOL {
+			return &val.ZError{Message: "load_csv() takes a boolean header flag"}
+		}
+		hasHeader = args[1].(*val.ZBool).Value
+	}
+	delimiter, zErr := delimiterArg("load_csv", args, 2)
+	if zErr != nil {
+		return zErr
+	}
+
+	f, err := os.Open(args[0].(*val.ZString).Value)
+	if err != nil {
+		return fileError("load_csv", err)
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	metaDtype, metaShape, zErr := readCSVMeta(br)
+	if zErr != nil {
+		return zErr
+	}
+	r := csv.NewReader(br)
+	r.Comma = delimiter
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return fileError("load_csv", err)
+	}
+	if hasHeader && len(records) > 0 {
+		records = records[1:]
+	}
+	if len(records) == 0 {
+		return &val.ZError{Message: "load_csv() file has no rows"}
+	}
+
+	// Parse all fields, and find the narrowest kind of value that fits all
+	isBool, isInt := true, true
+	rows := make([]val.ZValue, len(records))
+	for i, record := range records {
+		row := make([]val.ZValue, len(record))
+		for j, field := range record {
+			field = strings.TrimSpace(field)
+			if b, err := strconv.ParseBool(field); err == nil && (field == "true" || field == "false") {
+				row[j] = val.BOOL(b)
+				continue
+			}
+			isBool = false
+			if n, err := strconv.ParseInt(field, 10, 64); err == nil {
+				row[j] = val.INT(n)
+				continue
+			}
+			isInt = false
+			x, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return &val.ZError{Message: fmt.Sprintf("load_csv() cannot parse %q on row %d", field, i+1)}
+			}
+			row[j] = val.FLOAT(x)
+		}
+		rows[i] = &val.ZList{Elements: row}
+	}
+
+	dt := tensor.Float64
+	switch {
+	case isBool:
+		dt = tensor.Bool
+	case isInt:
+		dt = tensor.Int64
+	}
+	if metaShape != nil {
+		dt = metaDtype
+	}
+	if len(args) == 4 {
+		if dt, zErr = parseDtype("load_csv", args[3]); zErr != nil {
+			return zErr
+		}
+	}
+
+	t, zErr := val.TensorFromList(&val.ZList{Elements: rows}, dt)
+	if zErr != nil {
+		return zErr
+	}
+	if metaShape == nil {
+		return t
+	}
+	d := t.Dense()
+	if metaShape.TotalSize() != d.Shape().TotalSize() || d.Reshape(metaShape...) != nil {
+		return &val.ZError{Message: fmt.Sprintf("load_csv() cannot reshape %v values into the shape %v of the comment line", d.Shape().TotalSize(), metaShape)}
 	}
+	return val.TENSOR(d)
 }
@@ -0,0 +1,120 @@
+package std_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// Each tensor is saved and loaded back in every format, and must keep its
+// dtype, shape and values.
+func TestTensorSaveLoad(t *testing.T) {
+	tensors := []struct {
+		input    string
+		expected string
+	}{
+		{`tensor.from_list([1.5, 2.25, -3], "float32")`, "[float32, [3], [1.500000, 2.250000, -3.000000]]"},
+		{`tensor.from_list([[0.1, 2], [3, 0.000001]], "float64")`, "[float64, [2, 2], [[0.100000, 2.000000], [3.000000, 0.000001]]]"},
+		{`tensor.from_list([[1, -2, 3]], "int64")`, "[int64, [1, 3], [[1, -2, 3]]]"},
+		{`tensor.from_list([[7], [8]], "int32")`, "[int32, [2, 1], [[7], [8]]]"},
+		{`tensor.from_list([true, false], "bool")`, "[bool, [2], [true, false]]"},
+		{`tensor.arange(0, 24, 1, "int64") |> tensor.reshape{2, 3, 4} |> tensor.sum{2}`, "[int64, [2, 3], [[6, 22, 38], [54, 70, 86]]]"},
+		{`tensor.from_list([[0.5, 1]])`, "[float32, [1, 2], [[0.500000, 1.000000]]]"},
+	}
+	formats := []string{
+		`tensor.save_npy(t, path)
+		u = tensor.load_npy(path)`,
+		`tensor.save_csv(t, path)
+		u = tensor.load_csv(path)`,
+		`tensor.save_csv(t, path, [], ";")
+		u = tensor.load_csv(path, false, ";")`,
+	}
+
+	for i, format := range formats {
+		for _, tt := range tensors {
+			state := newScriptState()
+			state.Env.Set("path", val.STRING(filepath.Join(t.TempDir(), "t")))
+			input := `tensor = import("tensor")
+			t = ` + tt.input + "\n" + format + `
+			r = [tensor.dtype(u), tensor.shape(u), tensor.to_list(u)]
+			r`
+			evaluated, err := state.Eval(input)
+			if err != nil {
+				t.Fatalf("%s: %v", input, err)
+			}
+			if evaluated.Str() != tt.expected {
+				t.Errorf("format %d, %s: got=%s, want=%s", i, tt.input, evaluated.Str(), tt.expected)
+			}
+		}
+	}
+}
+
+func TestTensorCSV(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"plain.csv":   "1,2\n3,4\n",
+		"header.csv":  "a;b\n0.5;1\n",
+		"flags.csv":   "true,false\n",
+		"bad.csv":     "1,x\n",
+		"comment.csv": "# dtype=float128 shape=2\n1\n2\n",
+		"size.csv":    "# dtype=int64 shape=3\n1\n2\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	state := newScriptState()
+	state.Env.Set("dir", val.STRING(dir))
+	if _, err := state.Eval(`tensor = import("tensor")
+	fs = import("fs")`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`u = tensor.load_csv(fs.path.join(dir, "plain.csv"))
+		r = [tensor.dtype(u), tensor.shape(u)]
+		r`, "[int64, [2, 2]]"},
+		{`u = tensor.load_csv(fs.path.join(dir, "plain.csv"), false, ",", "float32")
+		tensor.dtype(u)`, "float32"},
+		{`u = tensor.load_csv(fs.path.join(dir, "header.csv"), true, ";")
+		r = [tensor.dtype(u), tensor.to_list(u)]
+		r`, "[float64, [[0.500000, 1.000000]]]"},
+		{`tensor.dtype(tensor.load_csv(fs.path.join(dir, "flags.csv")))`, "bool"},
+		{`tensor.load_csv(fs.path.join(dir, "bad.csv"))`, `ERROR: load_csv() cannot parse "x" on row 1`},
+		{`tensor.load_csv(fs.path.join(dir, "comment.csv"))`, `ERROR: load_csv() cannot parse the comment line "# dtype=float128 shape=2"`},
+		{`tensor.load_csv(fs.path.join(dir, "size.csv"))`, "ERROR: load_csv() cannot reshape 2 values into the shape (3) of the comment line"},
+		{`is_error(tensor.load_csv(fs.path.join(dir, "missing.csv")))`, "true"},
+		{`t = tensor.from_list([1, 2], "int64")
+		tensor.save_csv(t, fs.path.join(dir, "out.csv"), ["a", "b", "c"])`, "ERROR: save_csv() header has 3 names for 1 columns"},
+		{`t = tensor.zeros(2, 2, 2)
+		tensor.save_csv(t, fs.path.join(dir, "out.csv"))`, "ERROR: save_csv() takes a vector or a matrix, got shape (2, 2, 2)"},
+		{`t = tensor.from_list([[1, 2], [3, 4]], "int64")
+		tensor.save_csv(t, fs.path.join(dir, "out.csv"), ["a", "b"], ";")
+		u = tensor.load_csv(fs.path.join(dir, "out.csv"), true, ";")
+		tensor.to_list(u)`, "[[1, 2], [3, 4]]"},
+	}
+
+	for _, tt := range tests {
+		evaluated, err := state.Eval(tt.input)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.input, err)
+		}
+		if evaluated.Str() != tt.expected {
+			t.Errorf("%s: got=%s, want=%s", tt.input, evaluated.Str(), tt.expected)
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "out.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "# dtype=int64 shape=2x2\na;b\n1;2\n3;4\n" {
+		t.Errorf("unexpected file content %q", content)
+	}
+}
@@ -0,0 +1,421 @@
+package std
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // Embedded, so that zones are found on any system
+
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// Times and durations. Layouts for parsing and formatting are either Go
+// layouts such as "2006-01-02 15:04", strftime layouts such as "%Y-%m-%d %H:%M",
+// recognized by the %, or one of the names in timeLayouts. Zones are IANA
+// names such as "Europe/Paris", "UTC", or "Local".
+var TimeModule = val.MODULE(
+	"time",
+	&val.Env{
+		SymTable: map[string]val.ZValue{
+			// Durations
+			"NANOSECOND":  val.DURATION(time.Nanosecond),
+			"MICROSECOND": val.DURATION(time.Microsecond),
+			"MILLISECOND": val.DURATION(time.Millisecond),
+			"SECOND":      val.DURATION(time.Second),
+			"MINUTE":      val.DURATION(time.Minute),
+			"HOUR":        val.DURATION(time.Hour),
+			"duration":    &val.ZNativeFunc{Fn: Z_time_duration},
+			// Creation
+			"now":       &val.ZNativeFunc{Fn: Z_time_now},
+			"date":      &val.ZNativeFunc{Fn: Z_time_date},
+			"unix":      &val.ZNativeFunc{Fn: Z_time_unix},
+			"from_unix": &val.ZNativeFunc{Fn: Z_time_from_unix},
+			// Parsing and formatting
+			"parse":  &val.ZNativeFunc{Fn: Z_time_parse},
+			"format": &val.ZNativeFunc{Fn: Z_time_format},
+			// Arithmetic and zones
+			"add_date": &val.ZNativeFunc{Fn: Z_time_add_date},
+			"in_zone":  &val.ZNativeFunc{Fn: Z_time_in_zone},
+			"since":    &val.ZNativeFunc{Fn: Z_time_since},
+			"until":    &val.ZNativeFunc{Fn: Z_time_until},
+			// Clocks
+			"sleep":     &val.ZNativeFunc{Fn: Z_time_sleep},
+			"monotonic": &val.ZNativeFunc{Fn: Z_time_monotonic},
+		},
+	},
+)
+
+var timeLayouts = map[string]string{
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"RFC1123":     time.RFC1123,
+	"RFC822":      time.RFC822,
+	"ANSIC":       time.ANSIC,
+	"Kitchen":     time.Kitchen,
+	"DateTime":    "2006-01-02 15:04:05",
+	"DateOnly":    "2006-01-02",
+	"TimeOnly":    "15:04:05",
+}
+
+// The Go layouts of the strftime directives.
+var strftimeDirectives = map[byte]string{
+	'Y': "2006",
+	'y': "06",
+	'm': "01",
+	'd': "02",
+	'e': "_2",
+	'j': "002",
+	'H': "15",
+	'I': "03",
+	'M': "04",
+	'S': "05",
+	'f': "000000",
+	'p': "PM",
+	'b': "Jan",
+	'B': "January",
+	'a': "Mon",
+	'A': "Monday",
+	'Z': "MST",
+	'z': "-0700",
+}
+
+// The start of the monotonic clock.
+var clockStart = time.Now()
+
+// A part of a strftime layout, either the Go layout of a directive or literal
+// text.
+type layoutPart struct {
+	layout  string
+	literal string
+}
+
+// Splits a strftime layout into its directives and literal text.
+func strftimeParts(layout string) ([]layoutPart, error) {
+	var parts []layoutPart
+	var literal strings.Builder
+	for i := 0; i < len(layout); i++ {
+		if layout[i] != '%' {
+			literal.WriteByte(layout[i])
+			continue
+		}
+		if i+1 == len(layout) {
+			return nil, fmt.Errorf("layout %q ends with %%", layout)
+		}
+		i++
+		if layout[i] == '%' {
+			literal.WriteByte('%')
+			continue
+		}
+		goLayout, ok := strftimeDirectives[layout[i]]
+		if !ok {
+			return nil, fmt.Errorf("unknown directive %%%c in layout %q", layout[i], layout)
+		}
+		if literal.Len() > 0 {
+			parts = append(parts, layoutPart{literal: literal.String()})
+			literal.Reset()
+		}
+		parts = append(parts, layoutPart{layout: goLayout})
+	}
+	if literal.Len() > 0 {
+		parts = append(parts, layoutPart{literal: literal.String()})
+	}
+	return parts, nil
+}
+
+// Returns the Go layout for a layout argument. Strftime layouts are converted
+// as a whole, so their literal text must not contain Go layout elements.
+func toGoLayout(layout string) (string, error) {
+	if named, ok := timeLayouts[layout]; ok {
+		return named, nil
+	}
+	if !strings.Contains(layout, "%") {
+		return layout, nil
+	}
+	parts, err := strftimeParts(layout)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for _, part := range parts {
+		sb.WriteString(part.layout + part.literal)
+	}
+	return sb.String(), nil
+}
+
+// Formats a time. Strftime layouts are formatted one directive at a time, so
+// that their literal text is kept as is.
+func formatTime(t time.Time, layout string) (string, error) {
+	if !strings.Contains(layout, "%") {
+		goLayout, err := toGoLayout(layout)
+		return t.Format(goLayout), err
+	}
+	parts, err := strftimeParts(layout)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for _, part := range parts {
+		if part.layout == "" {
+			sb.WriteString(part.literal)
+		} else {
+			sb.WriteString(t.Format(part.layout))
+		}
+	}
+	return sb.String(), nil
+}
+
+// Returns the time argument at position i.
+func timeArg(name string, args []val.ZValue, i int) (time.Time, *val.ZError) {
+	if len(args) <= i || args[i].Type() != val.ZTIME {
+		return time.Time{}, &val.ZError{Message: fmt.Sprintf("%s() takes a time as argument %d", name, i+1)}
+	}
+	return args[i].(*val.ZTime).Value, nil
+}
+
+// Returns the duration argument at position i, either a duration or a number
+// of seconds.
+func durationArg(name string, args []val.ZValue, i int) (time.Duration, *val.ZError) {
+	if len(args) > i {
+		if d, ok := args[i].(*val.ZDuration); ok {
+			return d.Value, nil
+		}
+		if seconds, err := EnsureFloat(args[i]); err == nil {
+			return time.Duration(seconds * float64(time.Second)), nil
+		}
+	}
+	return 0, &val.ZError{Message: fmt.Sprintf("%s() takes a duration or a number of seconds as argument %d", name, i+1)}
+}
+
+// Returns the zone argument at position i, or the local zone if there is none.
+func zoneArg(name string, args []val.ZValue, i int) (*time.Location, *val.ZError) {
+	if len(args) <= i {
+		return time.Local, nil
+	}
+	zone, zErr := stringArg(name, args, i)
+	if zErr != nil {
+		return nil, zErr
+	}
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, &val.ZError{Message: fmt.Sprintf("%s() unknown time zone %q", name, zone)}
+	}
+	return loc, nil
+}
+
+// duration(text) parses a duration such as "1h30m", "250ms" or "-2.5s".
+func Z_time_duration(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "duration() takes 1 argument"}
+	}
+	text, zErr := stringArg("duration", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	d, err := time.ParseDuration(text)
+	if err != nil {
+		return &val.ZError{Message: fmt.Sprintf("duration() invalid duration %q", text)}
+	}
+	return val.DURATION(d)
+}
+
+// now([zone]) returns the current time, in the local zone unless given.
+func Z_time_now(args ...val.ZValue) val.ZValue {
+	if len(args) > 1 {
+		return &val.ZError{Message: "now() takes at most 1 argument"}
+	}
+	loc, zErr := zoneArg("now", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	return val.TIME(time.Now().In(loc))
+}
+
+// date(year, month, day[, hour[, minute[, second[, nanosecond]]]][, zone])
+// returns the time at a date, in the local zone unless given. Out of range
+// values are normalized, e.g., October 32 is November 1.
+func Z_time_date(args ...val.ZValue) val.ZValue {
+	var zone []val.ZValue
+	if len(args) > 0 && args[len(args)-1].Type() == val.ZSTRING {
+		args, zone = args[:len(args)-1], args[len(args)-1:]
+	}
+	if len(args) < 3 || len(args) > 7 {
+		return &val.ZError{Message: "date() takes a year, a month and a day, and optionally a time of day and a zone"}
+	}
+	fields := make([]int, 7)
+	for i, arg := range args {
+		if arg.Type() != val.ZINT {
+			return &val.ZError{Message: fmt.Sprintf("date() takes an integer as argument %d", i+1)}
+		}
+		fields[i] = int(arg.(*val.ZInt).Value)
+	}
+	loc, zErr := zoneArg("date", zone, 0)
+	if zErr != nil {
+		return zErr
+	}
+	return val.TIME(time.Date(fields[0], time.Month(fields[1]), fields[2], fields[3], fields[4], fields[5], fields[6], loc))
+}
+
+// unix() returns the current Unix time, in seconds with a fractional part.
+func Z_time_unix(args ...val.ZValue) val.ZValue {
+	if len(args) != 0 {
+		return &val.ZError{Message: "unix() takes no arguments"}
+	}
+	return val.FLOAT(float64(time.Now().UnixNano()) / float64(time.Second))
+}
+
+// from_unix(seconds[, zone]) returns the time at a Unix time in seconds, in
+// the local zone unless given.
+func Z_time_from_unix(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 && len(args) != 2 {
+		return &val.ZError{Message: "from_unix() takes 1 or 2 arguments"}
+	}
+	loc, zErr := zoneArg("from_unix", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+	if args[0].Type() == val.ZINT {
+		return val.TIME(time.Unix(args[0].(*val.ZInt).Value, 0).In(loc))
+	}
+	seconds, zErr := floatArg("from_unix", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	return val.TIME(time.Unix(0, int64(seconds*float64(time.Second))).In(loc))
+}
+
+// parse(text[, layout[, zone]]) parses a time, by default in RFC 3339 format.
+// Texts without a zone offset are read in the local zone unless given.
+func Z_time_parse(args ...val.ZValue) val.ZValue {
+	if len(args) < 1 || len(args) > 3 {
+		return &val.ZError{Message: "parse() takes 1 to 3 arguments"}
+	}
+	text, zErr := stringArg("parse", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	layout := time.RFC3339
+	if len(args) > 1 {
+		if layout, zErr = stringArg("parse", args, 1); zErr != nil {
+			return zErr
+		}
+	}
+	loc, zErr := zoneArg("parse", args, 2)
+	if zErr != nil {
+		return zErr
+	}
+
+	goLayout, err := toGoLayout(layout)
+	if err != nil {
+		return &val.ZError{Message: "parse() " + err.Error()}
+	}
+	t, err := time.ParseInLocation(goLayout, text, loc)
+	if err != nil {
+		return &val.ZError{Message: fmt.Sprintf("parse() cannot parse %q with layout %q", text, layout)}
+	}
+	return val.TIME(t)
+}
+
+// format(t[, layout]) formats a time, by default in RFC 3339 format.
+func Z_time_format(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 && len(args) != 2 {
+		return &val.ZError{Message: "format() takes 1 or 2 arguments"}
+	}
+	t, zErr := timeArg("format", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	layout := time.RFC3339
+	if len(args) > 1 {
+		if layout, zErr = stringArg("format", args, 1); zErr != nil {
+			return zErr
+		}
+	}
+	text, err := formatTime(t, layout)
+	if err != nil {
+		return &val.ZError{Message: "format() " + err.Error()}
+	}
+	return val.STRING(text)
+}
+
+// add_date(t, years, months, days) adds calendar years, months and days, e.g.,
+// one month after January 31 is March 2 or 3. The time of day is kept.
+func Z_time_add_date(args ...val.ZValue) val.ZValue {
+	if len(args) != 4 {
+		return &val.ZError{Message: "add_date() takes 4 arguments"}
+	}
+	t, zErr := timeArg("add_date", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	var delta [3]int
+	for i := range delta {
+		if args[i+1].Type() != val.ZINT {
+			return &val.ZError{Message: fmt.Sprintf("add_date() takes an integer as argument %d", i+2)}
+		}
+		delta[i] = int(args[i+1].(*val.ZInt).Value)
+	}
+	return val.TIME(t.AddDate(delta[0], delta[1], delta[2]))
+}
+
+// in_zone(t, zone) returns the same instant in another zone.
+func Z_time_in_zone(args ...val.ZValue) val.ZValue {
+	if len(args) != 2 {
+		return &val.ZError{Message: "in_zone() takes 2 arguments"}
+	}
+	t, zErr := timeArg("in_zone", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	loc, zErr := zoneArg("in_zone", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+	return val.TIME(t.In(loc))
+}
+
+// since(t) returns the duration elapsed since a time.
+func Z_time_since(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "since() takes 1 argument"}
+	}
+	t, zErr := timeArg("since", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	return val.DURATION(time.Since(t))
+}
+
+// until(t) returns the duration until a time.
+func Z_time_until(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "until() takes 1 argument"}
+	}
+	t, zErr := timeArg("until", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	return val.DURATION(time.Until(t))
+}
+
+// sleep(d) pauses for a duration, or a number of seconds.
+func Z_time_sleep(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 {
+		return &val.ZError{Message: "sleep() takes 1 argument"}
+	}
+	d, zErr := durationArg("sleep", args, 0)
+	if zErr != nil {
+		return zErr
+	}
+	time.Sleep(d)
+	return &val.ZNull{}
+}
+
+// monotonic() returns the duration since an arbitrary point, from a clock that
+// is not affected by changes of the system time. The difference of two
+// readings measures the time elapsed between them.
+func Z_time_monotonic(args ...val.ZValue) val.ZValue {
+	if len(args) != 0 {
+		return &val.ZError{Message: "monotonic() takes no arguments"}
+	}
+	return val.DURATION(time.Since(clockStart))
+}
@@ -1,7 +1,10 @@
 package native
 
 import (
+	"fmt"
 	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"github.com/ariaghora/zmol/pkg/val"
 )
@@ -29,3 +32,118 @@ func Z_split(args ...val.ZValue) val.ZValue {
 	}
 
 }
+
+// The text encodings of encode() and decode(), by their names.
+var encodings = map[string]string{
+	"utf-8":      "utf-8",
+	"utf8":       "utf-8",
+	"ascii":      "ascii",
+	"latin-1":    "latin-1",
+	"latin1":     "latin-1",
+	"iso-8859-1": "latin-1",
+	"utf-16le":   "utf-16le",
+	"utf-16be":   "utf-16be",
+}
+
+// Returns the encoding argument at position i, or UTF-8 if there is none.
+func encodingArg(name string, args []val.ZValue, i int) (string, *val.ZError) {
+	if len(args) <= i {
+		return "utf-8", nil
+	}
+	if args[i].Type() != val.ZSTRING {
+		return "", &val.ZError{Message: name + "() takes an encoding name as argument 2"}
+	}
+	enc, ok := encodings[strings.ToLower(args[i].(*val.ZString).Value)]
+	if !ok {
+		return "", &val.ZError{Message: fmt.Sprintf("%s() unknown encoding %q", name, args[i].(*val.ZString).Value)}
+	}
+	return enc, nil
+}
+
+// encode(s[, encoding]) returns the bytes of a string in an encoding, UTF-8 by
+// default.
+func Z_encode(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 && len(args) != 2 {
+		return &val.ZError{Message: "encode() takes 1 or 2 arguments"}
+	}
+	if args[0].Type() != val.ZSTRING {
+		return &val.ZError{Message: "encode() takes a string as argument 1"}
+	}
+	enc, zErr := encodingArg("encode", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+
+	s := args[0].(*val.ZString).Value
+	var out []byte
+	switch enc {
+	case "utf-8":
+		out = []byte(s)
+	case "ascii", "latin-1":
+		limit := rune(0x7f)
+		if enc == "latin-1" {
+			limit = 0xff
+		}
+		for _, r := range s {
+			if r > limit {
+				return &val.ZError{Message: fmt.Sprintf("encode() cannot encode %q in %s", r, enc)}
+			}
+			out = append(out, byte(r))
+		}
+	case "utf-16le", "utf-16be":
+		for _, unit := range utf16.Encode([]rune(s)) {
+			if enc == "utf-16le" {
+				out = append(out, byte(unit), byte(unit>>8))
+			} else {
+				out = append(out, byte(unit>>8), byte(unit))
+			}
+		}
+	}
+	return val.BYTES(out)
+}
+
+// decode(b[, encoding]) returns the string encoded by bytes in an encoding,
+// UTF-8 by default. Invalid data results in an error.
+func Z_decode(args ...val.ZValue) val.ZValue {
+	if len(args) != 1 && len(args) != 2 {
+		return &val.ZError{Message: "decode() takes 1 or 2 arguments"}
+	}
+	if args[0].Type() != val.ZBYTES {
+		return &val.ZError{Message: "decode() takes bytes as argument 1"}
+	}
+	enc, zErr := encodingArg("decode", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+
+	b := args[0].(*val.ZBytes).Value
+	switch enc {
+	case "utf-8":
+		if !utf8.Valid(b) {
+			return &val.ZError{Message: "decode() invalid utf-8 data"}
+		}
+		return val.STRING(string(b))
+	case "ascii", "latin-1":
+		runes := make([]rune, len(b))
+		for i, c := range b {
+			if enc == "ascii" && c > 0x7f {
+				return &val.ZError{Message: fmt.Sprintf("decode() invalid ascii byte 0x%02x at index %d", c, i)}
+			}
+			runes[i] = rune(c)
+		}
+		return val.STRING(string(runes))
+	default:
+		if len(b)%2 != 0 {
+			return &val.ZError{Message: "decode() " + enc + " data with an odd number of bytes"}
+		}
+		units := make([]uint16, len(b)/2)
+		for i := range units {
+			if enc == "utf-16le" {
+				units[i] = uint16(b[2*i]) | uint16(b[2*i+1])<<8
+			} else {
+				units[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+			}
+		}
+		return val.STRING(string(utf16.Decode(units)))
+	}
+}
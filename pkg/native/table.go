@@ -0,0 +1,88 @@
+package native
+
+import (
+	"github.com/ariaghora/zmol/pkg/val"
+)
+
+// table() returns an empty table, and table(pairs) a table with the given
+// [key, value] pairs.
+func Z_table(args ...val.ZValue) val.ZValue {
+	if len(args) > 1 {
+		return &val.ZError{Message: "table takes 0 or 1 argument"}
+	}
+	table := val.TABLE()
+	if len(args) == 0 {
+		return table
+	}
+
+	if args[0].Type() != val.ZLIST {
+		return &val.ZError{Message: "table takes a list of [key, value] pairs"}
+	}
+	for _, pair := range args[0].(*val.ZList).Elements {
+		pairList, ok := pair.(*val.ZList)
+		if !ok || len(pairList.Elements) != 2 || pairList.Elements[0].Type() != val.ZSTRING {
+			return &val.ZError{Message: "table takes a list of [key, value] pairs with string keys"}
+		}
+		table.Set(pairList.Elements[0].(*val.ZString).Value, pairList.Elements[1])
+	}
+	return table
+}
+
+func tableArg(name string, args []val.ZValue, n int) (*val.ZTable, *val.ZError) {
+	if len(args) != n || args[0].Type() != val.ZTABLE {
+		return nil, &val.ZError{Message: name + " takes a table as first argument"}
+	}
+	return args[0].(*val.ZTable), nil
+}
+
+// keys(t) returns the keys of a table, in insertion order.
+func Z_keys(args ...val.ZValue) val.ZValue {
+	table, zErr := tableArg("keys", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+	keys := make([]val.ZValue, table.Len())
+	for i, key := range table.Keys() {
+		keys[i] = val.STRING(key)
+	}
+	return &val.ZList{Elements: keys}
+}
+
+// values(t) returns the values of a table, in the order of their keys.
+func Z_values(args ...val.ZValue) val.ZValue {
+	table, zErr := tableArg("values", args, 1)
+	if zErr != nil {
+		return zErr
+	}
+	values := make([]val.ZValue, table.Len())
+	for i, key := range table.Keys() {
+		values[i], _ = table.Get(key)
+	}
+	return &val.ZList{Elements: values}
+}
+
+// has_key(t, key) returns whether a table has a key.
+func Z_has_key(args ...val.ZValue) val.ZValue {
+	table, zErr := tableArg("has_key", args, 2)
+	if zErr != nil {
+		return zErr
+	}
+	if args[1].Type() != val.ZSTRING {
+		return &val.ZError{Message: "has_key takes a string key"}
+	}
+	_, ok := table.Get(args[1].(*val.ZString).Value)
+	return val.BOOL(ok)
+}
+
+// delete(t, key) removes a key from a table, and returns whether it was
+// present.
+func Z_delete(args ...val.ZValue) val.ZValue {
+	table, zErr := tableArg("delete", args, 2)
+	if zErr != nil {
+		return zErr
+	}
+	if args[1].Type() != val.ZSTRING {
+		return &val.ZError{Message: "delete takes a string key"}
+	}
+	return val.BOOL(table.Delete(args[1].(*val.ZString).Value))
+}
@@ -3,6 +3,7 @@ package parser
 import (
 	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
 	"strings"
 
@@ -15,15 +16,20 @@ const (
 	_ int = iota
 	PrecLowest
 	PrecAssign       // 2: =
-	PrecTernary      // 2: ?:
-	PrecPipeline     // 2: |> >-
-	PrecOr           // 3: ||
-	PrecAnd          // 4: &&
-	PrecEquals       // 8: ==
-	PrecGtLt         // 9: > or <
-	PrecAddSub       // 11: +
-	PrecProd         // 12: *
-	PrecPrefix       // 14: -X or !X
+	PrecTernary      // 3: ?:
+	PrecPipeline     // 4: |> -> >-
+	PrecOr           // 5: ||
+	PrecAnd          // 6: &&
+	PrecEquals       // 7: ==
+	PrecGtLt         // 8: > or <
+	PrecBitOr        // 9: |
+	PrecBitXor       // 10: ^
+	PrecBitAnd       // 11: &
+	PrecShift        // 12: << >>
+	PrecAddSub       // 13: +
+	PrecProd         // 14: *
+	PrecPrefix       // 15: -X or !X
+	PrecPow          // 16: **
 	PrecCall         // 17: myFunction(X)
 	PrecMemberAccess // 18: array[index] or value.field
 )
@@ -49,12 +55,21 @@ var precedences = map[lexer.TokType]int{
 	lexer.TokMap:    PrecPipeline,
 
 	// Arithmetic operators
-	lexer.TokPlus:   PrecAddSub,
-	lexer.TokMinus:  PrecAddSub,
-	lexer.TokSlash:  PrecProd,
-	lexer.TokAster:  PrecProd,
-	lexer.TokMod:    PrecProd,
-	lexer.TokLParen: PrecCall,
+	lexer.TokPlus:     PrecAddSub,
+	lexer.TokMinus:    PrecAddSub,
+	lexer.TokSlash:    PrecProd,
+	lexer.TokAster:    PrecProd,
+	lexer.TokMod:      PrecProd,
+	lexer.TokFloorDiv: PrecProd,
+	lexer.TokPow:      PrecPow,
+	lexer.TokLParen:   PrecCall,
+
+	// Bitwise operators
+	lexer.TokBitOr:  PrecBitOr,
+	lexer.TokBitXor: PrecBitXor,
+	lexer.TokBitAnd: PrecBitAnd,
+	lexer.TokShl:    PrecShift,
+	lexer.TokShr:    PrecShift,
 
 	// Member access operator
 	lexer.TokLBrac: PrecMemberAccess,
@@ -100,11 +115,14 @@ func NewParser(l *lexer.ZLex) *Parser {
 	p.registerPrefix(lexer.TokIdent, p.parseIdentifier)
 	p.registerPrefix(lexer.TokInt, p.parseIntegerLiteral)
 	p.registerPrefix(lexer.TokFloat, p.parseFloatLiteral)
+	p.registerPrefix(lexer.TokDecimal, p.parseDecimalLiteral)
 	p.registerPrefix(lexer.TokString, p.parseStringLiteral)
+	p.registerPrefix(lexer.TokBytes, p.parseBytesLiteral)
 	p.registerPrefix(lexer.TokTrue, p.parseBooleanLiteral)
 	p.registerPrefix(lexer.TokFalse, p.parseBooleanLiteral)
 	p.registerPrefix(lexer.TokPlus, p.parserPrefixExpression)
 	p.registerPrefix(lexer.TokMinus, p.parserPrefixExpression)
+	p.registerPrefix(lexer.TokBitNot, p.parserPrefixExpression)
 	p.registerPrefix(lexer.TokLParen, p.parseGroupedExpression)
 	p.registerPrefix(lexer.TokLBrac, p.parseListLiteral)
 
@@ -126,6 +144,15 @@ func NewParser(l *lexer.ZLex) *Parser {
 	p.registerInfix(lexer.TokSlash, p.parseInfixExpression)
 	p.registerInfix(lexer.TokAssign, p.parseInfixExpression)
 	p.registerInfix(lexer.TokMod, p.parseInfixExpression)
+	p.registerInfix(lexer.TokFloorDiv, p.parseInfixExpression)
+	p.registerInfix(lexer.TokPow, p.parseInfixExpression)
+
+	// Bitwise operators
+	p.registerInfix(lexer.TokBitAnd, p.parseInfixExpression)
+	p.registerInfix(lexer.TokBitOr, p.parseInfixExpression)
+	p.registerInfix(lexer.TokBitXor, p.parseInfixExpression)
+	p.registerInfix(lexer.TokShl, p.parseInfixExpression)
+	p.registerInfix(lexer.TokShr, p.parseInfixExpression)
 
 	// Logical operators
 	p.registerInfix(lexer.TokAnd, p.parseInfixExpression)
@@ -302,6 +329,11 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curTok}
 
 	value, err := strconv.ParseInt(p.curTok.Text, 0, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		// Literals beyond 64 bits are kept as arbitrary-precision integers
+		lit.Big, _ = new(big.Int).SetString(p.curTok.Text, 0)
+		return lit
+	}
 	if err != nil {
 		msg := "Could not parse %q as integer, at line %d, column %d"
 		msg = fmt.Sprintf(msg, p.curTok.Text, p.curTok.Row+1, p.curTok.Col+1)
@@ -329,10 +361,21 @@ func (p *Parser) parseFloatLiteral() ast.Expression {
 	return lit
 }
 
+func (p *Parser) parseDecimalLiteral() ast.Expression {
+	return &ast.DecimalLiteral{
+		Token: p.curTok,
+		Value: strings.TrimSuffix(p.curTok.Text, "d"),
+	}
+}
+
 func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curTok, Value: p.curTok.Text}
 }
 
+func (p *Parser) parseBytesLiteral() ast.Expression {
+	return &ast.BytesLiteral{Token: p.curTok, Value: p.curTok.Text}
+}
+
 func (p *Parser) parseBooleanLiteral() ast.Expression {
 	return &ast.BooleanLiteral{Token: p.curTok, Value: p.curTok.Type == lexer.TokTrue}
 }
@@ -388,6 +431,10 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	}
 
 	prec := p.curPrec()
+	// Exponentiation is right-associative: 2 ** 3 ** 2 is 2 ** (3 ** 2)
+	if p.curTok.Type == lexer.TokPow {
+		prec--
+	}
 	p.nextToken()
 
 	right := p.parseExpression(prec)
@@ -523,19 +570,34 @@ func (p *Parser) parseGroupedExpression() ast.Expression {
 	return exp
 }
 
+// Parses an index expression x[i], or a slice expression x[a:b] where a and b
+// are optional.
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
-	exp := &ast.IndexExpression{
-		Token: p.curTok,
-		Left:  left,
+	token := p.curTok
+	p.nextToken()
+
+	var start ast.Expression
+	if p.curTok.Type != lexer.TokColon {
+		start = p.parseExpression(PrecLowest)
+		if p.peekTok.Type != lexer.TokColon {
+			if !p.expectPeek(lexer.TokRBrac) {
+				return nil
+			}
+			return &ast.IndexExpression{Token: token, Left: left, Index: start}
+		}
+		p.nextToken()
 	}
 
+	exp := &ast.SliceExpression{Token: token, Left: left, Start: start}
+	if p.peekTok.Type == lexer.TokRBrac {
+		p.nextToken()
+		return exp
+	}
 	p.nextToken()
-	exp.Index = p.parseExpression(PrecLowest)
-
+	exp.End = p.parseExpression(PrecLowest)
 	if !p.expectPeek(lexer.TokRBrac) {
 		return nil
 	}
-
 	return exp
 }
 
@@ -560,6 +622,15 @@ func (p *Parser) parsePipelineExpression(list ast.Expression) ast.Expression {
 
 	p.nextToken()
 
+	// `par` before the function of a map or filter stage makes it parallel.
+	// It is not a keyword, so `xs -> par{}` still calls a function named par.
+	isStage := exp.Token.Type == lexer.TokMap || exp.Token.Type == lexer.TokFilter
+	if isStage && p.curTok.Type == lexer.TokIdent && p.curTok.Text == "par" &&
+		(p.peekTok.Type == lexer.TokIdent || p.peekTok.Type == lexer.TokAt || p.peekTok.Type == lexer.TokFn) {
+		exp.Parallel = true
+		p.nextToken()
+	}
+
 	exp.FuncLiteral = p.parseExpression(PrecLowest)
 
 	if !p.expectPeek(lexer.TokLCurl) {
@@ -568,6 +639,13 @@ func (p *Parser) parsePipelineExpression(list ast.Expression) ast.Expression {
 
 	exp.ExtraArgs = p.parseCallArguments(lexer.TokRCurl)
 
+	// A bare `_` among the extra arguments marks where the piped value goes
+	for i, arg := range exp.ExtraArgs {
+		if ident, ok := arg.(*ast.Identifier); ok && ident.Value == "_" {
+			exp.ExtraArgs[i] = &ast.Placeholder{Token: ident.Token}
+		}
+	}
+
 	return exp
 }
 
@@ -501,6 +501,88 @@ func TestParseFunctionCall(t *testing.T) {
 	}
 }
 
+func TestParsePipelinePlaceholder(t *testing.T) {
+	source := `text |> split{_, ","}`
+
+	l := lexer.NewLexer(source)
+	err := l.Lex()
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	p := NewParser(l)
+	program, _ := p.ParseProgram()
+
+	if program == nil {
+		t.Fatalf("ParseProgram() returned nil")
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("Expected statement to be *ast.ExpressionStatement, got %T", program.Statements[0])
+	}
+
+	pipeline, ok := stmt.Expression.(*ast.PipelineExpression)
+	if !ok {
+		t.Fatalf("Expected expression to be *ast.PipelineExpression, got %T", stmt.Expression)
+	}
+
+	if len(pipeline.ExtraArgs) != 2 {
+		t.Fatalf("Expected 2 extra arguments, got %d", len(pipeline.ExtraArgs))
+	}
+
+	if _, ok := pipeline.ExtraArgs[0].(*ast.Placeholder); !ok {
+		t.Errorf("Expected first argument to be *ast.Placeholder, got %T", pipeline.ExtraArgs[0])
+	}
+
+	if _, ok := pipeline.ExtraArgs[1].(*ast.StringLiteral); !ok {
+		t.Errorf("Expected second argument to be *ast.StringLiteral, got %T", pipeline.ExtraArgs[1])
+	}
+}
+
+func TestParseParallelPipeline(t *testing.T) {
+	tests := []struct {
+		source   string
+		parallel bool
+	}{
+		{`xs -> par scale{2}`, true},
+		{`xs >- par @(x) { x > 1 }{}`, true},
+		{`xs >- par fn(x) { x > 1 }{}`, true},
+		{`xs -> par{}`, false},
+		{`xs |> par{}`, false},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.source)
+		if err := l.Lex(); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		p := NewParser(l)
+		program, _ := p.ParseProgram()
+		if program == nil || len(program.Statements) != 1 {
+			t.Fatalf("%s: expected 1 statement", tt.source)
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("Expected statement to be *ast.ExpressionStatement, got %T", program.Statements[0])
+		}
+
+		pipeline, ok := stmt.Expression.(*ast.PipelineExpression)
+		if !ok {
+			t.Fatalf("%s: expected expression to be *ast.PipelineExpression, got %T", tt.source, stmt.Expression)
+		}
+
+		if pipeline.Parallel != tt.parallel {
+			t.Errorf("%s: expected Parallel to be %t", tt.source, tt.parallel)
+		}
+		if !tt.parallel && pipeline.FuncLiteral.Str() != "par" {
+			t.Errorf("%s: expected the function to be par, got %s", tt.source, pipeline.FuncLiteral.Str())
+		}
+	}
+}
+
 func TestParseTernary(t *testing.T) {
 	source := `x ? y : z`
 
@@ -590,3 +672,42 @@ func TestParseList(t *testing.T) {
 		t.Errorf("Expected element to be '3', got %s", list.Elements[2])
 	}
 }
+
+func TestParseSliceExpression(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected string
+	}{
+		{`xs[1:3]`, "(xs[1:3])"},
+		{`xs[:n + 1]`, "(xs[:(n + 1)])"},
+		{`xs[2:]`, "(xs[2:])"},
+		{`xs[:]`, "(xs[:])"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.source)
+		if err := l.Lex(); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		p := NewParser(l)
+		program, _ := p.ParseProgram()
+		if program == nil || len(program.Statements) != 1 {
+			t.Fatalf("%s: expected 1 statement", tt.source)
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("Expected statement to be *ast.ExpressionStatement, got %T", program.Statements[0])
+		}
+
+		slice, ok := stmt.Expression.(*ast.SliceExpression)
+		if !ok {
+			t.Fatalf("Expected expression to be *ast.SliceExpression, got %T", stmt.Expression)
+		}
+
+		if slice.Str() != tt.expected {
+			t.Errorf("Expected %s, got %s", tt.expected, slice.Str())
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package val
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Arbitrary-precision integer type. Integer operations that overflow an Int
+// are promoted to BigInt, and BigInt results that fit in an Int are demoted
+// back, so both types behave as a single integer type.
+type ZBigInt struct {
+	Value *big.Int
+}
+
+func BIGINT(value *big.Int) *ZBigInt {
+	return &ZBigInt{Value: value}
+}
+
+// INTEGER returns an Int if the value fits in 64 bits, or a BigInt otherwise.
+func INTEGER(value *big.Int) ZValue {
+	if value.IsInt64() {
+		return INT(value.Int64())
+	}
+	return BIGINT(value)
+}
+
+// ToBigInt returns the arbitrary-precision value of an Int or a BigInt. The
+// second return value is false for other types.
+func ToBigInt(v ZValue) (*big.Int, bool) {
+	switch v := v.(type) {
+	case *ZInt:
+		return big.NewInt(v.Value), true
+	case *ZBigInt:
+		return v.Value, true
+	}
+	return nil, false
+}
+
+// MaxPowBits bounds the size of the results of `**` on integers and decimals,
+// so that a huge exponent fails at once instead of exhausting time and memory.
+const MaxPowBits = 1 << 24
+
+var bigOne = big.NewInt(1)
+
+// PowTooLarge reports whether base ** exp would have more than about
+// MaxPowBits bits. Powers of 0, 1 and -1 are never too large.
+func PowTooLarge(base, exp *big.Int) bool {
+	if base.CmpAbs(bigOne) <= 0 {
+		return false
+	}
+	if !exp.IsInt64() || exp.Int64() > MaxPowBits {
+		return true
+	}
+	// |base| >= 2^(BitLen - 1), so this underestimates by less than half
+	return int64(base.BitLen()-1)*exp.Int64() > MaxPowBits
+}
+
+func (z *ZBigInt) Type() ZValueType { return ZBIGINT }
+func (z *ZBigInt) Str() string      { return z.Value.String() }
+
+// Float returns the nearest float64 value.
+func (z *ZBigInt) Float() float64 {
+	f, _ := new(big.Float).SetInt(z.Value).Float64()
+	return f
+}
+
+// Compares with another number. The second return value is false if the other
+// value is not a number.
+func (z *ZBigInt) compare(other ZValue) (int, bool) {
+	if other.Type() == ZFLOAT {
+		return new(big.Float).SetInt(z.Value).Cmp(big.NewFloat(other.(*ZFloat).Value)), true
+	}
+	if o, ok := ToBigInt(other); ok {
+		return z.Value.Cmp(o), true
+	}
+	if other.Type() == ZDECIMAL {
+		cmp, ok := other.(*ZDecimal).compare(z)
+		return -cmp, ok
+	}
+	return 0, false
+}
+
+func (z *ZBigInt) Equals(other ZValue) ZValue {
+	if cmp, ok := z.compare(other); ok {
+		return BOOL(cmp == 0)
+	}
+	return ERROR(fmt.Sprintf("Operator '==' not defined for %s and %s", z.Type(), other.Type()))
+}
+
+func (z *ZBigInt) NotEquals(other ZValue) ZValue {
+	if cmp, ok := z.compare(other); ok {
+		return BOOL(cmp != 0)
+	}
+	return ERROR(fmt.Sprintf("Operator '!=' not defined for %s and %s", z.Type(), other.Type()))
+}
+
+func (z *ZBigInt) LessThan(other ZValue) ZValue {
+	if cmp, ok := z.compare(other); ok {
+		return BOOL(cmp < 0)
+	}
+	return ERROR(fmt.Sprintf("Operator '<' not defined for %s and %s", z.Type(), other.Type()))
+}
+
+func (z *ZBigInt) GreaterThan(other ZValue) ZValue {
+	if cmp, ok := z.compare(other); ok {
+		return BOOL(cmp > 0)
+	}
+	return ERROR(fmt.Sprintf("Operator '>' not defined for %s and %s", z.Type(), other.Type()))
+}
+
+func (z *ZBigInt) LessThanEquals(other ZValue) ZValue {
+	if cmp, ok := z.compare(other); ok {
+		return BOOL(cmp <= 0)
+	}
+	return ERROR(fmt.Sprintf("Operator '<=' not defined for %s and %s", z.Type(), other.Type()))
+}
+
+func (z *ZBigInt) GreaterThanEquals(other ZValue) ZValue {
+	if cmp, ok := z.compare(other); ok {
+		return BOOL(cmp >= 0)
+	}
+	return ERROR(fmt.Sprintf("Operator '>=' not defined for %s and %s", z.Type(), other.Type()))
+}
@@ -0,0 +1,98 @@
+package val
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Bytes type, an immutable sequence of bytes for binary data such as digests
+// and file contents. It is shown as `b"..."`, with non-printable bytes
+// escaped.
+type ZBytes struct {
+	Value []byte
+}
+
+func BYTES(value []byte) *ZBytes {
+	return &ZBytes{Value: value}
+}
+
+func (z *ZBytes) Type() ZValueType { return ZBYTES }
+
+func (z *ZBytes) Str() string {
+	var sb strings.Builder
+	sb.WriteString(`b"`)
+	for _, b := range z.Value {
+		switch {
+		case b == '"' || b == '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(b)
+		case b == '\n':
+			sb.WriteString(`\n`)
+		case b == '\t':
+			sb.WriteString(`\t`)
+		case b < 0x20 || b >= 0x7f:
+			fmt.Fprintf(&sb, `\x%02x`, b)
+		default:
+			sb.WriteByte(b)
+		}
+	}
+	sb.WriteString(`"`)
+	return sb.String()
+}
+
+// ToBytes converts bytes, a string, as its UTF-8 encoding, or a list of
+// integers from 0 to 255 to a byte slice.
+func ToBytes(v ZValue) ([]byte, bool) {
+	switch v := v.(type) {
+	case *ZBytes:
+		return v.Value, true
+	case *ZString:
+		return []byte(v.Value), true
+	case *ZList:
+		out := make([]byte, len(v.Elements))
+		for i, e := range v.Elements {
+			n, ok := e.(*ZInt)
+			if !ok || n.Value < 0 || n.Value > 255 {
+				return nil, false
+			}
+			out[i] = byte(n.Value)
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+func (z *ZBytes) compare(operator string, other ZValue) int {
+	o, ok := other.(*ZBytes)
+	if !ok {
+		ERROR(fmt.Sprintf("Operator '%s' not defined for %s and %s", operator, z.Type(), other.Type()))
+	}
+	return bytes.Compare(z.Value, o.Value)
+}
+
+func (z *ZBytes) Equals(other ZValue) ZValue {
+	o, ok := other.(*ZBytes)
+	return BOOL(ok && bytes.Equal(z.Value, o.Value))
+}
+
+func (z *ZBytes) NotEquals(other ZValue) ZValue {
+	o, ok := other.(*ZBytes)
+	return BOOL(!ok || !bytes.Equal(z.Value, o.Value))
+}
+
+func (z *ZBytes) LessThan(other ZValue) ZValue {
+	return BOOL(z.compare("<", other) < 0)
+}
+
+func (z *ZBytes) GreaterThan(other ZValue) ZValue {
+	return BOOL(z.compare(">", other) > 0)
+}
+
+func (z *ZBytes) LessThanEquals(other ZValue) ZValue {
+	return BOOL(z.compare("<=", other) <= 0)
+}
+
+func (z *ZBytes) GreaterThanEquals(other ZValue) ZValue {
+	return BOOL(z.compare(">=", other) >= 0)
+}
@@ -0,0 +1,164 @@
+package val
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Channel type, returned by channel(), to pass values between tasks. Sending
+// to a full channel, or receiving from an empty one, blocks. Once a channel is
+// closed, sending fails, and receiving returns the values left in it before
+// failing. Iterating over a channel receives until it is closed.
+type ZChannel struct {
+	Capacity int
+	values   chan ZValue
+	closed   chan struct{}
+
+	mu       sync.Mutex
+	isClosed bool
+}
+
+func CHANNEL(capacity int) *ZChannel {
+	return &ZChannel{
+		Capacity: capacity,
+		values:   make(chan ZValue, capacity),
+		closed:   make(chan struct{}),
+	}
+}
+
+func (z *ZChannel) Type() ZValueType { return ZCHANNEL }
+func (z *ZChannel) Str() string      { return "<Channel>" }
+
+// IsClosed reports whether the channel has been closed.
+func (z *ZChannel) IsClosed() bool {
+	select {
+	case <-z.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Send waits until the channel can take a value, and sends it.
+func (z *ZChannel) Send(value ZValue) ZValue {
+	if z.IsClosed() {
+		return &ZError{Message: "send() on a closed channel"}
+	}
+	select {
+	case z.values <- value:
+		return NULL()
+	case <-z.closed:
+		return &ZError{Message: "send() on a closed channel"}
+	}
+}
+
+// Recv waits for a value and returns it and true, or false if the channel is
+// closed and empty.
+func (z *ZChannel) Recv() (ZValue, bool) {
+	select {
+	case value := <-z.values:
+		return value, true
+	case <-z.closed:
+		select {
+		case value := <-z.values:
+			return value, true
+		default:
+			return nil, false
+		}
+	}
+}
+
+// Close closes the channel, and wakes up the tasks waiting on it.
+func (z *ZChannel) Close() ZValue {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	if z.isClosed {
+		return &ZError{Message: "close() on a closed channel"}
+	}
+	z.isClosed = true
+	close(z.closed)
+	return NULL()
+}
+
+// Select waits until one of the channels has a value, and returns the index of
+// the channel, the value and true. Closed channels are skipped, and the index
+// is -1 if the timeout expires first. A negative timeout waits forever. It
+// returns false if all the channels are closed and empty.
+func Select(channels []*ZChannel, timeout time.Duration) (int, ZValue, bool) {
+	var expired <-chan time.Time
+	if timeout >= 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	open := make([]int, len(channels))
+	for i := range channels {
+		open[i] = i
+	}
+	for len(open) > 0 {
+		// Each open channel has a case for a value and a case for its closing,
+		// followed by the case of the timeout.
+		cases := make([]reflect.SelectCase, 0, 2*len(open)+1)
+		for _, i := range open {
+			cases = append(cases,
+				reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(channels[i].values)},
+				reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(channels[i].closed)},
+			)
+		}
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(expired)})
+
+		chosen, value, _ := reflect.Select(cases)
+		if chosen == len(cases)-1 {
+			return -1, nil, true
+		}
+		i := open[chosen/2]
+		if chosen%2 == 0 {
+			return i, value.Interface().(ZValue), true
+		}
+		select {
+		case value := <-channels[i].values:
+			return i, value, true
+		default:
+			open = append(open[:chosen/2], open[chosen/2+1:]...)
+		}
+	}
+	return -1, nil, false
+}
+
+func (z *ZChannel) DotAccess(name string) ZValue {
+	switch name {
+	case "capacity":
+		return INT(int64(z.Capacity))
+	case "closed":
+		return BOOL(z.IsClosed())
+	case "send":
+		return &ZNativeFunc{Fn: func(args ...ZValue) ZValue {
+			if len(args) != 1 {
+				return &ZError{Message: "send() takes 1 argument"}
+			}
+			return z.Send(args[0])
+		}}
+	case "recv":
+		return noArgsMethod(name, func() ZValue {
+			value, ok := z.Recv()
+			if !ok {
+				return &ZError{Message: "recv() on a closed channel"}
+			}
+			return value
+		})
+	case "close":
+		return noArgsMethod(name, z.Close)
+	}
+	return ERROR(fmt.Sprintf("%s has no attribute '%s'", ZCHANNEL, name))
+}
+
+func (z *ZChannel) DotAssign(name string, value ZValue) {
+	ERROR(fmt.Sprintf("cannot assign to attribute '%s' of %s", name, ZCHANNEL))
+}
+
+func (z *ZChannel) Env() *Env {
+	return &Env{SymTable: map[string]ZValue{}}
+}
@@ -0,0 +1,450 @@
+package val
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Rounding modes for decimal operations that must drop digits.
+type RoundingMode string
+
+const (
+	RoundHalfEven RoundingMode = "half_even"
+	RoundHalfUp   RoundingMode = "half_up"
+	RoundHalfDown RoundingMode = "half_down"
+	RoundUp       RoundingMode = "up"
+	RoundDown     RoundingMode = "down"
+	RoundCeiling  RoundingMode = "ceiling"
+	RoundFloor    RoundingMode = "floor"
+)
+
+var RoundingModes = map[string]RoundingMode{
+	string(RoundHalfEven): RoundHalfEven,
+	string(RoundHalfUp):   RoundHalfUp,
+	string(RoundHalfDown): RoundHalfDown,
+	string(RoundUp):       RoundUp,
+	string(RoundDown):     RoundDown,
+	string(RoundCeiling):  RoundCeiling,
+	string(RoundFloor):    RoundFloor,
+}
+
+// MaxDecimalScale bounds the number of fractional digits that rounding and
+// division can be asked for, so that a huge scale fails at once instead of
+// exhausting time and memory.
+const MaxDecimalScale = 100000
+
+// DecimalContext controls how decimal results are rounded when they cannot
+// be represented exactly, i.e., on division and on explicit rounding. It is
+// shared by all the states of the process, and is safe for concurrent use.
+type DecimalContext struct {
+	mu sync.RWMutex
+	// Maximum number of fractional digits of a division result
+	divisionScale int
+	// Rounding mode used when no mode is given explicitly
+	rounding RoundingMode
+}
+
+var DecimalCtx = &DecimalContext{
+	divisionScale: 28,
+	rounding:      RoundHalfEven,
+}
+
+// DivisionScale returns the maximum number of fractional digits of a division
+// result.
+func (c *DecimalContext) DivisionScale() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.divisionScale
+}
+
+func (c *DecimalContext) SetDivisionScale(scale int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.divisionScale = scale
+}
+
+// Rounding returns the rounding mode used when no mode is given explicitly.
+func (c *DecimalContext) Rounding() RoundingMode {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.rounding
+}
+
+func (c *DecimalContext) SetRounding(mode RoundingMode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rounding = mode
+}
+
+// Returns the division scale and the rounding mode, read together so that a
+// division uses a consistent context.
+func (c *DecimalContext) division() (int, RoundingMode) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.divisionScale, c.rounding
+}
+
+// Decimal type. A decimal is an exact base-10 number, stored as an arbitrary
+// precision integer and a scale, i.e., the number of fractional digits. For
+// example, 12.50 is stored as 1250 with a scale of 2.
+type ZDecimal struct {
+	unscaled *big.Int
+	scale    int
+}
+
+var bigTen = big.NewInt(10)
+
+func DECIMAL(unscaled *big.Int, scale int) *ZDecimal {
+	return &ZDecimal{unscaled: unscaled, scale: scale}
+}
+
+// ParseDecimal parses a decimal from its string representation, e.g., "12.50",
+// "-0.001" or "1.5e3". Exponents whose power of ten would have more than
+// about MaxPowBits bits are an error, as for `**`.
+func ParseDecimal(s string) (*ZDecimal, error) {
+	text := strings.TrimSpace(s)
+	exponent := 0
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		var err error
+		if exponent, err = strconv.Atoi(text[i+1:]); err != nil {
+			return nil, errors.New("invalid decimal: " + s)
+		}
+		if PowTooLarge(bigTen, new(big.Int).Abs(big.NewInt(int64(exponent)))) {
+			return nil, errors.New("decimal exponent is too large: " + s)
+		}
+		text = text[:i]
+	}
+
+	scale := 0
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		scale = len(text) - i - 1
+		text = text[:i] + text[i+1:]
+	}
+	if text == "" || text == "-" || text == "+" || strings.ContainsAny(text[1:], "+-") {
+		return nil, errors.New("invalid decimal: " + s)
+	}
+
+	unscaled, ok := new(big.Int).SetString(text, 10)
+	if !ok {
+		return nil, errors.New("invalid decimal: " + s)
+	}
+
+	scale -= exponent
+	if scale < 0 {
+		unscaled.Mul(unscaled, pow10(-scale))
+		scale = 0
+	}
+	return DECIMAL(unscaled, scale), nil
+}
+
+// ToDecimal converts an Int, a BigInt or a Decimal to a decimal. The second
+// return value is false for other types. Floats are deliberately not
+// converted implicitly, since they are usually inexact already.
+func ToDecimal(v ZValue) (*ZDecimal, bool) {
+	switch v := v.(type) {
+	case *ZDecimal:
+		return v, true
+	case *ZInt:
+		return DECIMAL(big.NewInt(v.Value), 0), true
+	case *ZBigInt:
+		return DECIMAL(new(big.Int).Set(v.Value), 0), true
+	}
+	return nil, false
+}
+
+func maxScale(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+func (z *ZDecimal) Type() ZValueType { return ZDECIMAL }
+
+func (z *ZDecimal) Str() string {
+	digits := new(big.Int).Abs(z.unscaled).String()
+	sign := ""
+	if z.unscaled.Sign() < 0 {
+		sign = "-"
+	}
+	if z.scale == 0 {
+		return sign + digits
+	}
+	if len(digits) <= z.scale {
+		digits = strings.Repeat("0", z.scale-len(digits)+1) + digits
+	}
+	point := len(digits) - z.scale
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// Scale returns the number of fractional digits.
+func (z *ZDecimal) Scale() int {
+	return z.scale
+}
+
+// Rat returns the exact rational value of the decimal.
+func (z *ZDecimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(z.unscaled, pow10(z.scale))
+}
+
+// Float returns the nearest float64 value.
+func (z *ZDecimal) Float() float64 {
+	f, _ := z.Rat().Float64()
+	return f
+}
+
+// Int returns the integer part of the decimal, truncated toward zero.
+func (z *ZDecimal) Int() *big.Int {
+	return new(big.Int).Quo(z.unscaled, pow10(z.scale))
+}
+
+// Returns the unscaled value of the decimal at a larger scale.
+func (z *ZDecimal) rescaled(scale int) *big.Int {
+	return new(big.Int).Mul(z.unscaled, pow10(scale-z.scale))
+}
+
+// Round returns the decimal rounded to the given number of fractional digits.
+func (z *ZDecimal) Round(scale int, mode RoundingMode) *ZDecimal {
+	if scale >= z.scale {
+		return DECIMAL(z.rescaled(scale), scale)
+	}
+	return DECIMAL(roundQuotient(z.unscaled, pow10(z.scale-scale), mode), scale)
+}
+
+// Divides num by den and rounds the quotient to an integer.
+func roundQuotient(num, den *big.Int, mode RoundingMode) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(num, den, new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient
+	}
+
+	// The sign of the exact result, and how the remainder compares to half of
+	// the divisor
+	sign := num.Sign() * den.Sign()
+	half := new(big.Int).Abs(remainder)
+	half.Mul(half, big.NewInt(2))
+	halfCmp := half.Cmp(new(big.Int).Abs(den))
+
+	awayFromZero := false
+	switch mode {
+	case RoundUp:
+		awayFromZero = true
+	case RoundDown:
+		awayFromZero = false
+	case RoundCeiling:
+		awayFromZero = sign > 0
+	case RoundFloor:
+		awayFromZero = sign < 0
+	case RoundHalfUp:
+		awayFromZero = halfCmp >= 0
+	case RoundHalfDown:
+		awayFromZero = halfCmp > 0
+	default: // RoundHalfEven
+		awayFromZero = halfCmp > 0 || (halfCmp == 0 && quotient.Bit(0) == 1)
+	}
+
+	if awayFromZero {
+		quotient.Add(quotient, big.NewInt(int64(sign)))
+	}
+	return quotient
+}
+
+// Removes trailing fractional zeros, keeping at least minScale digits.
+func (z *ZDecimal) trimmed(minScale int) *ZDecimal {
+	unscaled := new(big.Int).Set(z.unscaled)
+	scale := z.scale
+	remainder := new(big.Int)
+	for scale > minScale {
+		quotient, _ := new(big.Int).QuoRem(unscaled, bigTen, remainder)
+		if remainder.Sign() != 0 {
+			break
+		}
+		unscaled = quotient
+		scale--
+	}
+	return DECIMAL(unscaled, scale)
+}
+
+// Converts an arithmetic operand to a decimal. Mixing with other types is a
+// type error.
+func decimalOperand(operator string, other ZValue) (*ZDecimal, ZValue) {
+	if d, ok := ToDecimal(other); ok {
+		return d, nil
+	}
+	if other.Type() == ZFLOAT {
+		return nil, ERROR("cannot mix Decimal and Float with `" + operator + "`, convert the Float with decimal() first")
+	}
+	return nil, ERROR(fmt.Sprintf("type mismatch: %s %s %s", ZDECIMAL, operator, other.Type()))
+}
+
+func (z *ZDecimal) Add(other ZValue) ZValue {
+	o, err := decimalOperand("+", other)
+	if err != nil {
+		return err
+	}
+	scale := maxScale(z.scale, o.scale)
+	return DECIMAL(new(big.Int).Add(z.rescaled(scale), o.rescaled(scale)), scale)
+}
+
+func (z *ZDecimal) Sub(other ZValue) ZValue {
+	o, err := decimalOperand("-", other)
+	if err != nil {
+		return err
+	}
+	scale := maxScale(z.scale, o.scale)
+	return DECIMAL(new(big.Int).Sub(z.rescaled(scale), o.rescaled(scale)), scale)
+}
+
+func (z *ZDecimal) Mul(other ZValue) ZValue {
+	o, err := decimalOperand("*", other)
+	if err != nil {
+		return err
+	}
+	return DECIMAL(new(big.Int).Mul(z.unscaled, o.unscaled), z.scale+o.scale)
+}
+
+// Div divides exactly when possible. Otherwise, the quotient is rounded to
+// DecimalCtx.DivisionScale() fractional digits. Trailing zeros beyond the scale
+// of the operands are removed, so 10.00 / 4 is 2.50.
+func (z *ZDecimal) Div(other ZValue) ZValue {
+	o, err := decimalOperand("/", other)
+	if err != nil {
+		return err
+	}
+	if o.unscaled.Sign() == 0 {
+		return &ZError{Message: "division by zero"}
+	}
+
+	divisionScale, rounding := DecimalCtx.division()
+	scale := maxScale(divisionScale, maxScale(z.scale, o.scale))
+	// z / o = (z.unscaled * 10^(scale - z.scale + o.scale)) / o.unscaled * 10^-scale
+	num := new(big.Int).Mul(z.unscaled, pow10(scale-z.scale+o.scale))
+	quotient := DECIMAL(roundQuotient(num, o.unscaled, rounding), scale)
+	return quotient.trimmed(maxScale(z.scale, o.scale))
+}
+
+// Mod returns the remainder of the truncated division, with the sign of z.
+func (z *ZDecimal) Mod(other ZValue) ZValue {
+	o, err := decimalOperand("%", other)
+	if err != nil {
+		return err
+	}
+	if o.unscaled.Sign() == 0 {
+		return &ZError{Message: "division by zero"}
+	}
+	scale := maxScale(z.scale, o.scale)
+	return DECIMAL(new(big.Int).Rem(z.rescaled(scale), o.rescaled(scale)), scale)
+}
+
+// FloorDiv returns the largest integer less than or equal to z / other.
+func (z *ZDecimal) FloorDiv(other ZValue) ZValue {
+	o, err := decimalOperand("//", other)
+	if err != nil {
+		return err
+	}
+	if o.unscaled.Sign() == 0 {
+		return &ZError{Message: "division by zero"}
+	}
+	scale := maxScale(z.scale, o.scale)
+	return DECIMAL(roundQuotient(z.rescaled(scale), o.rescaled(scale), RoundFloor), 0)
+}
+
+// Pow raises z to an integer power. Negative powers are computed by division.
+// Results with more than about MaxPowBits bits are an error.
+func (z *ZDecimal) Pow(other ZValue) ZValue {
+	exp, ok := other.(*ZInt)
+	if !ok {
+		return ERROR(fmt.Sprintf("type mismatch: %s ** %s, the exponent must be an Int", ZDECIMAL, other.Type()))
+	}
+	n := exp.Value
+	if n < 0 {
+		n = -n
+	}
+	// A power of 0.1 is small, but has as many digits as a large one
+	if PowTooLarge(z.unscaled, big.NewInt(n)) || int64(z.scale)*n > MaxPowBits {
+		return &ZError{Message: "exponent too large"}
+	}
+	result := DECIMAL(
+		new(big.Int).Exp(z.unscaled, big.NewInt(n), nil),
+		z.scale*int(n),
+	)
+	if exp.Value < 0 {
+		return DECIMAL(big.NewInt(1), 0).Div(result)
+	}
+	return result
+}
+
+func (z *ZDecimal) Neg() *ZDecimal {
+	return DECIMAL(new(big.Int).Neg(z.unscaled), z.scale)
+}
+
+// Compares with another number. Floats are compared by their exact value.
+// The second return value is false if the other value is not a number.
+func (z *ZDecimal) compare(other ZValue) (int, bool) {
+	if d, ok := ToDecimal(other); ok {
+		return z.Rat().Cmp(d.Rat()), true
+	}
+	if f, ok := other.(*ZFloat); ok {
+		switch {
+		case math.IsInf(f.Value, 1):
+			return -1, true
+		case math.IsInf(f.Value, -1):
+			return 1, true
+		case math.IsNaN(f.Value):
+			return 0, false
+		}
+		return z.Rat().Cmp(new(big.Rat).SetFloat64(f.Value)), true
+	}
+	return 0, false
+}
+
+func (z *ZDecimal) Equals(other ZValue) ZValue {
+	if cmp, ok := z.compare(other); ok {
+		return BOOL(cmp == 0)
+	}
+	return ERROR(fmt.Sprintf("Operator '==' not defined for %s and %s", z.Type(), other.Type()))
+}
+
+func (z *ZDecimal) NotEquals(other ZValue) ZValue {
+	if cmp, ok := z.compare(other); ok {
+		return BOOL(cmp != 0)
+	}
+	return ERROR(fmt.Sprintf("Operator '!=' not defined for %s and %s", z.Type(), other.Type()))
+}
+
+func (z *ZDecimal) LessThan(other ZValue) ZValue {
+	if cmp, ok := z.compare(other); ok {
+		return BOOL(cmp < 0)
+	}
+	return ERROR(fmt.Sprintf("Operator '<' not defined for %s and %s", z.Type(), other.Type()))
+}
+
+func (z *ZDecimal) GreaterThan(other ZValue) ZValue {
+	if cmp, ok := z.compare(other); ok {
+		return BOOL(cmp > 0)
+	}
+	return ERROR(fmt.Sprintf("Operator '>' not defined for %s and %s", z.Type(), other.Type()))
+}
+
+func (z *ZDecimal) LessThanEquals(other ZValue) ZValue {
+	if cmp, ok := z.compare(other); ok {
+		return BOOL(cmp <= 0)
+	}
+	return ERROR(fmt.Sprintf("Operator '<=' not defined for %s and %s", z.Type(), other.Type()))
+}
+
+func (z *ZDecimal) GreaterThanEquals(other ZValue) ZValue {
+	if cmp, ok := z.compare(other); ok {
+		return BOOL(cmp >= 0)
+	}
+	return ERROR(fmt.Sprintf("Operator '>=' not defined for %s and %s", z.Type(), other.Type()))
+}
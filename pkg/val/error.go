@@ -2,16 +2,22 @@ package val
 
 import (
 	"fmt"
+	"io"
 	"os"
 )
 
+// Stderr receives the messages of fatal errors, which end the process. Hosts
+// embedding the interpreter can replace it, along with the Stderr of their
+// states.
+var Stderr io.Writer = os.Stderr
+
 // Error type
 type ZError struct {
 	Message string
 }
 
 func ERROR(message string) *ZError {
-	fmt.Println("ERROR: " + message)
+	fmt.Fprintln(Stderr, "ERROR: "+message)
 	os.Exit(1)
 	return &ZError{Message: message}
 }
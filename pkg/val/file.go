@@ -0,0 +1,177 @@
+package val
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// File handle type, returned by io.open(). Its methods are accessed with dot
+// notation, e.g., `f.write("text")`, and failures are returned as errors.
+type ZFile struct {
+	Path   string
+	file   *os.File
+	reader *bufio.Reader
+	closed bool
+}
+
+// FileModes maps the modes of io.open() to the flags of os.OpenFile.
+var FileModes = map[string]int{
+	"r": os.O_RDONLY,
+	"w": os.O_WRONLY | os.O_CREATE | os.O_TRUNC,
+	"a": os.O_WRONLY | os.O_CREATE | os.O_APPEND,
+}
+
+func FILE(path string, file *os.File) *ZFile {
+	return &ZFile{Path: path, file: file, reader: bufio.NewReader(file)}
+}
+
+func (z *ZFile) Type() ZValueType { return ZFILE }
+func (z *ZFile) Str() string      { return fmt.Sprintf("<%s \"%s\">", z.Type(), z.Path) }
+
+// Returns an error value for a failed method, or for a method called on a
+// closed file.
+func (z *ZFile) fail(name string, err error) *ZError {
+	if z.closed {
+		return &ZError{Message: name + "() on closed file " + z.Path}
+	}
+	return &ZError{Message: name + "() " + err.Error()}
+}
+
+// ReadLine returns the next line including its newline, or an empty string at
+// the end of the file.
+func (z *ZFile) ReadLine() ZValue {
+	if z.closed {
+		return z.fail("read_line", nil)
+	}
+	line, err := z.reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return z.fail("read_line", err)
+	}
+	return STRING(line)
+}
+
+// Read returns the rest of the file.
+func (z *ZFile) Read() ZValue {
+	if z.closed {
+		return z.fail("read", nil)
+	}
+	content, err := io.ReadAll(z.reader)
+	if err != nil {
+		return z.fail("read", err)
+	}
+	return STRING(string(content))
+}
+
+// ReadBytes returns up to n bytes, or the rest of the file if n is negative.
+// At the end of the file, it returns empty bytes.
+func (z *ZFile) ReadBytes(n int) ZValue {
+	if z.closed {
+		return z.fail("read_bytes", nil)
+	}
+	if n < 0 {
+		content, err := io.ReadAll(z.reader)
+		if err != nil {
+			return z.fail("read_bytes", err)
+		}
+		return BYTES(content)
+	}
+	buf := make([]byte, n)
+	read, err := io.ReadFull(z.reader, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return z.fail("read_bytes", err)
+	}
+	return BYTES(buf[:read])
+}
+
+// Lines returns an iterator over the remaining lines, without their newlines.
+func (z *ZFile) Lines() ZValue {
+	if z.closed {
+		return z.fail("lines", nil)
+	}
+	return ITERATOR(func() (ZValue, bool) {
+		line := z.ReadLine()
+		if line.Type() == ZERROR {
+			return line, true
+		}
+		text := line.(*ZString).Value
+		if text == "" {
+			return nil, false
+		}
+		text = strings.TrimSuffix(text, "\n")
+		return STRING(strings.TrimSuffix(text, "\r")), true
+	})
+}
+
+// Write writes a string or bytes and returns the number of bytes written.
+func (z *ZFile) Write(b []byte) ZValue {
+	if z.closed {
+		return z.fail("write", nil)
+	}
+	n, err := z.file.Write(b)
+	if err != nil {
+		return z.fail("write", err)
+	}
+	return INT(int64(n))
+}
+
+func (z *ZFile) Close() ZValue {
+	if z.closed {
+		return NULL()
+	}
+	z.closed = true
+	if err := z.file.Close(); err != nil {
+		return &ZError{Message: "close() " + err.Error()}
+	}
+	return NULL()
+}
+
+func (z *ZFile) DotAccess(name string) ZValue {
+	switch name {
+	case "path":
+		return STRING(z.Path)
+	case "closed":
+		return BOOL(z.closed)
+	case "read":
+		return noArgsMethod(name, z.Read)
+	case "read_line":
+		return noArgsMethod(name, z.ReadLine)
+	case "lines":
+		return noArgsMethod(name, z.Lines)
+	case "close":
+		return noArgsMethod(name, z.Close)
+	case "read_bytes":
+		return &ZNativeFunc{Fn: func(args ...ZValue) ZValue {
+			if len(args) == 0 {
+				return z.ReadBytes(-1)
+			}
+			if len(args) != 1 || args[0].Type() != ZINT || args[0].(*ZInt).Value < 0 {
+				return &ZError{Message: "read_bytes() takes a non-negative integer"}
+			}
+			return z.ReadBytes(int(args[0].(*ZInt).Value))
+		}}
+	case "write":
+		return &ZNativeFunc{Fn: func(args ...ZValue) ZValue {
+			if len(args) == 1 {
+				switch data := args[0].(type) {
+				case *ZString:
+					return z.Write([]byte(data.Value))
+				case *ZBytes:
+					return z.Write(data.Value)
+				}
+			}
+			return &ZError{Message: "write() takes a string or bytes"}
+		}}
+	}
+	return ERROR(fmt.Sprintf("%s has no attribute '%s'", ZFILE, name))
+}
+
+func (z *ZFile) DotAssign(name string, value ZValue) {
+	ERROR(fmt.Sprintf("cannot assign to attribute '%s' of %s", name, ZFILE))
+}
+
+func (z *ZFile) Env() *Env {
+	return &Env{SymTable: map[string]ZValue{}}
+}
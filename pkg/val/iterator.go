@@ -0,0 +1,100 @@
+package val
+
+import "unicode/utf8"
+
+// Iterator type. An iterator produces its elements lazily, one at a time, and
+// can be consumed only once. An error element terminates the iteration.
+type ZIterator struct {
+	next func() (ZValue, bool)
+	done bool
+}
+
+func ITERATOR(next func() (ZValue, bool)) *ZIterator {
+	return &ZIterator{next: next}
+}
+
+func (z *ZIterator) Type() ZValueType { return ZITERATOR }
+func (z *ZIterator) Str() string      { return "<Iterator>" }
+
+// Next returns the next element and true, or false if the iterator is
+// exhausted.
+func (z *ZIterator) Next() (ZValue, bool) {
+	if z.done {
+		return nil, false
+	}
+	value, ok := z.next()
+	if !ok || value.Type() == ZERROR {
+		z.done = true
+	}
+	return value, ok
+}
+
+// Iterate returns an iterator over the elements of a list, the characters of
+// a string, the keys of a table, the bytes of bytes as integers, the values
+// received from a channel until it is closed, or the iterator itself. The
+// second return value is false if the value is not iterable.
+func Iterate(v ZValue) (*ZIterator, bool) {
+	switch v := v.(type) {
+	case *ZIterator:
+		return v, true
+	case *ZList:
+		i := 0
+		return ITERATOR(func() (ZValue, bool) {
+			if i >= len(v.Elements) {
+				return nil, false
+			}
+			i++
+			return v.Elements[i-1], true
+		}), true
+	case *ZTable:
+		keys := append([]string{}, v.Keys()...)
+		return ITERATOR(func() (ZValue, bool) {
+			if len(keys) == 0 {
+				return nil, false
+			}
+			key := keys[0]
+			keys = keys[1:]
+			return STRING(key), true
+		}), true
+	case *ZString:
+		s := v.Value
+		return ITERATOR(func() (ZValue, bool) {
+			if len(s) == 0 {
+				return nil, false
+			}
+			_, size := utf8.DecodeRuneInString(s)
+			char := s[:size]
+			s = s[size:]
+			return STRING(char), true
+		}), true
+	case *ZChannel:
+		return ITERATOR(v.Recv), true
+	case *ZBytes:
+		i := 0
+		return ITERATOR(func() (ZValue, bool) {
+			if i >= len(v.Value) {
+				return nil, false
+			}
+			i++
+			return INT(int64(v.Value[i-1])), true
+		}), true
+	}
+	return nil, false
+}
+
+// Collect consumes an iterator into a list. If the iterator yields an error,
+// the error is returned instead.
+func Collect(it *ZIterator) ZValue {
+	elements := []ZValue{}
+	for {
+		value, ok := it.Next()
+		if !ok {
+			break
+		}
+		if value.Type() == ZERROR {
+			return value
+		}
+		elements = append(elements, value)
+	}
+	return &ZList{Elements: elements}
+}
@@ -32,7 +32,7 @@ func (zm *ZModule) DotAccess(name string) ZValue {
 }
 
 func (zm *ZModule) DotAssign(name string, value ZValue) {
-	fmt.Println("cannt assign to module")
+	fmt.Fprintln(Stderr, "cannt assign to module")
 }
 
 func (zm *ZModule) Env() *Env {
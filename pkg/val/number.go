@@ -20,6 +20,12 @@ func (z *ZInt) Equals(other ZValue) ZValue {
 		return &ZBool{Value: float64(z.Value) == other.(*ZFloat).Value}
 	} else if other.Type() == ZINT {
 		return &ZBool{Value: z.Value == other.(*ZInt).Value}
+	} else if other.Type() == ZBIGINT {
+		cmp, _ := other.(*ZBigInt).compare(z)
+		return BOOL(-cmp == 0)
+	} else if other.Type() == ZDECIMAL {
+		cmp, _ := other.(*ZDecimal).compare(z)
+		return BOOL(-cmp == 0)
 	}
 	return ERROR(fmt.Sprintf("Operator '==' not defined for %s and %s", z.Type(), other.Type()))
 }
@@ -29,6 +35,12 @@ func (z *ZInt) NotEquals(other ZValue) ZValue {
 		return &ZBool{Value: float64(z.Value) != other.(*ZFloat).Value}
 	} else if other.Type() == ZINT {
 		return &ZBool{Value: z.Value != other.(*ZInt).Value}
+	} else if other.Type() == ZBIGINT {
+		cmp, _ := other.(*ZBigInt).compare(z)
+		return BOOL(-cmp != 0)
+	} else if other.Type() == ZDECIMAL {
+		cmp, _ := other.(*ZDecimal).compare(z)
+		return BOOL(-cmp != 0)
 	}
 	return ERROR(fmt.Sprintf("Operator '!=' not defined for %s and %s", z.Type(), other.Type()))
 }
@@ -38,6 +50,12 @@ func (z *ZInt) LessThan(other ZValue) ZValue {
 		return &ZBool{Value: float64(z.Value) < other.(*ZFloat).Value}
 	} else if other.Type() == ZINT {
 		return &ZBool{Value: z.Value < other.(*ZInt).Value}
+	} else if other.Type() == ZBIGINT {
+		cmp, _ := other.(*ZBigInt).compare(z)
+		return BOOL(-cmp < 0)
+	} else if other.Type() == ZDECIMAL {
+		cmp, _ := other.(*ZDecimal).compare(z)
+		return BOOL(-cmp < 0)
 	}
 	return ERROR(fmt.Sprintf("Operator '<' not defined for %s and %s", z.Type(), other.Type()))
 }
@@ -47,6 +65,12 @@ func (z *ZInt) GreaterThan(other ZValue) ZValue {
 		return &ZBool{Value: float64(z.Value) > other.(*ZFloat).Value}
 	} else if other.Type() == ZINT {
 		return &ZBool{Value: z.Value > other.(*ZInt).Value}
+	} else if other.Type() == ZBIGINT {
+		cmp, _ := other.(*ZBigInt).compare(z)
+		return BOOL(-cmp > 0)
+	} else if other.Type() == ZDECIMAL {
+		cmp, _ := other.(*ZDecimal).compare(z)
+		return BOOL(-cmp > 0)
 	}
 	return ERROR(fmt.Sprintf("Operator '>' not defined for %s and %s", z.Type(), other.Type()))
 }
@@ -56,6 +80,12 @@ func (z *ZInt) LessThanEquals(other ZValue) ZValue {
 		return &ZBool{Value: float64(z.Value) <= other.(*ZFloat).Value}
 	} else if other.Type() == ZINT {
 		return &ZBool{Value: z.Value <= other.(*ZInt).Value}
+	} else if other.Type() == ZBIGINT {
+		cmp, _ := other.(*ZBigInt).compare(z)
+		return BOOL(-cmp <= 0)
+	} else if other.Type() == ZDECIMAL {
+		cmp, _ := other.(*ZDecimal).compare(z)
+		return BOOL(-cmp <= 0)
 	}
 	return ERROR(fmt.Sprintf("Operator '<=' not defined for %s and %s", z.Type(), other.Type()))
 }
@@ -65,6 +95,12 @@ func (z *ZInt) GreaterThanEquals(other ZValue) ZValue {
 		return &ZBool{Value: float64(z.Value) >= other.(*ZFloat).Value}
 	} else if other.Type() == ZINT {
 		return &ZBool{Value: z.Value >= other.(*ZInt).Value}
+	} else if other.Type() == ZBIGINT {
+		cmp, _ := other.(*ZBigInt).compare(z)
+		return BOOL(-cmp >= 0)
+	} else if other.Type() == ZDECIMAL {
+		cmp, _ := other.(*ZDecimal).compare(z)
+		return BOOL(-cmp >= 0)
 	}
 	return ERROR(fmt.Sprintf("Operator '>=' not defined for %s and %s", z.Type(), other.Type()))
 }
@@ -87,6 +123,12 @@ func (z *ZFloat) Equals(other ZValue) ZValue {
 		return BOOL(z.Value == float64(other.(*ZInt).Value))
 	} else if other.Type() == ZFLOAT {
 		return BOOL(z.Value == other.(*ZFloat).Value)
+	} else if other.Type() == ZBIGINT {
+		cmp, _ := other.(*ZBigInt).compare(z)
+		return BOOL(-cmp == 0)
+	} else if other.Type() == ZDECIMAL {
+		cmp, _ := other.(*ZDecimal).compare(z)
+		return BOOL(-cmp == 0)
 	}
 	return ERROR(fmt.Sprintf("Operator '==' not defined for %s and %s", z.Type(), other.Type()))
 }
@@ -96,6 +138,12 @@ func (z *ZFloat) NotEquals(other ZValue) ZValue {
 		return BOOL(z.Value != float64(other.(*ZInt).Value))
 	} else if other.Type() == ZFLOAT {
 		return BOOL(z.Value != other.(*ZFloat).Value)
+	} else if other.Type() == ZBIGINT {
+		cmp, _ := other.(*ZBigInt).compare(z)
+		return BOOL(-cmp != 0)
+	} else if other.Type() == ZDECIMAL {
+		cmp, _ := other.(*ZDecimal).compare(z)
+		return BOOL(-cmp != 0)
 	}
 	return ERROR(fmt.Sprintf("Operator '!=' not defined for %s and %s", z.Type(), other.Type()))
 }
@@ -105,6 +153,12 @@ func (z *ZFloat) LessThan(other ZValue) ZValue {
 		return BOOL(z.Value < float64(other.(*ZInt).Value))
 	} else if other.Type() == ZFLOAT {
 		return BOOL(z.Value < other.(*ZFloat).Value)
+	} else if other.Type() == ZBIGINT {
+		cmp, _ := other.(*ZBigInt).compare(z)
+		return BOOL(-cmp < 0)
+	} else if other.Type() == ZDECIMAL {
+		cmp, _ := other.(*ZDecimal).compare(z)
+		return BOOL(-cmp < 0)
 	}
 	return ERROR(fmt.Sprintf("Operator '<' not defined for %s and %s", z.Type(), other.Type()))
 }
@@ -114,6 +168,12 @@ func (z *ZFloat) GreaterThan(other ZValue) ZValue {
 		return BOOL(z.Value > float64(other.(*ZInt).Value))
 	} else if other.Type() == ZFLOAT {
 		return BOOL(z.Value > other.(*ZFloat).Value)
+	} else if other.Type() == ZBIGINT {
+		cmp, _ := other.(*ZBigInt).compare(z)
+		return BOOL(-cmp > 0)
+	} else if other.Type() == ZDECIMAL {
+		cmp, _ := other.(*ZDecimal).compare(z)
+		return BOOL(-cmp > 0)
 	}
 	return ERROR(fmt.Sprintf("Operator '>' not defined for %s and %s", z.Type(), other.Type()))
 }
@@ -123,6 +183,12 @@ func (z *ZFloat) LessThanEquals(other ZValue) ZValue {
 		return BOOL(z.Value <= float64(other.(*ZInt).Value))
 	} else if other.Type() == ZFLOAT {
 		return BOOL(z.Value <= other.(*ZFloat).Value)
+	} else if other.Type() == ZBIGINT {
+		cmp, _ := other.(*ZBigInt).compare(z)
+		return BOOL(-cmp <= 0)
+	} else if other.Type() == ZDECIMAL {
+		cmp, _ := other.(*ZDecimal).compare(z)
+		return BOOL(-cmp <= 0)
 	}
 	return ERROR(fmt.Sprintf("Operator '<=' not defined for %s and %s", z.Type(), other.Type()))
 }
@@ -132,6 +198,12 @@ func (z *ZFloat) GreaterThanEquals(other ZValue) ZValue {
 		return BOOL(z.Value >= float64(other.(*ZInt).Value))
 	} else if other.Type() == ZFLOAT {
 		return BOOL(z.Value >= other.(*ZFloat).Value)
+	} else if other.Type() == ZBIGINT {
+		cmp, _ := other.(*ZBigInt).compare(z)
+		return BOOL(-cmp >= 0)
+	} else if other.Type() == ZDECIMAL {
+		cmp, _ := other.(*ZDecimal).compare(z)
+		return BOOL(-cmp >= 0)
 	}
 	return ERROR(fmt.Sprintf("Operator '>=' not defined for %s and %s", z.Type(), other.Type()))
 }
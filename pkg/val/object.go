@@ -32,7 +32,7 @@ func (z *ZObject) DotAccess(name string) ZValue {
 
 func (z *ZObject) DotAssign(name string, value ZValue) {
 	if name == "new" {
-		fmt.Println("`new` is a reserved attribute name")
+		fmt.Fprintln(Stderr, "`new` is a reserved attribute name")
 		return
 	}
 	if value.Type() == ZFUNCTION {
@@ -0,0 +1,48 @@
+package val
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Compiled regular expression type, returned by re.compile(). Its methods are
+// the functions of the re module with the pattern already given, e.g.,
+// `r.find(text)` is `re.find(r, text)`, and are provided by the module.
+type ZRegex struct {
+	Re      *regexp.Regexp
+	Methods map[string]ZValue
+}
+
+func REGEX(re *regexp.Regexp, methods map[string]ZValue) *ZRegex {
+	return &ZRegex{Re: re, Methods: methods}
+}
+
+func (z *ZRegex) Type() ZValueType { return ZREGEX }
+func (z *ZRegex) Str() string      { return fmt.Sprintf("<%s \"%s\">", z.Type(), z.Re.String()) }
+
+func (z *ZRegex) DotAccess(name string) ZValue {
+	switch name {
+	case "pattern":
+		return STRING(z.Re.String())
+	case "names":
+		names := []ZValue{}
+		for _, n := range z.Re.SubexpNames() {
+			if n != "" {
+				names = append(names, STRING(n))
+			}
+		}
+		return &ZList{Elements: names}
+	}
+	if method, ok := z.Methods[name]; ok {
+		return method
+	}
+	return ERROR(fmt.Sprintf("%s has no attribute '%s'", ZREGEX, name))
+}
+
+func (z *ZRegex) DotAssign(name string, value ZValue) {
+	ERROR(fmt.Sprintf("cannot assign to attribute '%s' of %s", name, ZREGEX))
+}
+
+func (z *ZRegex) Env() *Env {
+	return &Env{SymTable: map[string]ZValue{}}
+}
@@ -0,0 +1,143 @@
+package val
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Mutex type, returned by mutex(), to guard values shared by tasks, such as
+// lists and tables, which are not safe for concurrent use.
+type ZMutex struct {
+	held chan struct{}
+}
+
+func MUTEX() *ZMutex {
+	return &ZMutex{held: make(chan struct{}, 1)}
+}
+
+func (z *ZMutex) Type() ZValueType { return ZMUTEX }
+func (z *ZMutex) Str() string      { return "<Mutex>" }
+
+// Lock waits until the mutex is unlocked, and locks it.
+func (z *ZMutex) Lock() ZValue {
+	z.held <- struct{}{}
+	return NULL()
+}
+
+// TryLock locks the mutex if it is unlocked, and reports whether it did.
+func (z *ZMutex) TryLock() ZValue {
+	select {
+	case z.held <- struct{}{}:
+		return BOOL(true)
+	default:
+		return BOOL(false)
+	}
+}
+
+// Unlock unlocks the mutex, which may have been locked by another task.
+func (z *ZMutex) Unlock() ZValue {
+	select {
+	case <-z.held:
+		return NULL()
+	default:
+		return &ZError{Message: "unlock() on an unlocked mutex"}
+	}
+}
+
+func (z *ZMutex) DotAccess(name string) ZValue {
+	switch name {
+	case "lock":
+		return noArgsMethod(name, z.Lock)
+	case "try_lock":
+		return noArgsMethod(name, z.TryLock)
+	case "unlock":
+		return noArgsMethod(name, z.Unlock)
+	}
+	return ERROR(fmt.Sprintf("%s has no attribute '%s'", ZMUTEX, name))
+}
+
+func (z *ZMutex) DotAssign(name string, value ZValue) {
+	ERROR(fmt.Sprintf("cannot assign to attribute '%s' of %s", name, ZMUTEX))
+}
+
+func (z *ZMutex) Env() *Env {
+	return &Env{SymTable: map[string]ZValue{}}
+}
+
+// Wait group type, returned by wait_group(), to wait for a number of tasks.
+// Each task calls done() when it finishes, after add() counted it.
+type ZWaitGroup struct {
+	mu      sync.Mutex
+	zero    *sync.Cond
+	pending int64
+}
+
+func WAITGROUP() *ZWaitGroup {
+	z := &ZWaitGroup{}
+	z.zero = sync.NewCond(&z.mu)
+	return z
+}
+
+func (z *ZWaitGroup) Type() ZValueType { return ZWAITGROUP }
+func (z *ZWaitGroup) Str() string      { return "<WaitGroup>" }
+
+// Add adds n to the number of pending tasks.
+func (z *ZWaitGroup) Add(n int64) ZValue {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	if z.pending+n < 0 {
+		return &ZError{Message: "add() makes the wait group counter negative"}
+	}
+	z.pending += n
+	if z.pending == 0 {
+		z.zero.Broadcast()
+	}
+	return NULL()
+}
+
+// Wait waits until there are no pending tasks.
+func (z *ZWaitGroup) Wait() ZValue {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	for z.pending > 0 {
+		z.zero.Wait()
+	}
+	return NULL()
+}
+
+func (z *ZWaitGroup) DotAccess(name string) ZValue {
+	switch name {
+	case "pending":
+		z.mu.Lock()
+		defer z.mu.Unlock()
+		return INT(z.pending)
+	case "add":
+		return &ZNativeFunc{Fn: func(args ...ZValue) ZValue {
+			if len(args) == 0 {
+				return z.Add(1)
+			}
+			if len(args) != 1 || args[0].Type() != ZINT {
+				return &ZError{Message: "add() takes an integer"}
+			}
+			return z.Add(args[0].(*ZInt).Value)
+		}}
+	case "done":
+		return noArgsMethod(name, func() ZValue {
+			if zErr := z.Add(-1); zErr.Type() == ZERROR {
+				return &ZError{Message: "done() called more times than add()"}
+			}
+			return NULL()
+		})
+	case "wait":
+		return noArgsMethod(name, z.Wait)
+	}
+	return ERROR(fmt.Sprintf("%s has no attribute '%s'", ZWAITGROUP, name))
+}
+
+func (z *ZWaitGroup) DotAssign(name string, value ZValue) {
+	ERROR(fmt.Sprintf("cannot assign to attribute '%s' of %s", name, ZWAITGROUP))
+}
+
+func (z *ZWaitGroup) Env() *Env {
+	return &Env{SymTable: map[string]ZValue{}}
+}
@@ -0,0 +1,104 @@
+package val
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Table type, mapping string keys to values. Keys keep the order in which they
+// were first set, so that tables read from JSON are written back the same
+// way. Values are accessed with `t["key"]` or `t.key`.
+type ZTable struct {
+	keys   []string
+	values map[string]ZValue
+}
+
+func TABLE() *ZTable {
+	return &ZTable{values: map[string]ZValue{}}
+}
+
+func (z *ZTable) Type() ZValueType { return ZTABLE }
+
+// Str renders the table as `{key: value, ...}`, with string values quoted and
+// null shown, so that they can be told apart from other values.
+func (z *ZTable) Str() string {
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i, key := range z.keys {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(key)
+		sb.WriteString(": ")
+		switch value := z.values[key].(type) {
+		case *ZString:
+			sb.WriteString(strconv.Quote(value.Value))
+		case *ZNull:
+			sb.WriteString("null")
+		default:
+			sb.WriteString(value.Str())
+		}
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+func (z *ZTable) Len() int {
+	return len(z.keys)
+}
+
+// Keys returns the keys in insertion order.
+func (z *ZTable) Keys() []string {
+	return z.keys
+}
+
+// Get returns the value of a key, and whether the key is present.
+func (z *ZTable) Get(key string) (ZValue, bool) {
+	value, ok := z.values[key]
+	return value, ok
+}
+
+// Set sets the value of a key. A new key is added after the existing ones.
+func (z *ZTable) Set(key string, value ZValue) {
+	if _, ok := z.values[key]; !ok {
+		z.keys = append(z.keys, key)
+	}
+	z.values[key] = value
+}
+
+// Delete removes a key, and returns whether it was present.
+func (z *ZTable) Delete(key string) bool {
+	if _, ok := z.values[key]; !ok {
+		return false
+	}
+	delete(z.values, key)
+	for i, k := range z.keys {
+		if k == key {
+			z.keys = append(z.keys[:i:i], z.keys[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// Index returns the value of a key, or an error if it is missing.
+func (z *ZTable) Index(key string) ZValue {
+	value, ok := z.values[key]
+	if !ok {
+		return &ZError{Message: fmt.Sprintf("key %q not found", key)}
+	}
+	return value
+}
+
+func (z *ZTable) DotAccess(name string) ZValue {
+	return z.Index(name)
+}
+
+func (z *ZTable) DotAssign(name string, value ZValue) {
+	z.Set(name, value)
+}
+
+func (z *ZTable) Env() *Env {
+	return &Env{SymTable: map[string]ZValue{}}
+}
@@ -0,0 +1,73 @@
+package val
+
+import "fmt"
+
+// Task type, a function running on its own goroutine, returned by spawn().
+// The result of the function, or the error it returned, is available once it
+// finishes.
+type ZTask struct {
+	Name   string
+	done   chan struct{}
+	result ZValue
+}
+
+func TASK(name string) *ZTask {
+	return &ZTask{Name: name, done: make(chan struct{})}
+}
+
+func (z *ZTask) Type() ZValueType { return ZTASK }
+func (z *ZTask) Str() string      { return fmt.Sprintf("<%s %s>", z.Type(), z.Name) }
+
+// Finish records the result of the task and wakes up the joining tasks. It
+// must be called exactly once.
+func (z *ZTask) Finish(result ZValue) {
+	z.result = result
+	close(z.done)
+}
+
+// Done reports whether the task has finished.
+func (z *ZTask) Done() bool {
+	select {
+	case <-z.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Join waits for the task to finish and returns its result.
+func (z *ZTask) Join() ZValue {
+	<-z.done
+	return z.result
+}
+
+// Result returns the result of a finished task, or an error if it is still
+// running.
+func (z *ZTask) Result() ZValue {
+	if !z.Done() {
+		return &ZError{Message: "result() task " + z.Name + " is still running"}
+	}
+	return z.result
+}
+
+func (z *ZTask) DotAccess(name string) ZValue {
+	switch name {
+	case "name":
+		return STRING(z.Name)
+	case "done":
+		return noArgsMethod(name, func() ZValue { return BOOL(z.Done()) })
+	case "join":
+		return noArgsMethod(name, z.Join)
+	case "result":
+		return noArgsMethod(name, z.Result)
+	}
+	return ERROR(fmt.Sprintf("%s has no attribute '%s'", ZTASK, name))
+}
+
+func (z *ZTask) DotAssign(name string, value ZValue) {
+	ERROR(fmt.Sprintf("cannot assign to attribute '%s' of %s", name, ZTASK))
+}
+
+func (z *ZTask) Env() *Env {
+	return &Env{SymTable: map[string]ZValue{}}
+}
@@ -2,13 +2,560 @@ package val
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
 
 	"gorgonia.org/tensor"
 )
 
+// N-dimensional array type, backed by a gorgonia dense tensor. Operations
+// never modify their operands, so tensors behave as immutable values.
 type ZTensor struct {
 	Data tensor.Tensor
 }
 
+func TENSOR(data tensor.Tensor) *ZTensor {
+	return &ZTensor{Data: data}
+}
+
 func (z *ZTensor) Type() ZValueType { return ZTENSOR }
-func (z *ZTensor) Str() string      { return fmt.Sprintf("%v", z.Data) }
+
+// Str renders the elements, followed by the dtype unless it is the default
+// float32, e.g., "[1  2  3] int64".
+func (z *ZTensor) Str() string {
+	text := fmt.Sprintf("%v", z.Data)
+	if z.Dtype() == tensor.Float32 {
+		return text
+	}
+	if strings.HasSuffix(text, "\n") {
+		return text + DtypeName(z.Dtype()) + "\n"
+	}
+	return text + " " + DtypeName(z.Dtype())
+}
+
+// Dense returns the data as a contiguous dense tensor, materializing views
+// and transpositions.
+func (z *ZTensor) Dense() *tensor.Dense {
+	d := z.Data.(*tensor.Dense)
+	if d.IsMaterializable() {
+		return d.Materialize().(*tensor.Dense)
+	}
+	return d
+}
+
+func (z *ZTensor) Shape() tensor.Shape {
+	return z.Data.Shape()
+}
+
+func (z *ZTensor) Dtype() tensor.Dtype {
+	return z.Data.Dtype()
+}
+
+// Supported element types of tensors, by name
+var TensorDtypes = map[string]tensor.Dtype{
+	"float32": tensor.Float32,
+	"float64": tensor.Float64,
+	"int32":   tensor.Int32,
+	"int64":   tensor.Int64,
+	"bool":    tensor.Bool,
+}
+
+// DtypeName returns the name of a dtype, as used in TensorDtypes.
+func DtypeName(dt tensor.Dtype) string {
+	return dt.String()
+}
+
+// Ranks dtypes for promotion. A dtype can represent all values of the dtypes
+// with a lower rank, except int64 values beyond the precision of float32.
+var dtypeRanks = map[tensor.Dtype]int{
+	tensor.Bool:    0,
+	tensor.Int32:   1,
+	tensor.Int64:   2,
+	tensor.Float32: 3,
+	tensor.Float64: 4,
+}
+
+func isFloatDtype(dt tensor.Dtype) bool {
+	return dt == tensor.Float32 || dt == tensor.Float64
+}
+
+// PromoteDtypes returns the dtype of the result of an operation between
+// tensors of dtypes a and b. Mixing integers with float32 results in float64,
+// so that no integer precision is lost.
+func PromoteDtypes(a, b tensor.Dtype) tensor.Dtype {
+	if dtypeRanks[a] < dtypeRanks[b] {
+		a, b = b, a
+	}
+	if a == tensor.Float32 && (b == tensor.Int32 || b == tensor.Int64) {
+		return tensor.Float64
+	}
+	return a
+}
+
+// Returns the dtype of the result of an operation between a tensor of dtype dt
+// and a scalar or a list. Numbers adopt the dtype of the tensor, unless floats
+// are mixed with an integer or bool tensor, or integers with a bool tensor.
+func weakDtype(dt tensor.Dtype, v ZValue) tensor.Dtype {
+	switch v := v.(type) {
+	case *ZFloat:
+		if !isFloatDtype(dt) {
+			return tensor.Float64
+		}
+	case *ZInt:
+		if dt == tensor.Bool {
+			return tensor.Int64
+		}
+	case *ZList:
+		for _, e := range v.Elements {
+			dt = weakDtype(dt, e)
+		}
+	}
+	return dt
+}
+
+// AsType returns the tensor with its elements converted to another dtype.
+func (z *ZTensor) AsType(dt tensor.Dtype) *ZTensor {
+	if z.Dtype() == dt {
+		return z
+	}
+	elements := z.Elements()
+	data := reflect.MakeSlice(reflect.SliceOf(dt.Type), len(elements), len(elements))
+	for i, e := range elements {
+		elem, _ := ToTensorElem(dt, e)
+		data.Index(i).Set(reflect.ValueOf(elem))
+	}
+	return TENSOR(tensor.New(tensor.WithShape(z.Shape()...), tensor.WithBacking(data.Interface())))
+}
+
+// Reports whether any element is zero.
+func (z *ZTensor) hasZero() bool {
+	zero := reflect.Zero(z.Dtype().Type).Interface()
+	data := reflect.ValueOf(z.Dense().Data())
+	for i := 0; i < data.Len(); i++ {
+		if data.Index(i).Interface() == zero {
+			return true
+		}
+	}
+	return false
+}
+
+// TensorElem converts an element of a tensor to a value.
+func TensorElem(x interface{}) ZValue {
+	switch x := x.(type) {
+	case float32:
+		return FLOAT(float64(x))
+	case float64:
+		return FLOAT(x)
+	case int:
+		return INT(int64(x))
+	case int32:
+		return INT(int64(x))
+	case int64:
+		return INT(x)
+	case bool:
+		return BOOL(x)
+	}
+	return ERROR(fmt.Sprintf("unsupported tensor element %T", x))
+}
+
+// ToTensorElem converts a number or a boolean to an element of the given
+// dtype. The second return value is false for other values.
+func ToTensorElem(dt tensor.Dtype, v ZValue) (interface{}, bool) {
+	var i int64
+	var f float64
+	switch v := v.(type) {
+	case *ZInt:
+		i, f = v.Value, float64(v.Value)
+	case *ZFloat:
+		i, f = int64(v.Value), v.Value
+	case *ZBool:
+		if v.Value {
+			i, f = 1, 1
+		}
+	default:
+		return nil, false
+	}
+
+	switch dt {
+	case tensor.Float32:
+		return float32(f), true
+	case tensor.Float64:
+		return f, true
+	case tensor.Int:
+		return int(i), true
+	case tensor.Int32:
+		return int32(i), true
+	case tensor.Int64:
+		return i, true
+	case tensor.Bool:
+		return f != 0, true
+	}
+	return nil, false
+}
+
+// Elements returns all elements in row-major order.
+func (z *ZTensor) Elements() []ZValue {
+	d := z.Dense()
+	if d.IsScalar() {
+		return []ZValue{TensorElem(d.ScalarValue())}
+	}
+	data := reflect.ValueOf(d.Data())
+	elements := make([]ZValue, data.Len())
+	for i := range elements {
+		elements[i] = TensorElem(data.Index(i).Interface())
+	}
+	return elements
+}
+
+// ToList converts the tensor to nested lists.
+func (z *ZTensor) ToList() ZValue {
+	elements := z.Elements()
+	if len(z.Shape()) == 0 {
+		return elements[0]
+	}
+	return nestElements(elements, z.Shape())
+}
+
+func nestElements(elements []ZValue, shape []int) *ZList {
+	if len(shape) == 1 {
+		return &ZList{Elements: elements}
+	}
+	n := len(elements) / shape[0]
+	rows := make([]ZValue, shape[0])
+	for i := range rows {
+		rows[i] = nestElements(elements[i*n:(i+1)*n], shape[1:])
+	}
+	return &ZList{Elements: rows}
+}
+
+// Returns the row-major strides of a shape.
+func strides(shape []int) []int {
+	result := make([]int, len(shape))
+	stride := 1
+	for i := len(shape) - 1; i >= 0; i-- {
+		result[i] = stride
+		stride *= shape[i]
+	}
+	return result
+}
+
+// Calls fn with all positions within a non-empty shape, in row-major order.
+func forEachPosition(shape []int, fn func(pos []int)) {
+	pos := make([]int, len(shape))
+	for {
+		fn(pos)
+
+		axis := len(pos) - 1
+		for ; axis >= 0; axis-- {
+			pos[axis]++
+			if pos[axis] < shape[axis] {
+				break
+			}
+			pos[axis] = 0
+		}
+		if axis < 0 {
+			return
+		}
+	}
+}
+
+// Returns a new tensor of the given shape, with the elements of z at the given
+// row-major indices.
+func (z *ZTensor) take(shape []int, indices []int) *ZTensor {
+	src := reflect.ValueOf(z.Dense().Data())
+	dst := reflect.MakeSlice(src.Type(), len(indices), len(indices))
+	for i, j := range indices {
+		dst.Index(i).Set(src.Index(j))
+	}
+	return TENSOR(tensor.New(tensor.WithShape(shape...), tensor.WithBacking(dst.Interface())))
+}
+
+// A range of indices along one axis. A range with Single set selects one index
+// and removes the axis from the result.
+type TensorRange struct {
+	Start, Stop, Step int
+	Single            bool
+}
+
+// Slice returns the elements within the given ranges, one range per leading
+// axis. Ranges must be normalized to the size of their axes. The result is a
+// scalar if all axes are removed.
+func (z *ZTensor) Slice(ranges []TensorRange) ZValue {
+	shape := z.Shape()
+	if len(ranges) > len(shape) {
+		return &ZError{Message: fmt.Sprintf("too many indices for tensor of shape %v", shape)}
+	}
+
+	// Axes without range are taken fully
+	full := make([]TensorRange, len(shape))
+	copy(full, ranges)
+	for i := len(ranges); i < len(shape); i++ {
+		full[i] = TensorRange{Start: 0, Stop: shape[i], Step: 1}
+	}
+
+	var resultShape []int
+	counts := make([]int, len(shape))
+	for i, r := range full {
+		n := 0
+		if r.Step > 0 && r.Stop > r.Start {
+			n = (r.Stop - r.Start + r.Step - 1) / r.Step
+		} else if r.Step < 0 && r.Stop < r.Start {
+			n = (r.Start - r.Stop - r.Step - 1) / -r.Step
+		}
+		counts[i] = n
+		if !r.Single {
+			resultShape = append(resultShape, n)
+		}
+	}
+
+	size := 1
+	for _, n := range counts {
+		size *= n
+	}
+	if size == 0 {
+		return &ZError{Message: "slice of a tensor must not be empty"}
+	}
+
+	srcStrides := strides(shape)
+	indices := make([]int, 0, size)
+	forEachPosition(counts, func(pos []int) {
+		index := 0
+		for i, p := range pos {
+			index += (full[i].Start + p*full[i].Step) * srcStrides[i]
+		}
+		indices = append(indices, index)
+	})
+
+	if len(resultShape) == 0 {
+		return z.take([]int{1}, indices).Elements()[0]
+	}
+	return z.take(resultShape, indices)
+}
+
+// Index returns the element, or the sub-tensor, at index i of the first axis.
+// Negative indices count from the end.
+func (z *ZTensor) Index(i int) ZValue {
+	n := z.Shape()[0]
+	if i < 0 {
+		i += n
+	}
+	if i < 0 || i >= n {
+		return &ZError{Message: fmt.Sprintf("index %d out of range for axis of size %d", i, n)}
+	}
+	return z.Slice([]TensorRange{{Start: i, Stop: i + 1, Step: 1, Single: true}})
+}
+
+// TensorResult returns the result of a tensor operation as a value. Results
+// with no dimensions are converted to scalars.
+func TensorResult(result tensor.Tensor, err error) ZValue {
+	if err != nil {
+		return &ZError{Message: err.Error()}
+	}
+	if d, ok := result.(*tensor.Dense); ok && d.IsScalar() {
+		return TensorElem(d.ScalarValue())
+	}
+	// Indices are returned as int, which is not a supported dtype
+	if result.Dtype() == tensor.Int {
+		return TENSOR(result).AsType(tensor.Int64)
+	}
+	return TENSOR(result)
+}
+
+// TensorFromList creates a tensor of the given dtype from (nested) lists. The
+// shape is given by the nesting, e.g., [[1, 2, 3], [4, 5, 6]] has shape (2, 3).
+func TensorFromList(list *ZList, dt tensor.Dtype) (*ZTensor, *ZError) {
+	var shape []int
+	for v := ZValue(list); v.Type() == ZLIST; v = v.(*ZList).Elements[0] {
+		if len(v.(*ZList).Elements) == 0 {
+			return nil, &ZError{Message: "cannot create a tensor from an empty list"}
+		}
+		shape = append(shape, len(v.(*ZList).Elements))
+	}
+
+	data := reflect.MakeSlice(reflect.SliceOf(dt.Type), 0, tensor.Shape(shape).TotalSize())
+	var flatten func(v ZValue, shape []int) *ZError
+	flatten = func(v ZValue, shape []int) *ZError {
+		if len(shape) == 0 {
+			elem, ok := ToTensorElem(dt, v)
+			if !ok {
+				return &ZError{Message: "cannot create a tensor from a list of " + string(v.Type())}
+			}
+			data = reflect.Append(data, reflect.ValueOf(elem))
+			return nil
+		}
+		if v.Type() != ZLIST || len(v.(*ZList).Elements) != shape[0] {
+			return &ZError{Message: "cannot create a tensor from nested lists of unequal lengths"}
+		}
+		for _, e := range v.(*ZList).Elements {
+			if err := flatten(e, shape[1:]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := flatten(list, shape); err != nil {
+		return nil, err
+	}
+	return TENSOR(tensor.New(tensor.WithShape(shape...), tensor.WithBacking(data.Interface()))), nil
+}
+
+// BroadcastShapes returns the shape of the result of an elementwise operation
+// between tensors of shapes a and b. Shapes are aligned on their last axis, and
+// each pair of dimensions must be equal, or one of them must be 1.
+func BroadcastShapes(a, b []int) ([]int, bool) {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	result := append([]int{}, a...)
+	offset := len(a) - len(b)
+	for i, n := range b {
+		switch m := a[offset+i]; {
+		case m == n || n == 1:
+		case m == 1:
+			result[offset+i] = n
+		default:
+			return nil, false
+		}
+	}
+	return result, true
+}
+
+// Returns the tensor with its elements repeated to fill a broadcast shape.
+func (z *ZTensor) broadcastTo(shape []int) *ZTensor {
+	srcShape := z.Shape()
+	if tensor.Shape(shape).Eq(srcShape) {
+		return z
+	}
+
+	// Broadcast axes have a stride of zero, so the same element is repeated
+	srcStrides := strides(srcShape)
+	offset := len(shape) - len(srcShape)
+	indices := make([]int, 0, tensor.Shape(shape).TotalSize())
+	forEachPosition(shape, func(pos []int) {
+		index := 0
+		for i, n := range srcShape {
+			if n != 1 {
+				index += pos[offset+i] * srcStrides[i]
+			}
+		}
+		indices = append(indices, index)
+	})
+	return z.take(shape, indices)
+}
+
+// An elementwise operation, either between two tensors of the same shape, or
+// between a tensor and a scalar.
+type tensorOp struct {
+	dense  func(a *tensor.Dense, b *tensor.Dense, opts ...tensor.FuncOpt) (*tensor.Dense, error)
+	scalar func(a *tensor.Dense, b interface{}, leftTensor bool, opts ...tensor.FuncOpt) (*tensor.Dense, error)
+}
+
+var tensorOps = map[string]tensorOp{
+	// Arithmetic
+	"+": {(*tensor.Dense).Add, (*tensor.Dense).AddScalar},
+	"-": {(*tensor.Dense).Sub, (*tensor.Dense).SubScalar},
+	"*": {(*tensor.Dense).Mul, (*tensor.Dense).MulScalar},
+	"/": {(*tensor.Dense).Div, (*tensor.Dense).DivScalar},
+	"%": {(*tensor.Dense).Mod, (*tensor.Dense).ModScalar},
+	// Comparison, resulting in bool tensors
+	"==": {(*tensor.Dense).ElEq, (*tensor.Dense).ElEqScalar},
+	"!=": {(*tensor.Dense).ElNe, (*tensor.Dense).ElNeScalar},
+	"<":  {(*tensor.Dense).Lt, (*tensor.Dense).LtScalar},
+	">":  {(*tensor.Dense).Gt, (*tensor.Dense).GtScalar},
+	"<=": {(*tensor.Dense).Lte, (*tensor.Dense).LteScalar},
+	">=": {(*tensor.Dense).Gte, (*tensor.Dense).GteScalar},
+}
+
+// Reports whether an operator is a comparison, which results in a bool tensor.
+func isComparison(operator string) bool {
+	switch operator {
+	case "==", "!=", "<", ">", "<=", ">=":
+		return true
+	}
+	return false
+}
+
+// Elementwise evaluates `z <operator> other`, or `other <operator> z` if
+// tensorLeft is false. The other operand is a tensor or a list, which are
+// broadcast against z, or a scalar. Operands are converted to a common dtype
+// first, see PromoteDtypes.
+func (z *ZTensor) Elementwise(operator string, other ZValue, tensorLeft bool) ZValue {
+	op, ok := tensorOps[operator]
+	if !ok {
+		return ERROR(fmt.Sprintf("Operator %s not supported for %s and %s", operator, ZTENSOR, other.Type()))
+	}
+
+	// Find the common dtype. Bools are counted as integers in arithmetic.
+	var dt tensor.Dtype
+	if o, ok := other.(*ZTensor); ok {
+		dt = PromoteDtypes(z.Dtype(), o.Dtype())
+	} else {
+		dt = weakDtype(z.Dtype(), other)
+	}
+	if dt == tensor.Bool && !isComparison(operator) {
+		dt = tensor.Int64
+	}
+	if dt == tensor.Bool && operator != "==" && operator != "!=" {
+		return &ZError{Message: fmt.Sprintf("Operator %s not supported for bool tensors", operator)}
+	}
+
+	if list, ok := other.(*ZList); ok {
+		t, err := TensorFromList(list, dt)
+		if err != nil {
+			return err
+		}
+		other = t
+	}
+	z = z.AsType(dt)
+
+	// Integer division by zero is an error, as it is for Int values
+	divisor := other
+	if !tensorLeft {
+		divisor = z
+	}
+	if (operator == "/" || operator == "%") && !isFloatDtype(dt) {
+		if d, ok := divisor.(*ZTensor); ok && d.AsType(dt).hasZero() {
+			return &ZError{Message: "division by zero"}
+		}
+		if elem, ok := ToTensorElem(dt, divisor); ok && elem == reflect.Zero(dt.Type).Interface() {
+			return &ZError{Message: "division by zero"}
+		}
+	}
+
+	if o, ok := other.(*ZTensor); ok {
+		o = o.AsType(dt)
+		shape, ok := BroadcastShapes(z.Shape(), o.Shape())
+		if !ok {
+			left, right := z.Shape(), o.Shape()
+			if !tensorLeft {
+				left, right = right, left
+			}
+			return &ZError{Message: fmt.Sprintf("cannot broadcast shapes %v and %v for `%s`", left, right, operator)}
+		}
+		a, b := z.broadcastTo(shape).Dense(), o.broadcastTo(shape).Dense()
+		if !tensorLeft {
+			a, b = b, a
+		}
+		return TensorResult(op.dense(a, b))
+	}
+
+	scalar, ok := ToTensorElem(dt, other)
+	if !ok {
+		return ERROR(fmt.Sprintf("type mismatch: %s %s %s", ZTENSOR, operator, other.Type()))
+	}
+	return TensorResult(op.scalar(z.Dense(), scalar, tensorLeft))
+}
+
+func (z *ZTensor) Add(other ZValue) ZValue { return z.Elementwise("+", other, true) }
+func (z *ZTensor) Sub(other ZValue) ZValue { return z.Elementwise("-", other, true) }
+func (z *ZTensor) Mul(other ZValue) ZValue { return z.Elementwise("*", other, true) }
+func (z *ZTensor) Div(other ZValue) ZValue { return z.Elementwise("/", other, true) }
+func (z *ZTensor) Mod(other ZValue) ZValue { return z.Elementwise("%", other, true) }
+
+func (z *ZTensor) Equals(other ZValue) ZValue            { return z.Elementwise("==", other, true) }
+func (z *ZTensor) NotEquals(other ZValue) ZValue         { return z.Elementwise("!=", other, true) }
+func (z *ZTensor) LessThan(other ZValue) ZValue          { return z.Elementwise("<", other, true) }
+func (z *ZTensor) GreaterThan(other ZValue) ZValue       { return z.Elementwise(">", other, true) }
+func (z *ZTensor) LessThanEquals(other ZValue) ZValue    { return z.Elementwise("<=", other, true) }
+func (z *ZTensor) GreaterThanEquals(other ZValue) ZValue { return z.Elementwise(">=", other, true) }
@@ -0,0 +1,305 @@
+package val
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Time type, an instant with a time zone, returned by the time module. Times
+// are compared as instants, regardless of their zones. Adding or subtracting
+// a duration gives a time, and subtracting two times gives a duration.
+type ZTime struct {
+	Value time.Time
+}
+
+func TIME(t time.Time) *ZTime {
+	return &ZTime{Value: t}
+}
+
+func (z *ZTime) Type() ZValueType { return ZTIME }
+func (z *ZTime) Str() string      { return z.Value.Format(time.RFC3339Nano) }
+
+// Compare returns -1, 0 or 1 if the time is before, at or after another time,
+// and false if the other value is not a time.
+func (z *ZTime) Compare(other ZValue) (int, bool) {
+	o, ok := other.(*ZTime)
+	if !ok {
+		return 0, false
+	}
+	switch {
+	case z.Value.Before(o.Value):
+		return -1, true
+	case z.Value.After(o.Value):
+		return 1, true
+	}
+	return 0, true
+}
+
+func (z *ZTime) Add(other ZValue) ZValue {
+	if d, ok := other.(*ZDuration); ok {
+		return TIME(z.Value.Add(d.Value))
+	}
+	return ERROR(fmt.Sprintf("Operator '+' not defined for %s and %s", z.Type(), other.Type()))
+}
+
+func (z *ZTime) Sub(other ZValue) ZValue {
+	switch o := other.(type) {
+	case *ZDuration:
+		return TIME(z.Value.Add(-o.Value))
+	case *ZTime:
+		return DURATION(z.Value.Sub(o.Value))
+	}
+	return ERROR(fmt.Sprintf("Operator '-' not defined for %s and %s", z.Type(), other.Type()))
+}
+
+func (z *ZTime) Mul(other ZValue) ZValue {
+	return ERROR(fmt.Sprintf("Operator '*' not defined for %s and %s", z.Type(), other.Type()))
+}
+
+func (z *ZTime) Div(other ZValue) ZValue {
+	return ERROR(fmt.Sprintf("Operator '/' not defined for %s and %s", z.Type(), other.Type()))
+}
+
+func (z *ZTime) Mod(other ZValue) ZValue {
+	return ERROR(fmt.Sprintf("Operator '%%' not defined for %s and %s", z.Type(), other.Type()))
+}
+
+func (z *ZTime) Equals(other ZValue) ZValue {
+	cmp, ok := z.Compare(other)
+	return BOOL(ok && cmp == 0)
+}
+
+func (z *ZTime) NotEquals(other ZValue) ZValue {
+	cmp, ok := z.Compare(other)
+	return BOOL(!ok || cmp != 0)
+}
+
+func (z *ZTime) LessThan(other ZValue) ZValue {
+	return compareResult("<", z, other, func(cmp int) bool { return cmp < 0 })
+}
+
+func (z *ZTime) GreaterThan(other ZValue) ZValue {
+	return compareResult(">", z, other, func(cmp int) bool { return cmp > 0 })
+}
+
+func (z *ZTime) LessThanEquals(other ZValue) ZValue {
+	return compareResult("<=", z, other, func(cmp int) bool { return cmp <= 0 })
+}
+
+func (z *ZTime) GreaterThanEquals(other ZValue) ZValue {
+	return compareResult(">=", z, other, func(cmp int) bool { return cmp >= 0 })
+}
+
+func (z *ZTime) DotAccess(name string) ZValue {
+	t := z.Value
+	switch name {
+	case "year":
+		return INT(int64(t.Year()))
+	case "month":
+		return INT(int64(t.Month()))
+	case "day":
+		return INT(int64(t.Day()))
+	case "hour":
+		return INT(int64(t.Hour()))
+	case "minute":
+		return INT(int64(t.Minute()))
+	case "second":
+		return INT(int64(t.Second()))
+	case "nanosecond":
+		return INT(int64(t.Nanosecond()))
+	case "weekday":
+		return STRING(t.Weekday().String())
+	case "yearday":
+		return INT(int64(t.YearDay()))
+	case "zone":
+		return STRING(t.Location().String())
+	case "offset":
+		_, offset := t.Zone()
+		return INT(int64(offset))
+	case "unix":
+		return INT(t.Unix())
+	case "unix_ms":
+		return INT(t.UnixMilli())
+	case "unix_nano":
+		return INT(t.UnixNano())
+	}
+	return ERROR(fmt.Sprintf("%s has no attribute '%s'", ZTIME, name))
+}
+
+func (z *ZTime) DotAssign(name string, value ZValue) {
+	ERROR(fmt.Sprintf("cannot assign to attribute '%s' of %s", name, ZTIME))
+}
+
+func (z *ZTime) Env() *Env {
+	return &Env{SymTable: map[string]ZValue{}}
+}
+
+// Duration type, an elapsed time with nanosecond precision. Durations can be
+// added and subtracted, multiplied or divided by numbers, and divided by
+// another duration to get their ratio.
+type ZDuration struct {
+	Value time.Duration
+}
+
+func DURATION(d time.Duration) *ZDuration {
+	return &ZDuration{Value: d}
+}
+
+func (z *ZDuration) Type() ZValueType { return ZDURATION }
+func (z *ZDuration) Str() string      { return z.Value.String() }
+
+func (z *ZDuration) Neg() *ZDuration {
+	return DURATION(-z.Value)
+}
+
+// Compare returns -1, 0 or 1 if the duration is shorter, equal or longer than
+// another duration, and false if the other value is not a duration.
+func (z *ZDuration) Compare(other ZValue) (int, bool) {
+	o, ok := other.(*ZDuration)
+	if !ok {
+		return 0, false
+	}
+	switch {
+	case z.Value < o.Value:
+		return -1, true
+	case z.Value > o.Value:
+		return 1, true
+	}
+	return 0, true
+}
+
+// Returns the value of an Int or a Float operand.
+func durationFactor(other ZValue) (float64, bool) {
+	switch o := other.(type) {
+	case *ZInt:
+		return float64(o.Value), true
+	case *ZFloat:
+		return o.Value, true
+	}
+	return 0, false
+}
+
+// Returns a duration from a number of nanoseconds, or an error if it does not
+// fit in a duration.
+func scaledDuration(ns float64) ZValue {
+	if math.IsNaN(ns) || ns >= math.MaxInt64 || ns < math.MinInt64 {
+		return &ZError{Message: "duration out of range"}
+	}
+	return DURATION(time.Duration(math.Round(ns)))
+}
+
+func (z *ZDuration) Add(other ZValue) ZValue {
+	switch o := other.(type) {
+	case *ZDuration:
+		return DURATION(z.Value + o.Value)
+	case *ZTime:
+		return TIME(o.Value.Add(z.Value))
+	}
+	return ERROR(fmt.Sprintf("Operator '+' not defined for %s and %s", z.Type(), other.Type()))
+}
+
+func (z *ZDuration) Sub(other ZValue) ZValue {
+	if o, ok := other.(*ZDuration); ok {
+		return DURATION(z.Value - o.Value)
+	}
+	return ERROR(fmt.Sprintf("Operator '-' not defined for %s and %s", z.Type(), other.Type()))
+}
+
+func (z *ZDuration) Mul(other ZValue) ZValue {
+	if factor, ok := durationFactor(other); ok {
+		return scaledDuration(float64(z.Value) * factor)
+	}
+	return ERROR(fmt.Sprintf("Operator '*' not defined for %s and %s", z.Type(), other.Type()))
+}
+
+func (z *ZDuration) Div(other ZValue) ZValue {
+	if o, ok := other.(*ZDuration); ok {
+		if o.Value == 0 {
+			return &ZError{Message: "division by zero"}
+		}
+		return FLOAT(float64(z.Value) / float64(o.Value))
+	}
+	if factor, ok := durationFactor(other); ok {
+		if factor == 0 {
+			return &ZError{Message: "division by zero"}
+		}
+		return scaledDuration(float64(z.Value) / factor)
+	}
+	return ERROR(fmt.Sprintf("Operator '/' not defined for %s and %s", z.Type(), other.Type()))
+}
+
+func (z *ZDuration) Mod(other ZValue) ZValue {
+	if o, ok := other.(*ZDuration); ok {
+		if o.Value == 0 {
+			return &ZError{Message: "division by zero"}
+		}
+		return DURATION(z.Value % o.Value)
+	}
+	return ERROR(fmt.Sprintf("Operator '%%' not defined for %s and %s", z.Type(), other.Type()))
+}
+
+func (z *ZDuration) Equals(other ZValue) ZValue {
+	cmp, ok := z.Compare(other)
+	return BOOL(ok && cmp == 0)
+}
+
+func (z *ZDuration) NotEquals(other ZValue) ZValue {
+	cmp, ok := z.Compare(other)
+	return BOOL(!ok || cmp != 0)
+}
+
+func (z *ZDuration) LessThan(other ZValue) ZValue {
+	return compareResult("<", z, other, func(cmp int) bool { return cmp < 0 })
+}
+
+func (z *ZDuration) GreaterThan(other ZValue) ZValue {
+	return compareResult(">", z, other, func(cmp int) bool { return cmp > 0 })
+}
+
+func (z *ZDuration) LessThanEquals(other ZValue) ZValue {
+	return compareResult("<=", z, other, func(cmp int) bool { return cmp <= 0 })
+}
+
+func (z *ZDuration) GreaterThanEquals(other ZValue) ZValue {
+	return compareResult(">=", z, other, func(cmp int) bool { return cmp >= 0 })
+}
+
+func (z *ZDuration) DotAccess(name string) ZValue {
+	switch name {
+	case "hours":
+		return FLOAT(z.Value.Hours())
+	case "minutes":
+		return FLOAT(z.Value.Minutes())
+	case "seconds":
+		return FLOAT(z.Value.Seconds())
+	case "milliseconds":
+		return INT(z.Value.Milliseconds())
+	case "microseconds":
+		return INT(z.Value.Microseconds())
+	case "nanoseconds":
+		return INT(z.Value.Nanoseconds())
+	}
+	return ERROR(fmt.Sprintf("%s has no attribute '%s'", ZDURATION, name))
+}
+
+func (z *ZDuration) DotAssign(name string, value ZValue) {
+	ERROR(fmt.Sprintf("cannot assign to attribute '%s' of %s", name, ZDURATION))
+}
+
+func (z *ZDuration) Env() *Env {
+	return &Env{SymTable: map[string]ZValue{}}
+}
+
+// Returns the result of an ordering operator on two values of the same type,
+// or an error if the other value has a different type.
+func compareResult(operator string, z interface {
+	ZValue
+	Compare(ZValue) (int, bool)
+}, other ZValue, test func(int) bool) ZValue {
+	cmp, ok := z.Compare(other)
+	if !ok {
+		return ERROR(fmt.Sprintf("Operator '%s' not defined for %s and %s", operator, z.Type(), other.Type()))
+	}
+	return BOOL(test(cmp))
+}
@@ -1,33 +1,59 @@
 package val
 
-import "github.com/ariaghora/zmol/pkg/ast"
+import (
+	"sync"
+
+	"github.com/ariaghora/zmol/pkg/ast"
+)
 
 type ZValueType string
 
 const (
 	ZINT        ZValueType = "Int"
+	ZBIGINT     ZValueType = "BigInt"
 	ZFLOAT      ZValueType = "Float"
+	ZDECIMAL    ZValueType = "Decimal"
 	ZBOOL       ZValueType = "Bool"
 	ZLIST       ZValueType = "List"
 	ZCLASS      ZValueType = "Class"
 	ZOBJECT     ZValueType = "Object"
+	ZTABLE      ZValueType = "Table"
 	ZTENSOR     ZValueType = "Tensor"
+	ZVARIABLE   ZValueType = "Variable"
+	ZFILE       ZValueType = "File"
+	ZREGEX      ZValueType = "Regex"
+	ZTIME       ZValueType = "Time"
+	ZDURATION   ZValueType = "Duration"
+	ZTASK       ZValueType = "Task"
+	ZCHANNEL    ZValueType = "Channel"
+	ZMUTEX      ZValueType = "Mutex"
+	ZWAITGROUP  ZValueType = "WaitGroup"
 	ZERROR      ZValueType = "Error"
 	ZSTRING     ZValueType = "String"
+	ZBYTES      ZValueType = "Bytes"
 	ZFUNCTION   ZValueType = "Function"
 	ZNATIVE     ZValueType = "BuiltinFunction"
 	ZNULL       ZValueType = "Null"
 	ZMODULE     ZValueType = "Module"
 	ZMODULEFUNC ZValueType = "ModuleFunction"
+	ZITERATOR   ZValueType = "Iterator"
 )
 
+// Env is a scope of variables. It is safe for concurrent use, as closures
+// running on spawned tasks read and write the environments they were defined
+// in. The SymTable can only be accessed directly before the environment is
+// shared, e.g., to define the symbols of a module.
 type Env struct {
 	SymTable  map[string]ZValue
 	ParentEnv *Env
+
+	mu sync.RWMutex
 }
 
 func (e *Env) Get(name string) (ZValue, bool) {
+	e.mu.RLock()
 	obj, ok := e.SymTable[name]
+	e.mu.RUnlock()
 	if !ok && e.ParentEnv != nil {
 		return e.ParentEnv.Get(name)
 	}
@@ -35,10 +61,24 @@ func (e *Env) Get(name string) (ZValue, bool) {
 }
 
 func (e *Env) Set(name string, val ZValue) ZValue {
+	e.mu.Lock()
 	e.SymTable[name] = val
+	e.mu.Unlock()
 	return val
 }
 
+// Symbols returns a copy of the variables defined in this scope, not in its
+// parents.
+func (e *Env) Symbols() map[string]ZValue {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	symbols := make(map[string]ZValue, len(e.SymTable))
+	for name, value := range e.SymTable {
+		symbols[name] = value
+	}
+	return symbols
+}
+
 // The ZValue interface is implemented by all types that can be used as values
 type ZValue interface {
 	Type() ZValueType
@@ -72,6 +112,16 @@ type ZDotAccessable interface {
 	Env() *Env
 }
 
+// Returns a method value, for DotAccess, that takes no arguments.
+func noArgsMethod(name string, fn func() ZValue) *ZNativeFunc {
+	return &ZNativeFunc{Fn: func(args ...ZValue) ZValue {
+		if len(args) != 0 {
+			return &ZError{Message: name + "() takes no arguments"}
+		}
+		return fn()
+	}}
+}
+
 type ZCallable interface {
 	ZValue
 	Params() []*ast.Identifier
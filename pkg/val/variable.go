@@ -0,0 +1,398 @@
+package val
+
+import (
+	"fmt"
+	"math"
+
+	"gorgonia.org/tensor"
+)
+
+// Variable type for reverse-mode automatic differentiation. A variable holds a
+// Float or a float64 tensor. Operations on variables record how each result
+// was computed, so that Backward can propagate gradients from a result back to
+// the variables that require them.
+type ZVariable struct {
+	data  []float64
+	shape []int // empty for a Float
+	grad  []float64
+
+	RequiresGrad bool
+
+	// The operands of the operation that computed this variable, and the
+	// function that adds their gradients given the gradient of this variable.
+	// Gradients of operands that do not require them are nil.
+	parents  []*ZVariable
+	backward func(grad []float64, parentGrads [][]float64)
+}
+
+// VARIABLE returns a variable that requires gradients, holding a copy of an
+// Int, a Float, a tensor or a list. The second return value is false for other types.
+func VARIABLE(v ZValue) (*ZVariable, bool) {
+	if _, ok := v.(*ZVariable); ok {
+		return nil, false
+	}
+	z, ok := ToVariable(v)
+	if !ok {
+		return nil, false
+	}
+	return &ZVariable{data: append([]float64{}, z.data...), shape: z.shape, RequiresGrad: true}, true
+}
+
+// ToVariable converts an Int, a Float, a tensor or a list to a constant
+// variable, and returns variables as they are.
+func ToVariable(v ZValue) (*ZVariable, bool) {
+	switch v := v.(type) {
+	case *ZVariable:
+		return v, true
+	case *ZInt:
+		return &ZVariable{data: []float64{float64(v.Value)}}, true
+	case *ZFloat:
+		return &ZVariable{data: []float64{v.Value}}, true
+	case *ZTensor:
+		data := v.AsType(tensor.Float64).Dense().Data().([]float64)
+		return &ZVariable{data: data, shape: append([]int{}, v.Shape()...)}, true
+	case *ZList:
+		t, err := TensorFromList(v, tensor.Float64)
+		if err != nil {
+			return nil, false
+		}
+		return ToVariable(t)
+	}
+	return nil, false
+}
+
+func (z *ZVariable) Type() ZValueType { return ZVARIABLE }
+func (z *ZVariable) Str() string      { return fmt.Sprintf("<%s %s>", z.Type(), z.Value().Str()) }
+
+// Value returns the value as a Float, or as a float64 tensor.
+func (z *ZVariable) Value() ZValue {
+	return fromFloats(z.data, z.shape)
+}
+
+// Grad returns the gradient accumulated by Backward, with the shape of the
+// value, or null if there is none.
+func (z *ZVariable) Grad() ZValue {
+	if z.grad == nil {
+		return NULL()
+	}
+	return fromFloats(z.grad, z.shape)
+}
+
+func (z *ZVariable) isLeaf() bool {
+	return z.RequiresGrad && z.parents == nil
+}
+
+func fromFloats(data []float64, shape []int) ZValue {
+	if len(shape) == 0 {
+		return FLOAT(data[0])
+	}
+	backing := append([]float64{}, data...)
+	return TENSOR(tensor.New(tensor.WithShape(shape...), tensor.WithBacking(backing)))
+}
+
+// Returns the result of an operation on the given operands, recording the
+// operands only if any of them requires gradients.
+func newResult(data []float64, shape []int, parents []*ZVariable, backward func([]float64, [][]float64)) *ZVariable {
+	result := &ZVariable{data: data, shape: shape}
+	for _, p := range parents {
+		if p.RequiresGrad {
+			result.RequiresGrad = true
+			result.parents = parents
+			result.backward = backward
+			break
+		}
+	}
+	return result
+}
+
+// Returns, for each position of a broadcast shape, the index of the element
+// of src that is broadcast there.
+func broadcastIndices(src, shape []int) []int {
+	srcStrides := strides(src)
+	offset := len(shape) - len(src)
+	indices := []int{}
+	forEachPosition(shape, func(pos []int) {
+		index := 0
+		for i, n := range src {
+			if n != 1 {
+				index += pos[offset+i] * srcStrides[i]
+			}
+		}
+		indices = append(indices, index)
+	})
+	return indices
+}
+
+// Applies an elementwise operation to two broadcast operands. The derivative
+// function returns the gradients of both operands at one position, given the
+// operands a and b, the result, and the gradient g of the result.
+func (z *ZVariable) binary(operator string, other ZValue, f func(a, b float64) float64, df func(a, b, out, g float64) (float64, float64)) ZValue {
+	o, ok := ToVariable(other)
+	if !ok {
+		return ERROR(fmt.Sprintf("type mismatch: %s %s %s", ZVARIABLE, operator, other.Type()))
+	}
+	shape, ok := BroadcastShapes(z.shape, o.shape)
+	if !ok {
+		return &ZError{Message: fmt.Sprintf("cannot broadcast shapes %v and %v for `%s`", tensor.Shape(z.shape), tensor.Shape(o.shape), operator)}
+	}
+	ai, bi := broadcastIndices(z.shape, shape), broadcastIndices(o.shape, shape)
+
+	a, b := z.data, o.data
+	out := make([]float64, len(ai))
+	for k := range out {
+		out[k] = f(a[ai[k]], b[bi[k]])
+	}
+	return newResult(out, shape, []*ZVariable{z, o}, func(grad []float64, parentGrads [][]float64) {
+		ga, gb := parentGrads[0], parentGrads[1]
+		for k, g := range grad {
+			da, db := df(a[ai[k]], b[bi[k]], out[k], g)
+			if ga != nil {
+				ga[ai[k]] += da
+			}
+			if gb != nil {
+				gb[bi[k]] += db
+			}
+		}
+	})
+}
+
+// Applies an elementwise function. The derivative function returns the
+// derivative at x, given the result.
+func (z *ZVariable) Map(f func(x float64) float64, df func(x, out float64) float64) *ZVariable {
+	x := z.data
+	out := make([]float64, len(x))
+	for k := range out {
+		out[k] = f(x[k])
+	}
+	return newResult(out, z.shape, []*ZVariable{z}, func(grad []float64, parentGrads [][]float64) {
+		for k, g := range grad {
+			parentGrads[0][k] += g * df(x[k], out[k])
+		}
+	})
+}
+
+func (z *ZVariable) Add(other ZValue) ZValue {
+	return z.binary("+", other,
+		func(a, b float64) float64 { return a + b },
+		func(a, b, out, g float64) (float64, float64) { return g, g })
+}
+
+func (z *ZVariable) Sub(other ZValue) ZValue {
+	return z.binary("-", other,
+		func(a, b float64) float64 { return a - b },
+		func(a, b, out, g float64) (float64, float64) { return g, -g })
+}
+
+func (z *ZVariable) Mul(other ZValue) ZValue {
+	return z.binary("*", other,
+		func(a, b float64) float64 { return a * b },
+		func(a, b, out, g float64) (float64, float64) { return g * b, g * a })
+}
+
+func (z *ZVariable) Div(other ZValue) ZValue {
+	return z.binary("/", other,
+		func(a, b float64) float64 { return a / b },
+		func(a, b, out, g float64) (float64, float64) { return g / b, -g * a / (b * b) })
+}
+
+// Pow raises to a power. The gradient of the exponent is taken as zero where
+// the base is not positive.
+func (z *ZVariable) Pow(other ZValue) ZValue {
+	return z.binary("**", other, math.Pow,
+		func(a, b, out, g float64) (float64, float64) {
+			da, db := 0.0, 0.0
+			if b != 0 {
+				da = g * b * math.Pow(a, b-1)
+			}
+			if a > 0 {
+				db = g * out * math.Log(a)
+			}
+			return da, db
+		})
+}
+
+func (z *ZVariable) Mod(other ZValue) ZValue {
+	return ERROR(fmt.Sprintf("Operator %% not supported for %s and %s", ZVARIABLE, other.Type()))
+}
+
+func (z *ZVariable) Neg() *ZVariable {
+	return z.Map(func(x float64) float64 { return -x }, func(x, out float64) float64 { return -1 })
+}
+
+// Sum returns the sum of all elements.
+func (z *ZVariable) Sum() *ZVariable {
+	sum := 0.0
+	for _, x := range z.data {
+		sum += x
+	}
+	return newResult([]float64{sum}, nil, []*ZVariable{z}, func(grad []float64, parentGrads [][]float64) {
+		for k := range parentGrads[0] {
+			parentGrads[0][k] += grad[0]
+		}
+	})
+}
+
+// Mean returns the mean of all elements.
+func (z *ZVariable) Mean() *ZVariable {
+	return z.Sum().Div(FLOAT(float64(len(z.data)))).(*ZVariable)
+}
+
+// MatMul returns the matrix product. Either operand may be a vector, which is
+// treated as a row on the left and as a column on the right.
+func (z *ZVariable) MatMul(other *ZVariable) ZValue {
+	aShape, bShape := z.shape, other.shape
+	if len(aShape) == 1 {
+		aShape = []int{1, aShape[0]}
+	}
+	if len(bShape) == 1 {
+		bShape = []int{bShape[0], 1}
+	}
+	if len(aShape) != 2 || len(bShape) != 2 || aShape[1] != bShape[0] {
+		return &ZError{Message: fmt.Sprintf("cannot multiply shapes %v and %v", tensor.Shape(z.shape), tensor.Shape(other.shape))}
+	}
+	m, n, p := aShape[0], aShape[1], bShape[1]
+
+	var shape []int
+	if len(z.shape) == 2 {
+		shape = append(shape, m)
+	}
+	if len(other.shape) == 2 {
+		shape = append(shape, p)
+	}
+
+	a, b := z.data, other.data
+	out := make([]float64, m*p)
+	for i := 0; i < m; i++ {
+		for k := 0; k < n; k++ {
+			for j := 0; j < p; j++ {
+				out[i*p+j] += a[i*n+k] * b[k*p+j]
+			}
+		}
+	}
+	return newResult(out, shape, []*ZVariable{z, other}, func(grad []float64, parentGrads [][]float64) {
+		ga, gb := parentGrads[0], parentGrads[1]
+		for i := 0; i < m; i++ {
+			for k := 0; k < n; k++ {
+				for j := 0; j < p; j++ {
+					g := grad[i*p+j]
+					if ga != nil {
+						ga[i*n+k] += g * b[k*p+j]
+					}
+					if gb != nil {
+						gb[k*p+j] += g * a[i*n+k]
+					}
+				}
+			}
+		}
+	})
+}
+
+// Backward computes the gradient of a scalar variable with respect to every
+// variable it was computed from, and adds it to their gradients.
+func (z *ZVariable) Backward() ZValue {
+	if len(z.data) != 1 {
+		return &ZError{Message: fmt.Sprintf("backward() takes a single value, got shape %v, reduce it with sum() or mean() first", tensor.Shape(z.shape))}
+	}
+	if !z.RequiresGrad {
+		return &ZError{Message: "backward() takes a variable computed from variables"}
+	}
+
+	// Visit the operations in reverse topological order, so that the gradient
+	// of each variable is complete before it is propagated to its operands
+	order := []*ZVariable{}
+	visited := map[*ZVariable]bool{}
+	var visit func(v *ZVariable)
+	visit = func(v *ZVariable) {
+		if visited[v] || !v.RequiresGrad {
+			return
+		}
+		visited[v] = true
+		for _, p := range v.parents {
+			visit(p)
+		}
+		order = append(order, v)
+	}
+	visit(z)
+
+	grads := map[*ZVariable][]float64{z: {1}}
+	for i := len(order) - 1; i >= 0; i-- {
+		v := order[i]
+		grad := grads[v]
+		if v.isLeaf() {
+			if v.grad == nil {
+				v.grad = make([]float64, len(v.data))
+			}
+			for k, g := range grad {
+				v.grad[k] += g
+			}
+			continue
+		}
+
+		parentGrads := make([][]float64, len(v.parents))
+		for j, p := range v.parents {
+			if !p.RequiresGrad {
+				continue
+			}
+			if grads[p] == nil {
+				grads[p] = make([]float64, len(p.data))
+			}
+			parentGrads[j] = grads[p]
+		}
+		v.backward(grad, parentGrads)
+	}
+	return NULL()
+}
+
+func (z *ZVariable) DotAccess(name string) ZValue {
+	switch name {
+	case "value":
+		return z.Value()
+	case "grad":
+		return z.Grad()
+	case "requires_grad":
+		return BOOL(z.RequiresGrad)
+	case "backward":
+		return &ZNativeFunc{Fn: func(args ...ZValue) ZValue {
+			if len(args) != 0 {
+				return &ZError{Message: "backward() takes no arguments"}
+			}
+			return z.Backward()
+		}}
+	case "zero_grad":
+		return &ZNativeFunc{Fn: func(args ...ZValue) ZValue {
+			if len(args) != 0 {
+				return &ZError{Message: "zero_grad() takes no arguments"}
+			}
+			z.grad = nil
+			return NULL()
+		}}
+	}
+	return ERROR(fmt.Sprintf("%s has no attribute '%s'", ZVARIABLE, name))
+}
+
+// DotAssign updates the value of a variable created with grad.var(), e.g.,
+// by an optimizer step. The gradient is kept.
+func (z *ZVariable) DotAssign(name string, value ZValue) {
+	if name != "value" {
+		ERROR(fmt.Sprintf("cannot assign to attribute '%s' of %s", name, ZVARIABLE))
+		return
+	}
+	if !z.isLeaf() {
+		ERROR("can only assign the value of a variable created with var()")
+		return
+	}
+	v, ok := ToVariable(value)
+	if !ok || v.RequiresGrad {
+		ERROR(fmt.Sprintf("cannot assign %s to the value of a %s", value.Type(), ZVARIABLE))
+		return
+	}
+	if fmt.Sprint(v.shape) != fmt.Sprint(z.shape) {
+		ERROR(fmt.Sprintf("cannot assign a value of shape %v to a variable of shape %v", tensor.Shape(v.shape), tensor.Shape(z.shape)))
+		return
+	}
+	z.data = append([]float64{}, v.data...)
+}
+
+func (z *ZVariable) Env() *Env {
+	return &Env{SymTable: map[string]ZValue{}}
+}